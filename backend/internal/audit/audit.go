@@ -0,0 +1,219 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"taskman-backend/internal/middleware"
+	"taskman-backend/internal/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Audit action constants
+const (
+	ActionLogin                 = "auth.login"
+	ActionLoginFailed           = "auth.login_failed"
+	ActionMemberJoined          = "org.member_joined"
+	ActionMemberRemoved         = "org.member_removed"
+	ActionMemberRoleChanged     = "org.member_role_changed"
+	ActionInviteRegenerated     = "org.invite_regenerated"
+	ActionOrganizationUpdated   = "org.updated"
+	ActionProjectDeleted        = "project.deleted"
+	ActionAPITokenCreated       = "auth.token_created"
+	ActionAPITokenRevoked       = "auth.token_revoked"
+	ActionServiceAccountAdded   = "org.service_account_created"
+	ActionServiceAccountRemoved = "org.service_account_deleted"
+	ActionTwoFactorEnabled      = "auth.2fa_enabled"
+	ActionTwoFactorDisabled     = "auth.2fa_disabled"
+	ActionLoginLocked           = "auth.login_locked"
+)
+
+// Audit target type constants
+const (
+	TargetUser           = "user"
+	TargetOrganization   = "organization"
+	TargetProject        = "project"
+	TargetAPIToken       = "api_token"
+	TargetServiceAccount = "service_account"
+)
+
+// Pagination defaults for listing audit entries
+const (
+	DefaultPerPage = 50
+	MaxPerPage     = 500
+)
+
+// Entry describes an event to be written to the audit trail
+type Entry struct {
+	OrgID      *uuid.UUID
+	ActorID    *uuid.UUID
+	ActorEmail string
+	Action     string
+	TargetType string
+	TargetID   *uuid.UUID
+	Metadata   map[string]interface{}
+}
+
+// Logger writes and reads audit trail entries
+type Logger struct {
+	db *gorm.DB
+}
+
+// NewLogger creates a new audit logger
+func NewLogger(db *gorm.DB) *Logger {
+	return &Logger{db: db}
+}
+
+// Record writes an entry to the audit trail. Failures are logged rather than
+// returned so that auditing never breaks the request being audited.
+func (l *Logger) Record(entry Entry, ipAddress, userAgent string) {
+	record := &models.AuditLog{
+		OrgID:      entry.OrgID,
+		ActorID:    entry.ActorID,
+		ActorEmail: entry.ActorEmail,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+		CreatedAt:  time.Now(),
+	}
+
+	if len(entry.Metadata) > 0 {
+		metadata, err := json.Marshal(entry.Metadata)
+		if err != nil {
+			log.Printf("Failed to encode audit metadata for %s: %v", entry.Action, err)
+		} else {
+			record.Metadata = metadata
+		}
+	}
+
+	if err := l.db.Create(record).Error; err != nil {
+		log.Printf("Failed to record audit entry %s: %v", entry.Action, err)
+	}
+}
+
+// RecordRequest writes an entry to the audit trail using the request for the
+// client address, user agent and, when not already set, the acting user
+func (l *Logger) RecordRequest(c *fiber.Ctx, entry Entry) {
+	if entry.ActorID == nil {
+		if userID, err := middleware.GetUserIDFromContext(c); err == nil {
+			entry.ActorID = &userID
+		}
+	}
+	if entry.ActorEmail == "" {
+		if email, err := middleware.GetUserEmailFromContext(c); err == nil {
+			entry.ActorEmail = email
+		}
+	}
+
+	l.Record(entry, c.IP(), c.Get(fiber.HeaderUserAgent))
+}
+
+// List retrieves a page of audit entries visible to an organization, newest first
+func (l *Logger) List(orgID uuid.UUID, filter *models.AuditLogFilter) ([]models.AuditLog, int64, error) {
+	var total int64
+	if err := l.scope(orgID, filter).Model(&models.AuditLog{}).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	page, perPage := NormalizePage(filter.Page, filter.PerPage)
+
+	var entries []models.AuditLog
+	err := l.scope(orgID, filter).
+		Select(visibleColumns).
+		Order("created_at DESC").
+		Limit(perPage).
+		Offset((page - 1) * perPage).
+		Find(&entries).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get audit entries: %w", err)
+	}
+
+	return entries, total, nil
+}
+
+// Each calls fn for every audit entry matching the filter, oldest first,
+// without loading the whole result set into memory
+func (l *Logger) Each(orgID uuid.UUID, filter *models.AuditLogFilter, fn func(*models.AuditLog) error) error {
+	rows, err := l.scope(orgID, filter).Model(&models.AuditLog{}).Select(visibleColumns).Order("created_at ASC").Rows()
+	if err != nil {
+		return fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.AuditLog
+		if err := l.db.ScanRows(rows, &entry); err != nil {
+			return fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// visibleColumns selects audit entries as an organization sees them: the
+// client address and user agent of account level events are left out, as
+// they are not the organization's to see
+const visibleColumns = `id, org_id, actor_id, actor_email, action, target_type, target_id, metadata, created_at,
+	CASE WHEN org_id IS NULL THEN '' ELSE ip_address END AS ip_address,
+	CASE WHEN org_id IS NULL THEN '' ELSE user_agent END AS user_agent`
+
+// orgVisibleAccountActions are the account level events, which carry no
+// organization, that an organization sees for its members
+var orgVisibleAccountActions = []string{ActionLogin, ActionLoginFailed}
+
+// scope builds the base query for an organization's audit entries. Logins
+// and failed logins carry no organization and are included when the actor is
+// a member of the organization; other account level events are not.
+func (l *Logger) scope(orgID uuid.UUID, filter *models.AuditLogFilter) *gorm.DB {
+	query := l.db.Where(
+		"(org_id = ? OR (org_id IS NULL AND action IN ? AND actor_id IN (SELECT user_id FROM org_members WHERE org_id = ?)))",
+		orgID, orgVisibleAccountActions, orgID,
+	)
+
+	if filter == nil {
+		return query
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != nil {
+		query = query.Where("target_id = ?", *filter.TargetID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	return query
+}
+
+// NormalizePage applies defaults and bounds to pagination parameters
+func NormalizePage(page, perPage int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = DefaultPerPage
+	}
+	if perPage > MaxPerPage {
+		perPage = MaxPerPage
+	}
+	return page, perPage
+}
@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"taskman-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Export formats
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// csvHeader is the column order used for CSV exports
+var csvHeader = []string{
+	"id", "created_at", "org_id", "actor_id", "actor_email", "action",
+	"target_type", "target_id", "ip_address", "user_agent", "metadata",
+}
+
+// Export writes all audit entries matching the filter to w in the given format
+func (l *Logger) Export(w io.Writer, format string, orgID uuid.UUID, filter *models.AuditLogFilter) error {
+	switch format {
+	case FormatCSV:
+		return l.exportCSV(w, orgID, filter)
+	case FormatJSONL:
+		return l.exportJSONL(w, orgID, filter)
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// exportCSV writes audit entries as CSV with a header row
+func (l *Logger) exportCSV(w io.Writer, orgID uuid.UUID, filter *models.AuditLogFilter) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+
+	err := l.Each(orgID, filter, func(entry *models.AuditLog) error {
+		return writer.Write([]string{
+			entry.ID.String(),
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			optionalUUID(entry.OrgID),
+			optionalUUID(entry.ActorID),
+			entry.ActorEmail,
+			entry.Action,
+			entry.TargetType,
+			optionalUUID(entry.TargetID),
+			entry.IPAddress,
+			entry.UserAgent,
+			string(entry.Metadata),
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export audit entries: %w", err)
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// exportJSONL writes audit entries as newline-delimited JSON
+func (l *Logger) exportJSONL(w io.Writer, orgID uuid.UUID, filter *models.AuditLogFilter) error {
+	buffered := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buffered)
+
+	err := l.Each(orgID, filter, func(entry *models.AuditLog) error {
+		return encoder.Encode(entry)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export audit entries: %w", err)
+	}
+
+	return buffered.Flush()
+}
+
+// optionalUUID formats a nullable UUID for export
+func optionalUUID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
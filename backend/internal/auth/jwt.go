@@ -2,6 +2,7 @@ package auth
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,16 +13,40 @@ import (
 type JWTClaims struct {
 	UserID uuid.UUID `json:"user_id"`
 	Email  string    `json:"email"`
+
+	// AuthMethod is how the user signed in; empty means a password
+	AuthMethod string `json:"auth_method,omitempty"`
+
+	// TwoFactor is set when the user also passed two-factor authentication
+	TwoFactor bool `json:"two_factor,omitempty"`
+
+	// Purpose marks tokens that are not sessions, such as login challenges
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// JWTManager handles JWT operations
+// purposeTwoFactorChallenge marks a challenge token issued after a correct
+// password, which only buys the chance to enter a two-factor code
+const purposeTwoFactorChallenge = "2fa_challenge"
+
+// challengeExpiry is how long a user has to enter their two-factor code
+const challengeExpiry = 5 * time.Minute
+
+// JWTManager handles JWT operations. It signs with either a shared secret
+// (HS256) or an asymmetric key, in which case tokens carry the key's ID and
+// any of the verification keys are accepted, so that keys can be rotated
+// without signing everyone out.
 type JWTManager struct {
 	secretKey string
 	expiry    time.Duration
+
+	signingKey *Key
+	keys       map[string]*Key
+	// published lists the keys in the order the JWKS shows them
+	published []*Key
 }
 
-// NewJWTManager creates a new JWT manager
+// NewJWTManager creates a new JWT manager that signs with a shared secret
 func NewJWTManager(secretKey string, expiry time.Duration) *JWTManager {
 	return &JWTManager{
 		secretKey: secretKey,
@@ -29,31 +54,122 @@ func NewJWTManager(secretKey string, expiry time.Duration) *JWTManager {
 	}
 }
 
-// GenerateToken generates a new JWT token for a user
+// NewKeyedJWTManager creates a new JWT manager that signs with an asymmetric
+// key. Tokens signed with any of the verification keys, such as keys being
+// rotated out, are also accepted.
+func NewKeyedJWTManager(signingKey *Key, verificationKeys []*Key, expiry time.Duration) (*JWTManager, error) {
+	if !signingKey.CanSign() {
+		return nil, errors.New("the signing key must be a private key")
+	}
+
+	keys := map[string]*Key{signingKey.ID: signingKey}
+	published := []*Key{signingKey}
+	for _, key := range verificationKeys {
+		if _, ok := keys[key.ID]; !ok {
+			keys[key.ID] = key
+			published = append(published, key)
+		}
+	}
+
+	return &JWTManager{
+		expiry:     expiry,
+		signingKey: signingKey,
+		keys:       keys,
+		published:  published,
+	}, nil
+}
+
+// JWKS returns the public keys tokens are verified with, for other services
+// to check tokens themselves. It is empty with a shared secret.
+func (j *JWTManager) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range j.published {
+		set.Keys = append(set.Keys, key.JWK())
+	}
+	return set
+}
+
+// GenerateToken generates a new JWT token for a user who signed in with a
+// password
 func (j *JWTManager) GenerateToken(userID uuid.UUID, email string) (string, error) {
+	return j.GenerateTokenForMethod(userID, email, "", false)
+}
+
+// GenerateTokenForMethod generates a new JWT token for a user, recording how
+// they signed in and whether they passed two-factor authentication
+func (j *JWTManager) GenerateTokenForMethod(userID uuid.UUID, email, authMethod string, twoFactor bool) (string, error) {
+	claims := JWTClaims{
+		UserID:     userID,
+		Email:      email,
+		AuthMethod: authMethod,
+		TwoFactor:  twoFactor,
+	}
+	return j.sign(claims, j.expiry)
+}
+
+// GenerateChallengeToken generates a short-lived token for a user who gave a
+// correct password and must now pass two-factor authentication. It cannot
+// be used as a session.
+func (j *JWTManager) GenerateChallengeToken(userID uuid.UUID, email string) (string, error) {
 	claims := JWTClaims{
-		UserID: userID,
-		Email:  email,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.expiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "taskman-backend",
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secretKey))
+		UserID:  userID,
+		Email:   email,
+		Purpose: purposeTwoFactorChallenge,
+	}
+	return j.sign(claims, challengeExpiry)
+}
+
+// ValidateChallengeToken validates a two-factor challenge token and returns
+// its claims
+func (j *JWTManager) ValidateChallengeToken(tokenString string) (*JWTClaims, error) {
+	claims, err := j.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Purpose != purposeTwoFactorChallenge {
+		return nil, errors.New("not a challenge token")
+	}
+
+	return claims, nil
+}
+
+// sign fills in the registered claims and signs the token
+func (j *JWTManager) sign(claims JWTClaims, expiry time.Duration) (string, error) {
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		NotBefore: jwt.NewNumericDate(time.Now()),
+		Issuer:    "taskman-backend",
+	}
+
+	if j.signingKey == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(j.secretKey))
+	}
+
+	token := jwt.NewWithClaims(j.signingKey.method, claims)
+	token.Header["kid"] = j.signingKey.ID
+	return token.SignedString(j.signingKey.private)
 }
 
-// ValidateToken validates a JWT token and returns the claims
+// ValidateToken validates a session JWT token and returns the claims
 func (j *JWTManager) ValidateToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(j.secretKey), nil
-	})
+	claims, err := j.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Purpose != "" {
+		return nil, errors.New("not a session token")
+	}
+
+	return claims, nil
+}
+
+// parse verifies a token's signature and expiry and returns its claims
+func (j *JWTManager) parse(tokenString string) (*JWTClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, j.verificationKey)
 
 	if err != nil {
 		return nil, err
@@ -66,6 +182,28 @@ func (j *JWTManager) ValidateToken(tokenString string) (*JWTClaims, error) {
 	return nil, errors.New("invalid token")
 }
 
+// verificationKey picks the key a token must be signed with. With
+// asymmetric keys the token names its key, and must use that key's
+// algorithm.
+func (j *JWTManager) verificationKey(token *jwt.Token) (interface{}, error) {
+	if j.signingKey == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(j.secretKey), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := j.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.public, nil
+}
+
 // RefreshToken generates a new token with extended expiry
 func (j *JWTManager) RefreshToken(tokenString string) (string, error) {
 	claims, err := j.ValidateToken(tokenString)
@@ -74,5 +212,5 @@ func (j *JWTManager) RefreshToken(tokenString string) (string, error) {
 	}
 
 	// Generate new token with extended expiry
-	return j.GenerateToken(claims.UserID, claims.Email)
+	return j.GenerateTokenForMethod(claims.UserID, claims.Email, claims.AuthMethod, claims.TwoFactor)
 }
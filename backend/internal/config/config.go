@@ -1,14 +1,21 @@
 package config
 
 import (
+	"errors"
 	"log"
 	"os"
 	"strconv"
 	"strings"
+	"taskman-backend/internal/ratelimit"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// DefaultJWTSecret is the placeholder secret used when JWT_SECRET is unset.
+// It is only good enough for development.
+const DefaultJWTSecret = "your-super-secret-jwt-key-here"
+
 // Config holds all configuration for the application
 type Config struct {
 	// Database
@@ -23,9 +30,17 @@ type Config struct {
 	SupabaseAnonKey        string
 	SupabaseServiceRoleKey string
 
-	// JWT
-	JWTSecret string
-	JWTExpiry string
+	// Environment is "development" or "production". It defaults to
+	// production, so development shortcuts must be asked for explicitly.
+	Environment string
+
+	// JWT. Tokens are signed with the private key in JWTSigningKeyFile when
+	// set, and with the shared JWTSecret otherwise. Keys in
+	// JWTVerificationKeyFiles are still accepted, for rotation.
+	JWTSecret               string
+	JWTSigningKeyFile       string
+	JWTVerificationKeyFiles []string
+	JWTExpiry               time.Duration
 
 	// Server
 	Port    string
@@ -35,17 +50,71 @@ type Config struct {
 	// CORS
 	CORSAllowedOrigins []string
 
+	// AppURL is where the frontend is served, for links in emails
+	AppURL string
+
+	// TrustedProxies are the addresses or CIDR ranges of the load balancers
+	// in front of the server. Client addresses are only read from
+	// X-Forwarded-For on requests that come through one of them.
+	TrustedProxies []string
+
 	// Logging
 	LogLevel string
 
+	// Tasks
+	BlockedTaskStatuses []string
+
 	// Migrations
 	RunMigrations bool
 
+	// Background jobs
+	RunScheduler          bool
+	RecurringTaskInterval time.Duration
+
+	// Deadline reminders
+	DeadlineReminderInterval time.Duration
+	DeadlineReminderLead     time.Duration
+
 	// Email
 	SMTPHost     string
 	SMTPPort     int
 	SMTPUsername string
 	SMTPPassword string
+	SMTPFrom     string
+
+	// NotificationEmailInterval is how often notification emails and daily
+	// digests are sent
+	NotificationEmailInterval time.Duration
+
+	// Webhooks
+	WebhookDeliveryInterval     time.Duration
+	WebhookAllowPrivateNetworks bool
+
+	// Single sign-on through an OpenID Connect provider; disabled without
+	// an issuer
+	OIDCProviderName string
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+	OIDCScopes       []string
+
+	// TOTPIssuer names the app in users' authenticator apps
+	TOTPIssuer string
+
+	// Login throttling: failures allowed per account and per client address
+	// before a lockout of LoginLockout
+	LoginMaxAccountFailures int
+	LoginMaxIPFailures      int
+	LoginLockout            time.Duration
+
+	// Request rate limits, as token buckets: per client address on public
+	// routes, per user or API token and per organization on the API, and
+	// per user for exports
+	RateLimitPublic ratelimit.Limit
+	RateLimitUser   ratelimit.Limit
+	RateLimitOrg    ratelimit.Limit
+	RateLimitExport ratelimit.Limit
 }
 
 // Load loads configuration from environment variables
@@ -57,33 +126,74 @@ func Load() *Config {
 
 	config := &Config{
 		// Database configuration - prefer individual params over DATABASE_URL
-		DatabaseURL:            getEnv("DATABASE_URL", ""),
-		DBHost:                 getEnv("DB_HOST", "localhost"),
-		DBPort:                 getEnv("DB_PORT", "5432"),
-		DBUser:                 getEnv("DB_USER", "postgres"),
-		DBPassword:             getEnv("DB_PASSWORD", ""),
-		DBName:                 getEnv("DB_NAME", "taskman"),
-		DBSSLMode:              getEnv("DB_SSL_MODE", "disable"),
-		SupabaseURL:            getEnv("SUPABASE_URL", ""),
-		SupabaseAnonKey:        getEnv("SUPABASE_ANON_KEY", ""),
-		SupabaseServiceRoleKey: getEnv("SUPABASE_SERVICE_ROLE_KEY", ""),
-		JWTSecret:              getEnv("JWT_SECRET", "your-super-secret-jwt-key-here"),
-		JWTExpiry:              getEnv("JWT_EXPIRY", "24h"),
-		Port:                   getEnv("SERVER_PORT", getEnv("PORT", "8080")),
-		Host:                   getEnv("SERVER_HOST", "localhost"),
-		GinMode:                getEnv("GIN_MODE", "debug"),
-		CORSAllowedOrigins:     strings.Split(getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:3000,http://localhost:5173"), ","),
-		LogLevel:               getEnv("LOG_LEVEL", "info"),
-		RunMigrations:          getEnvAsBool("RUN_MIGRATIONS", false),
-		SMTPHost:               getEnv("SMTP_HOST", ""),
-		SMTPPort:               getEnvAsInt("SMTP_PORT", 587),
-		SMTPUsername:           getEnv("SMTP_USERNAME", ""),
-		SMTPPassword:           getEnv("SMTP_PASSWORD", ""),
+		DatabaseURL:                 getEnv("DATABASE_URL", ""),
+		DBHost:                      getEnv("DB_HOST", "localhost"),
+		DBPort:                      getEnv("DB_PORT", "5432"),
+		DBUser:                      getEnv("DB_USER", "postgres"),
+		DBPassword:                  getEnv("DB_PASSWORD", ""),
+		DBName:                      getEnv("DB_NAME", "taskman"),
+		DBSSLMode:                   getEnv("DB_SSL_MODE", "disable"),
+		SupabaseURL:                 getEnv("SUPABASE_URL", ""),
+		SupabaseAnonKey:             getEnv("SUPABASE_ANON_KEY", ""),
+		SupabaseServiceRoleKey:      getEnv("SUPABASE_SERVICE_ROLE_KEY", ""),
+		Environment:                 getEnv("APP_ENV", "production"),
+		JWTSecret:                   getEnv("JWT_SECRET", DefaultJWTSecret),
+		JWTSigningKeyFile:           getEnv("JWT_SIGNING_KEY_FILE", ""),
+		JWTVerificationKeyFiles:     getEnvAsList("JWT_VERIFICATION_KEY_FILES"),
+		JWTExpiry:                   getEnvAsDuration("JWT_EXPIRY", 24*time.Hour),
+		Port:                        getEnv("SERVER_PORT", getEnv("PORT", "8080")),
+		Host:                        getEnv("SERVER_HOST", "localhost"),
+		GinMode:                     getEnv("GIN_MODE", "debug"),
+		CORSAllowedOrigins:          strings.Split(getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:3000,http://localhost:5173"), ","),
+		AppURL:                      getEnv("APP_URL", "http://localhost:5173"),
+		TrustedProxies:              getEnvAsList("TRUSTED_PROXIES"),
+		LogLevel:                    getEnv("LOG_LEVEL", "info"),
+		BlockedTaskStatuses:         strings.Split(getEnv("BLOCKED_TASK_STATUSES", "done"), ","),
+		RunMigrations:               getEnvAsBool("RUN_MIGRATIONS", false),
+		RunScheduler:                getEnvAsBool("RUN_SCHEDULER", true),
+		RecurringTaskInterval:       getEnvAsDuration("RECURRING_TASK_INTERVAL", time.Minute),
+		DeadlineReminderInterval:    getEnvAsDuration("DEADLINE_REMINDER_INTERVAL", 5*time.Minute),
+		DeadlineReminderLead:        getEnvAsDuration("DEADLINE_REMINDER_LEAD", 24*time.Hour),
+		SMTPHost:                    getEnv("SMTP_HOST", ""),
+		SMTPPort:                    getEnvAsInt("SMTP_PORT", 587),
+		SMTPUsername:                getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:                getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:                    getEnv("SMTP_FROM", "taskman@localhost"),
+		NotificationEmailInterval:   getEnvAsDuration("NOTIFICATION_EMAIL_INTERVAL", time.Minute),
+		WebhookDeliveryInterval:     getEnvAsDuration("WEBHOOK_DELIVERY_INTERVAL", 10*time.Second),
+		WebhookAllowPrivateNetworks: getEnvAsBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+		OIDCProviderName:            getEnv("OIDC_PROVIDER_NAME", "oidc"),
+		OIDCIssuer:                  getEnv("OIDC_ISSUER", ""),
+		OIDCClientID:                getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:            getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:             getEnv("OIDC_REDIRECT_URL", ""),
+		OIDCScopes:                  strings.Fields(getEnv("OIDC_SCOPES", "openid email profile")),
+		TOTPIssuer:                  getEnv("TOTP_ISSUER", "Taskman"),
+		LoginMaxAccountFailures:     getEnvAsInt("LOGIN_MAX_ACCOUNT_FAILURES", 5),
+		LoginMaxIPFailures:          getEnvAsInt("LOGIN_MAX_IP_FAILURES", 50),
+		LoginLockout:                getEnvAsDuration("LOGIN_LOCKOUT", 15*time.Minute),
+		RateLimitPublic:             getEnvAsLimit("RATE_LIMIT_PUBLIC", ratelimit.Limit{Requests: 60, Per: time.Minute}),
+		RateLimitUser:               getEnvAsLimit("RATE_LIMIT_USER", ratelimit.Limit{Requests: 600, Per: time.Minute}),
+		RateLimitOrg:                getEnvAsLimit("RATE_LIMIT_ORG", ratelimit.Limit{Requests: 3000, Per: time.Minute}),
+		RateLimitExport:             getEnvAsLimit("RATE_LIMIT_EXPORT", ratelimit.Limit{Requests: 10, Per: time.Minute}),
 	}
 
 	return config
 }
 
+// IsDevelopment reports whether the app runs in development
+func (c *Config) IsDevelopment() bool {
+	return c.Environment == "development"
+}
+
+// Validate checks for settings that are unsafe to run with
+func (c *Config) Validate() error {
+	if c.JWTSigningKeyFile == "" && c.JWTSecret == DefaultJWTSecret && !c.IsDevelopment() {
+		return errors.New("JWT_SECRET is unset; set it or JWT_SIGNING_KEY_FILE, or set APP_ENV=development to run locally with the default secret")
+	}
+	return nil
+}
+
 // getEnv gets an environment variable with a fallback value
 func getEnv(key, fallback string) string {
 	if value := os.Getenv(key); value != "" {
@@ -102,6 +212,16 @@ func getEnvAsInt(key string, fallback int) int {
 	return fallback
 }
 
+// getEnvAsDuration gets an environment variable as a duration with a fallback value
+func getEnvAsDuration(key string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil && duration > 0 {
+			return duration
+		}
+	}
+	return fallback
+}
+
 // getEnvAsBool gets an environment variable as boolean with a fallback value
 func getEnvAsBool(key string, fallback bool) bool {
 	if value := os.Getenv(key); value != "" {
@@ -111,3 +231,26 @@ func getEnvAsBool(key string, fallback bool) bool {
 	}
 	return fallback
 }
+
+// getEnvAsLimit gets an environment variable as a rate limit such as
+// "600/1m" with a fallback value
+func getEnvAsLimit(key string, fallback ratelimit.Limit) ratelimit.Limit {
+	if value := os.Getenv(key); value != "" {
+		if limit, err := ratelimit.ParseLimit(value); err == nil {
+			return limit
+		}
+	}
+	return fallback
+}
+
+// getEnvAsList gets a comma-separated environment variable, skipping empty
+// items
+func getEnvAsList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -73,12 +73,38 @@ func runMigrations() error {
 	// Auto-migrate all models
 	err := DB.AutoMigrate(
 		&models.User{},
+		&models.PendingRegistration{},
 		&models.Organization{},
 		&models.OrgMember{},
 		&models.Project{},
+		&models.WorkflowColumn{},
+		&models.WorkflowTransition{},
+		&models.Label{},
+		&models.TaskLabel{},
+		&models.ProjectLabel{},
+		&models.CustomField{},
+		&models.TimeEntry{},
+		&models.RecurringTask{},
+		&models.Notification{},
+		&models.DeadlineReminder{},
+		&models.NotificationPreference{},
+		&models.NotificationSettings{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.ServiceAccount{},
+		&models.APIToken{},
+		&models.UserIdentity{},
+		&models.SSOLoginState{},
+		&models.UserTwoFactor{},
+		&models.RecoveryCode{},
 		&models.ProjectAssignee{},
 		&models.Task{},
 		&models.TaskAssignee{},
+		&models.ChecklistItem{},
+		&models.TaskDependency{},
+		&models.AuditLog{},
+		&models.SavedView{},
+		&models.ProjectTemplate{},
 	)
 
 	if err != nil {
@@ -90,6 +116,11 @@ func runMigrations() error {
 		}
 	}
 
+	// Add generated columns that models do not map
+	if err := createSearchColumns(); err != nil {
+		return fmt.Errorf("failed to create search columns: %w", err)
+	}
+
 	// Create indexes for better performance
 	if err := createIndexes(); err != nil {
 		return fmt.Errorf("failed to create indexes: %w", err)
@@ -113,6 +144,27 @@ func createIndexes() error {
 		"CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status)",
 		"CREATE INDEX IF NOT EXISTS idx_task_assignees_task_id ON task_assignees(task_id)",
 		"CREATE INDEX IF NOT EXISTS idx_task_assignees_user_id ON task_assignees(user_id)",
+		"CREATE INDEX IF NOT EXISTS idx_tasks_parent_task_id ON tasks(parent_task_id)",
+		"CREATE INDEX IF NOT EXISTS idx_tasks_project_status_rank ON tasks(project_id, status, rank)",
+		"CREATE INDEX IF NOT EXISTS idx_projects_org_status_rank ON projects(org_id, status, rank)",
+		"CREATE INDEX IF NOT EXISTS idx_checklist_items_task_id ON checklist_items(task_id)",
+		"CREATE INDEX IF NOT EXISTS idx_task_labels_label_id ON task_labels(label_id)",
+		"CREATE INDEX IF NOT EXISTS idx_project_labels_label_id ON project_labels(label_id)",
+		"CREATE INDEX IF NOT EXISTS idx_tasks_priority ON tasks(priority)",
+		"CREATE INDEX IF NOT EXISTS idx_tasks_custom_fields ON tasks USING GIN (custom_fields)",
+		"CREATE INDEX IF NOT EXISTS idx_projects_custom_fields ON projects USING GIN (custom_fields)",
+		"CREATE INDEX IF NOT EXISTS idx_task_dependencies_blocked_by ON task_dependencies(blocked_by_task_id)",
+		"CREATE INDEX IF NOT EXISTS idx_audit_logs_org_id_created_at ON audit_logs(org_id, created_at DESC)",
+		"CREATE INDEX IF NOT EXISTS idx_time_entries_started_at ON time_entries(started_at)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_time_entries_running ON time_entries(user_id) WHERE ended_at IS NULL",
+		"CREATE INDEX IF NOT EXISTS idx_recurring_tasks_next_run_at ON recurring_tasks(next_run_at) WHERE next_run_at IS NOT NULL",
+		"CREATE INDEX IF NOT EXISTS idx_tasks_deadline ON tasks(deadline) WHERE deadline IS NOT NULL",
+		"CREATE INDEX IF NOT EXISTS idx_projects_deadline ON projects(deadline) WHERE deadline IS NOT NULL",
+		"CREATE INDEX IF NOT EXISTS idx_notifications_user_unread ON notifications(user_id, created_at) WHERE read_at IS NULL",
+		"CREATE INDEX IF NOT EXISTS idx_notifications_email_pending ON notifications(user_id) WHERE email_pending",
+		"CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending'",
+		"CREATE INDEX IF NOT EXISTS idx_tasks_search_vector ON tasks USING GIN (search_vector)",
+		"CREATE INDEX IF NOT EXISTS idx_projects_search_vector ON projects USING GIN (search_vector)",
 	}
 
 	for _, indexSQL := range indexes {
@@ -124,6 +176,21 @@ func createIndexes() error {
 	return nil
 }
 
+// createSearchColumns adds the full-text search vectors of tasks and
+// projects, which Postgres keeps up to date from their names and descriptions
+func createSearchColumns() error {
+	for _, table := range []string{"tasks", "projects"} {
+		err := DB.Exec("ALTER TABLE " + table + " ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (" +
+			"setweight(to_tsvector('english', coalesce(name, '')), 'A') || " +
+			"setweight(to_tsvector('english', coalesce(description, '')), 'B')) STORED").Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Close closes the database connection
 func Close() error {
 	if DB != nil {
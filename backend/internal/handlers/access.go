@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"taskman-backend/internal/middleware"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// authorizeTask resolves the task from the route and checks that the caller is
+// a member of the organization owning its project and, when modifying, the
+// task's assignee or creator. The caller's role is returned. A project of
+// another organization is reported as not found, and scoped API tokens are
+// checked against the project's organization.
+func authorizeTask(c *fiber.Ctx, orgService *services.OrganizationService, taskService *services.TaskService, modify bool) (*models.Task, string, error) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return nil, "", fiber.NewError(fiber.StatusUnauthorized, "Invalid user context")
+	}
+
+	orgID, err := uuid.Parse(c.Params("orgId"))
+	if err != nil {
+		return nil, "", fiber.NewError(fiber.StatusBadRequest, "Invalid organization ID")
+	}
+
+	projectID, err := uuid.Parse(c.Params("projectId"))
+	if err != nil {
+		return nil, "", fiber.NewError(fiber.StatusBadRequest, "Invalid project ID")
+	}
+
+	taskID, err := uuid.Parse(c.Params("taskId"))
+	if err != nil {
+		return nil, "", fiber.NewError(fiber.StatusBadRequest, "Invalid task ID")
+	}
+
+	// Check if user is member
+	isMember, role, err := orgService.IsMember(orgID, userID)
+	if err != nil {
+		return nil, "", fiber.NewError(fiber.StatusInternalServerError, "Failed to check membership")
+	}
+
+	if !isMember {
+		return nil, "", fiber.NewError(fiber.StatusForbidden, "Not a member of this organization")
+	}
+
+	projectOrgID, err := taskService.GetProjectOrgID(projectID)
+	if err != nil || projectOrgID != orgID {
+		return nil, "", fiber.NewError(fiber.StatusNotFound, "Project not found")
+	}
+
+	if err := authorizeResourceOrg(c, orgService, projectOrgID); err != nil {
+		return nil, "", err
+	}
+
+	task, err := taskService.GetTaskByID(taskID)
+	if err != nil {
+		return nil, "", fiber.NewError(fiber.StatusNotFound, "Task not found")
+	}
+
+	if task.ProjectID != projectID {
+		return nil, "", fiber.NewError(fiber.StatusForbidden, "Task does not belong to this project")
+	}
+
+	if !modify {
+		return task, role, nil
+	}
+
+	isAssignee, err := taskService.IsTaskAssignee(taskID, userID)
+	if err != nil {
+		return nil, "", fiber.NewError(fiber.StatusInternalServerError, "Failed to check task assignment")
+	}
+
+	if !isAssignee && task.CreatedBy != userID {
+		return nil, "", fiber.NewError(fiber.StatusForbidden, "Not assigned to this task")
+	}
+
+	return task, role, nil
+}
+
+// authorizeProject resolves the project from the route and checks that the
+// caller is a member of its organization. The caller's role is returned. A
+// project of another organization is reported as not found, and scoped API
+// tokens are checked against the project's organization.
+func authorizeProject(c *fiber.Ctx, orgService *services.OrganizationService, projectService *services.ProjectService) (*models.Project, string, error) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return nil, "", fiber.NewError(fiber.StatusUnauthorized, "Invalid user context")
+	}
+
+	orgID, err := uuid.Parse(c.Params("orgId"))
+	if err != nil {
+		return nil, "", fiber.NewError(fiber.StatusBadRequest, "Invalid organization ID")
+	}
+
+	projectID, err := uuid.Parse(c.Params("projectId"))
+	if err != nil {
+		return nil, "", fiber.NewError(fiber.StatusBadRequest, "Invalid project ID")
+	}
+
+	// Check if user is member
+	isMember, role, err := orgService.IsMember(orgID, userID)
+	if err != nil {
+		return nil, "", fiber.NewError(fiber.StatusInternalServerError, "Failed to check membership")
+	}
+
+	if !isMember {
+		return nil, "", fiber.NewError(fiber.StatusForbidden, "Not a member of this organization")
+	}
+
+	project, err := projectService.GetProjectByID(projectID)
+	if err != nil || project.OrgID != orgID {
+		return nil, "", fiber.NewError(fiber.StatusNotFound, "Project not found")
+	}
+
+	if err := authorizeResourceOrg(c, orgService, project.OrgID); err != nil {
+		return nil, "", err
+	}
+
+	return project, role, nil
+}
+
+// authorizeOrg checks that the caller is a member of the organization in the
+// route and returns the organization ID and the caller's role
+func authorizeOrg(c *fiber.Ctx, orgService *services.OrganizationService) (uuid.UUID, string, error) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return uuid.Nil, "", fiber.NewError(fiber.StatusUnauthorized, "Invalid user context")
+	}
+
+	orgID, err := uuid.Parse(c.Params("orgId"))
+	if err != nil {
+		return uuid.Nil, "", fiber.NewError(fiber.StatusBadRequest, "Invalid organization ID")
+	}
+
+	// Check if user is member
+	isMember, role, err := orgService.IsMember(orgID, userID)
+	if err != nil {
+		return uuid.Nil, "", fiber.NewError(fiber.StatusInternalServerError, "Failed to check membership")
+	}
+
+	if !isMember {
+		return uuid.Nil, "", fiber.NewError(fiber.StatusForbidden, "Not a member of this organization")
+	}
+
+	return orgID, role, nil
+}
+
+// authorizeResourceOrg checks the token scope and access policy of the
+// organization owning a resource. The middleware only sees the organization
+// named in the route, which for some resources is not the one they belong to.
+func authorizeResourceOrg(c *fiber.Ctx, orgService *services.OrganizationService, orgID uuid.UUID) error {
+	if !middleware.TokenAllowsOrg(c, orgID) {
+		return fiber.NewError(fiber.StatusForbidden, "This token cannot access this organization")
+	}
+
+	return middleware.CheckOrgPolicy(c, orgService, orgID)
+}
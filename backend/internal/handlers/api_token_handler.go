@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"errors"
+	"taskman-backend/internal/audit"
+	"taskman-backend/internal/middleware"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// APITokenHandler handles personal access tokens and service accounts
+type APITokenHandler struct {
+	tokenService *services.APITokenService
+	orgService   *services.OrganizationService
+	auditLogger  *audit.Logger
+}
+
+// NewAPITokenHandler creates a new API token handler
+func NewAPITokenHandler(tokenService *services.APITokenService, orgService *services.OrganizationService, auditLogger *audit.Logger) *APITokenHandler {
+	return &APITokenHandler{
+		tokenService: tokenService,
+		orgService:   orgService,
+		auditLogger:  auditLogger,
+	}
+}
+
+// GetTokens handles listing the caller's personal access tokens
+func (h *APITokenHandler) GetTokens(c *fiber.Ctx) error {
+	userID, err := requireSession(c)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := h.tokenService.GetPersonalTokens(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get API tokens"})
+	}
+
+	return c.JSON(fiber.Map{
+		"tokens": tokens,
+	})
+}
+
+// CreateToken handles creating a personal access token. The token itself is
+// only ever returned here.
+func (h *APITokenHandler) CreateToken(c *fiber.Ctx) error {
+	userID, err := requireSession(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.APITokenCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	raw, token, err := h.tokenService.CreatePersonalToken(userID, middleware.GetAuthMethodFromContext(c), middleware.GetTwoFactorFromContext(c), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAPIToken) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create API token"})
+	}
+
+	h.auditLogger.RecordRequest(c, audit.Entry{
+		Action:     audit.ActionAPITokenCreated,
+		TargetType: audit.TargetAPIToken,
+		TargetID:   &token.ID,
+		Metadata:   map[string]interface{}{"name": token.Name, "permission": token.Permission, "org_ids": token.OrgIDs},
+	})
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":      "API token created successfully",
+		"token":        token,
+		"access_token": raw,
+	})
+}
+
+// DeleteToken handles revoking one of the caller's personal access tokens
+func (h *APITokenHandler) DeleteToken(c *fiber.Ctx) error {
+	userID, err := requireSession(c)
+	if err != nil {
+		return err
+	}
+
+	tokenID, err := uuid.Parse(c.Params("tokenId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid token ID"})
+	}
+
+	if err := h.tokenService.DeletePersonalToken(userID, tokenID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "API token not found"})
+	}
+
+	h.auditLogger.RecordRequest(c, audit.Entry{
+		Action:     audit.ActionAPITokenRevoked,
+		TargetType: audit.TargetAPIToken,
+		TargetID:   &tokenID,
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "API token revoked successfully",
+	})
+}
+
+// GetServiceAccounts handles listing an organization's service accounts
+// (admin only)
+func (h *APITokenHandler) GetServiceAccounts(c *fiber.Ctx) error {
+	orgID, err := h.authorizeAdmin(c)
+	if err != nil {
+		return err
+	}
+
+	accounts, err := h.tokenService.GetServiceAccounts(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get service accounts"})
+	}
+
+	return c.JSON(fiber.Map{
+		"service_accounts": accounts,
+	})
+}
+
+// CreateServiceAccount handles creating a service account (admin only)
+func (h *APITokenHandler) CreateServiceAccount(c *fiber.Ctx) error {
+	orgID, err := h.authorizeAdmin(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	var req models.ServiceAccountCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if req.Name == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Name is required"})
+	}
+
+	account, err := h.tokenService.CreateServiceAccount(orgID, userID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAPIToken) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create service account"})
+	}
+
+	h.auditLogger.RecordRequest(c, audit.Entry{
+		OrgID:      &orgID,
+		Action:     audit.ActionServiceAccountAdded,
+		TargetType: audit.TargetServiceAccount,
+		TargetID:   &account.ID,
+		Metadata:   map[string]interface{}{"name": account.Name, "role": account.Role},
+	})
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":         "Service account created successfully",
+		"service_account": account,
+	})
+}
+
+// DeleteServiceAccount handles deleting a service account and revoking its
+// tokens (admin only)
+func (h *APITokenHandler) DeleteServiceAccount(c *fiber.Ctx) error {
+	account, err := h.getAdminServiceAccount(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.tokenService.DeleteServiceAccount(account); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete service account"})
+	}
+
+	h.auditLogger.RecordRequest(c, audit.Entry{
+		OrgID:      &account.OrgID,
+		Action:     audit.ActionServiceAccountRemoved,
+		TargetType: audit.TargetServiceAccount,
+		TargetID:   &account.ID,
+		Metadata:   map[string]interface{}{"name": account.Name},
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Service account deleted successfully",
+	})
+}
+
+// GetServiceAccountTokens handles listing a service account's tokens (admin only)
+func (h *APITokenHandler) GetServiceAccountTokens(c *fiber.Ctx) error {
+	account, err := h.getAdminServiceAccount(c)
+	if err != nil {
+		return err
+	}
+
+	tokens, err := h.tokenService.GetServiceAccountTokens(account.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get service account tokens"})
+	}
+
+	return c.JSON(fiber.Map{
+		"tokens": tokens,
+	})
+}
+
+// CreateServiceAccountToken handles creating a token for a service account
+// (admin only). The token itself is only ever returned here.
+func (h *APITokenHandler) CreateServiceAccountToken(c *fiber.Ctx) error {
+	account, err := h.getAdminServiceAccount(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.APITokenCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	raw, token, err := h.tokenService.CreateServiceAccountToken(account, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAPIToken) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create service account token"})
+	}
+
+	h.auditLogger.RecordRequest(c, audit.Entry{
+		OrgID:      &account.OrgID,
+		Action:     audit.ActionAPITokenCreated,
+		TargetType: audit.TargetAPIToken,
+		TargetID:   &token.ID,
+		Metadata:   map[string]interface{}{"name": token.Name, "permission": token.Permission, "service_account_id": account.ID},
+	})
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":      "Service account token created successfully",
+		"token":        token,
+		"access_token": raw,
+	})
+}
+
+// DeleteServiceAccountToken handles revoking a service account's token
+// (admin only)
+func (h *APITokenHandler) DeleteServiceAccountToken(c *fiber.Ctx) error {
+	account, err := h.getAdminServiceAccount(c)
+	if err != nil {
+		return err
+	}
+
+	tokenID, err := uuid.Parse(c.Params("tokenId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid token ID"})
+	}
+
+	if err := h.tokenService.DeleteServiceAccountToken(account.ID, tokenID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "API token not found"})
+	}
+
+	h.auditLogger.RecordRequest(c, audit.Entry{
+		OrgID:      &account.OrgID,
+		Action:     audit.ActionAPITokenRevoked,
+		TargetType: audit.TargetAPIToken,
+		TargetID:   &tokenID,
+		Metadata:   map[string]interface{}{"service_account_id": account.ID},
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Service account token revoked successfully",
+	})
+}
+
+// authorizeAdmin checks that the caller signed in as an organization admin.
+// API tokens cannot manage service accounts, so a leaked token cannot be
+// used to mint more.
+func (h *APITokenHandler) authorizeAdmin(c *fiber.Ctx) (uuid.UUID, error) {
+	if _, err := requireSession(c); err != nil {
+		return uuid.Nil, err
+	}
+
+	orgID, role, err := authorizeOrg(c, h.orgService)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if role != models.RoleAdmin {
+		return uuid.Nil, fiber.NewError(fiber.StatusForbidden, "Admin access required")
+	}
+
+	return orgID, nil
+}
+
+// getAdminServiceAccount checks that the caller signed in as an organization
+// admin and resolves the service account from the route
+func (h *APITokenHandler) getAdminServiceAccount(c *fiber.Ctx) (*models.ServiceAccount, error) {
+	orgID, err := h.authorizeAdmin(c)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := uuid.Parse(c.Params("accountId"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid service account ID")
+	}
+
+	account, err := h.tokenService.GetServiceAccountByID(accountID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Service account not found")
+	}
+
+	if account.OrgID != orgID {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Service account does not belong to this organization")
+	}
+
+	return account, nil
+}
+
+// requireSession returns the caller's user ID, refusing requests made with
+// an API token rather than a signed-in session
+func requireSession(c *fiber.Ctx) (uuid.UUID, error) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return uuid.Nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid user context")
+	}
+
+	if middleware.GetAPITokenFromContext(c) != nil {
+		return uuid.Nil, fiber.NewError(fiber.StatusForbidden, "This requires a signed-in session, not an API token")
+	}
+
+	return userID, nil
+}
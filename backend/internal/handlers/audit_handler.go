@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"taskman-backend/internal/audit"
+	"taskman-backend/internal/middleware"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/services"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// AuditHandler handles audit log requests
+type AuditHandler struct {
+	auditLogger *audit.Logger
+	orgService  *services.OrganizationService
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditLogger *audit.Logger, orgService *services.OrganizationService) *AuditHandler {
+	return &AuditHandler{
+		auditLogger: auditLogger,
+		orgService:  orgService,
+	}
+}
+
+// GetAuditLog handles listing an organization's audit entries (admin only)
+func (h *AuditHandler) GetAuditLog(c *fiber.Ctx) error {
+	orgID, err := h.authorizeAdmin(c)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	filter.Page, filter.PerPage = audit.NormalizePage(filter.Page, filter.PerPage)
+
+	entries, total, err := h.auditLogger.List(orgID, filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get audit log"})
+	}
+
+	return c.JSON(fiber.Map{
+		"entries":  entries,
+		"total":    total,
+		"page":     filter.Page,
+		"per_page": filter.PerPage,
+	})
+}
+
+// ExportAuditLog handles exporting an organization's audit entries as CSV or JSONL (admin only)
+func (h *AuditHandler) ExportAuditLog(c *fiber.Ctx) error {
+	orgID, err := h.authorizeAdmin(c)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	format := c.Query("format", audit.FormatCSV)
+	var contentType string
+	switch format {
+	case audit.FormatCSV:
+		contentType = "text/csv"
+	case audit.FormatJSONL:
+		contentType = "application/x-ndjson"
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid export format"})
+	}
+
+	if err := h.auditLogger.Export(c.Response().BodyWriter(), format, orgID, filter); err != nil {
+		c.Response().ResetBody()
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to export audit log"})
+	}
+
+	filename := fmt.Sprintf("audit-%s-%s.%s", orgID, time.Now().UTC().Format("20060102"), format)
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	return nil
+}
+
+// authorizeAdmin parses the organization ID and checks that the caller is an
+// admin of it
+func (h *AuditHandler) authorizeAdmin(c *fiber.Ctx) (uuid.UUID, error) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return uuid.Nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid user context")
+	}
+
+	orgIDStr := c.Params("orgId")
+	orgID, err := uuid.Parse(orgIDStr)
+	if err != nil {
+		return uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "Invalid organization ID")
+	}
+
+	// Check if user is admin
+	isMember, role, err := h.orgService.IsMember(orgID, userID)
+	if err != nil {
+		return uuid.Nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to check membership")
+	}
+
+	if !isMember || role != models.RoleAdmin {
+		return uuid.Nil, fiber.NewError(fiber.StatusForbidden, "Admin access required")
+	}
+
+	return orgID, nil
+}
+
+// parseAuditFilter builds an audit filter from query parameters
+func parseAuditFilter(c *fiber.Ctx) (*models.AuditLogFilter, error) {
+	filter := &models.AuditLogFilter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		Page:       c.QueryInt("page", 1),
+		PerPage:    c.QueryInt("per_page", audit.DefaultPerPage),
+	}
+
+	if actorIDStr := c.Query("actor_id"); actorIDStr != "" {
+		actorID, err := uuid.Parse(actorIDStr)
+		if err != nil {
+			return nil, errors.New("Invalid actor ID")
+		}
+		filter.ActorID = &actorID
+	}
+
+	if targetIDStr := c.Query("target_id"); targetIDStr != "" {
+		targetID, err := uuid.Parse(targetIDStr)
+		if err != nil {
+			return nil, errors.New("Invalid target ID")
+		}
+		filter.TargetID = &targetID
+	}
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			return nil, errors.New("Invalid from timestamp, expected RFC3339")
+		}
+		filter.From = &from
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			return nil, errors.New("Invalid to timestamp, expected RFC3339")
+		}
+		filter.To = &to
+	}
+
+	return filter, nil
+}
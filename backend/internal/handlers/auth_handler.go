@@ -1,45 +1,103 @@
 package handlers
 
 import (
+	"errors"
+	"log"
+	"strconv"
 	"strings"
+	"taskman-backend/internal/audit"
 	"taskman-backend/internal/auth"
+	"taskman-backend/internal/mailer"
 	"taskman-backend/internal/middleware"
 	"taskman-backend/internal/models"
+	"taskman-backend/internal/ratelimit"
 	"taskman-backend/internal/services"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // AuthHandler handles authentication-related requests
 type AuthHandler struct {
-	userService *services.UserService
-	jwtManager  *auth.JWTManager
+	userService      *services.UserService
+	twoFactorService *services.TwoFactorService
+	jwtManager       *auth.JWTManager
+	loginGuard       *ratelimit.LoginGuard
+	auditLogger      *audit.Logger
+	mailer           mailer.Mailer
+	appURL           string
 }
 
-// NewAuthHandler creates a new auth handler
-func NewAuthHandler(userService *services.UserService, jwtManager *auth.JWTManager) *AuthHandler {
+// NewAuthHandler creates a new auth handler. Registration emails link to
+// pages of the frontend at appURL.
+func NewAuthHandler(userService *services.UserService, twoFactorService *services.TwoFactorService, jwtManager *auth.JWTManager, loginGuard *ratelimit.LoginGuard, auditLogger *audit.Logger, m mailer.Mailer, appURL string) *AuthHandler {
 	return &AuthHandler{
-		userService: userService,
-		jwtManager:  jwtManager,
+		userService:      userService,
+		twoFactorService: twoFactorService,
+		jwtManager:       jwtManager,
+		loginGuard:       loginGuard,
+		auditLogger:      auditLogger,
+		mailer:           m,
+		appURL:           strings.TrimRight(appURL, "/"),
 	}
 }
 
-// Register handles user registration
+// Register handles user registration. The user is created once they open
+// the link emailed to them.
 func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	var req models.UserCreateRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Check if user already exists
-	_, err := h.userService.GetUserByEmail(req.Email)
-	if err == nil {
-		return c.Status(409).JSON(fiber.Map{"error": "User already exists"})
+	// The response is the same whether or not the email is registered; only
+	// its owner learns which, from the email they get
+	token, err := h.userService.StartRegistration(&req)
+	switch {
+	case err == nil:
+		h.sendEmail(mailer.Message{
+			To:      req.Email,
+			Subject: "Confirm your Taskman account",
+			Body: "Welcome to Taskman! Open this link within a day to finish creating your account:\n\n" +
+				h.appURL + "/register/confirm?token=" + token + "\n\n" +
+				"If you did not sign up, you can ignore this email.\n",
+		})
+	case errors.Is(err, services.ErrEmailTaken):
+		h.sendEmail(mailer.Message{
+			To:      req.Email,
+			Subject: "You already have a Taskman account",
+			Body: "Someone tried to create a Taskman account with this email address, but you already have one. " +
+				"Sign in at " + h.appURL + "/login instead.\n\n" +
+				"If this was not you, you can ignore this email.\n",
+		})
+	default:
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to register"})
 	}
 
-	// Create user
-	user, err := h.userService.CreateUser(&req)
+	return c.Status(202).JSON(fiber.Map{
+		"message": "Check your email to finish signing up",
+	})
+}
+
+// ConfirmRegistration handles the link from a registration email, creating
+// the user and signing them in
+func (h *AuthHandler) ConfirmRegistration(c *fiber.Ctx) error {
+	var req models.RegistrationConfirmRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if req.Token == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Token is required"})
+	}
+
+	user, err := h.userService.CompleteRegistration(req.Token)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidRegistration) {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid or expired link"})
+		}
+		if errors.Is(err, services.ErrEmailTaken) {
+			return c.Status(400).JSON(fiber.Map{"error": "Email is already registered"})
+		}
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to create user"})
 	}
 
@@ -63,15 +121,61 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Get user by email
-	user, err := h.userService.GetUserByEmail(req.Email)
+	if wait := h.loginGuard.Check(c.IP(), req.Email); wait > 0 {
+		h.auditLogger.RecordRequest(c, audit.Entry{
+			ActorEmail: req.Email,
+			Action:     audit.ActionLoginFailed,
+			TargetType: audit.TargetUser,
+			Metadata:   map[string]interface{}{"reason": "throttled"},
+		})
+		return tooManyAttempts(c, wait)
+	}
+
+	user, err := h.userService.Authenticate(req.Email, req.Password)
 	if err != nil {
+		if !errors.Is(err, services.ErrInvalidCredentials) {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to sign in"})
+		}
+
+		entry := audit.Entry{
+			ActorEmail: req.Email,
+			Action:     audit.ActionLoginFailed,
+			TargetType: audit.TargetUser,
+			Metadata:   map[string]interface{}{"reason": "unknown_email"},
+		}
+		if user != nil {
+			entry.ActorID = &user.ID
+			entry.TargetID = &user.ID
+			entry.Metadata = map[string]interface{}{"reason": "invalid_password"}
+		}
+		h.auditLogger.RecordRequest(c, entry)
+
+		if h.loginGuard.Failure(c.IP(), req.Email) {
+			entry.Action = audit.ActionLoginLocked
+			entry.Metadata = nil
+			h.auditLogger.RecordRequest(c, entry)
+		}
 		return c.Status(401).JSON(fiber.Map{"error": "Invalid credentials"})
 	}
+	h.loginGuard.Success(req.Email)
 
-	// Validate password
-	if err := h.userService.ValidatePassword(user, req.Password); err != nil {
-		return c.Status(401).JSON(fiber.Map{"error": "Invalid credentials"})
+	// With two-factor authentication on, the password only earns a challenge
+	// token to exchange for a session at /auth/2fa/verify
+	twoFactor, err := h.twoFactorService.IsEnabled(user.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to check two-factor authentication"})
+	}
+	if twoFactor {
+		challenge, err := h.jwtManager.GenerateChallengeToken(user.ID, user.Email)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to generate token"})
+		}
+
+		return c.JSON(fiber.Map{
+			"message":             "Two-factor authentication required",
+			"two_factor_required": true,
+			"challenge_token":     challenge,
+		})
 	}
 
 	// Generate JWT token
@@ -80,6 +184,14 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to generate token"})
 	}
 
+	h.auditLogger.RecordRequest(c, audit.Entry{
+		ActorID:    &user.ID,
+		ActorEmail: user.Email,
+		Action:     audit.ActionLogin,
+		TargetType: audit.TargetUser,
+		TargetID:   &user.ID,
+	})
+
 	return c.JSON(fiber.Map{
 		"message": "Login successful",
 		"user":    user.ToResponse(),
@@ -87,6 +199,17 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	})
 }
 
+// tooManyAttempts responds to a throttled sign-in, telling the client when
+// to try again
+func tooManyAttempts(c *fiber.Ctx, wait time.Duration) error {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return c.Status(429).JSON(fiber.Map{
+		"error":       "Too many failed attempts, try again later",
+		"retry_after": seconds,
+	})
+}
+
 // GetMe handles getting current user info
 func (h *AuthHandler) GetMe(c *fiber.Ctx) error {
 	userID, err := middleware.GetUserIDFromContext(c)
@@ -129,6 +252,12 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 	})
 }
 
+// GetJWKS handles publishing the public keys tokens are signed with
+func (h *AuthHandler) GetJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(h.jwtManager.JWKS())
+}
+
 // GetProfile handles getting user profile
 func (h *AuthHandler) GetProfile(c *fiber.Ctx) error {
 	userID, err := middleware.GetUserIDFromContext(c)
@@ -192,3 +321,13 @@ func (h *AuthHandler) UpdateProfile(c *fiber.Ctx) error {
 		"user":    user.ToResponse(),
 	})
 }
+
+// sendEmail sends an email in the background, so that how long the mail
+// server takes does not show in the response
+func (h *AuthHandler) sendEmail(msg mailer.Message) {
+	go func() {
+		if err := h.mailer.Send(msg); err != nil {
+			log.Printf("Failed to send email to %s: %v", msg.To, err)
+		}
+	}()
+}
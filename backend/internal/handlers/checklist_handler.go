@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ChecklistHandler handles checklist requests for tasks
+type ChecklistHandler struct {
+	checklistService *services.ChecklistService
+	taskService      *services.TaskService
+	orgService       *services.OrganizationService
+}
+
+// NewChecklistHandler creates a new checklist handler
+func NewChecklistHandler(checklistService *services.ChecklistService, taskService *services.TaskService, orgService *services.OrganizationService) *ChecklistHandler {
+	return &ChecklistHandler{
+		checklistService: checklistService,
+		taskService:      taskService,
+		orgService:       orgService,
+	}
+}
+
+// GetChecklist handles getting a task's checklist
+func (h *ChecklistHandler) GetChecklist(c *fiber.Ctx) error {
+	task, _, err := authorizeTask(c, h.orgService, h.taskService, false)
+	if err != nil {
+		return err
+	}
+
+	items, err := h.checklistService.GetItems(task.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get checklist"})
+	}
+
+	return c.JSON(fiber.Map{
+		"checklist": items,
+	})
+}
+
+// AddChecklistItem handles adding an item to a task's checklist
+func (h *ChecklistHandler) AddChecklistItem(c *fiber.Ctx) error {
+	task, _, err := authorizeTask(c, h.orgService, h.taskService, true)
+	if err != nil {
+		return err
+	}
+
+	var req models.ChecklistItemCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if req.Text == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Text is required"})
+	}
+
+	item, err := h.checklistService.AddItem(task.ID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidChecklistAssignee) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to add checklist item"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Checklist item added successfully",
+		"item":    item,
+	})
+}
+
+// UpdateChecklistItem handles updating a checklist item
+func (h *ChecklistHandler) UpdateChecklistItem(c *fiber.Ctx) error {
+	task, _, err := authorizeTask(c, h.orgService, h.taskService, true)
+	if err != nil {
+		return err
+	}
+
+	item, err := h.getItem(c, task.ID)
+	if err != nil {
+		return err
+	}
+
+	var req models.ChecklistItemUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	updatedItem, err := h.checklistService.UpdateItem(task.ID, item.ID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidChecklistAssignee) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update checklist item"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Checklist item updated successfully",
+		"item":    updatedItem,
+	})
+}
+
+// DeleteChecklistItem handles deleting a checklist item
+func (h *ChecklistHandler) DeleteChecklistItem(c *fiber.Ctx) error {
+	task, _, err := authorizeTask(c, h.orgService, h.taskService, true)
+	if err != nil {
+		return err
+	}
+
+	item, err := h.getItem(c, task.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := h.checklistService.DeleteItem(item.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete checklist item"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Checklist item deleted successfully",
+	})
+}
+
+// ReorderChecklist handles reordering a task's checklist
+func (h *ChecklistHandler) ReorderChecklist(c *fiber.Ctx) error {
+	task, _, err := authorizeTask(c, h.orgService, h.taskService, true)
+	if err != nil {
+		return err
+	}
+
+	var req models.ChecklistReorderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.checklistService.ReorderItems(task.ID, req.ItemIDs); err != nil {
+		if errors.Is(err, services.ErrInvalidChecklistOrder) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to reorder checklist"})
+	}
+
+	items, err := h.checklistService.GetItems(task.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get checklist"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":   "Checklist reordered successfully",
+		"checklist": items,
+	})
+}
+
+// getItem resolves the checklist item from the route and checks it belongs to the task
+func (h *ChecklistHandler) getItem(c *fiber.Ctx, taskID uuid.UUID) (*models.ChecklistItem, error) {
+	itemID, err := uuid.Parse(c.Params("itemId"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid checklist item ID")
+	}
+
+	item, err := h.checklistService.GetItemByID(itemID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Checklist item not found")
+	}
+
+	if item.TaskID != taskID {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Checklist item does not belong to this task")
+	}
+
+	return item, nil
+}
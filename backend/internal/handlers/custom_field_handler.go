@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"errors"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// CustomFieldHandler handles organization custom field requests
+type CustomFieldHandler struct {
+	customFieldService *services.CustomFieldService
+	orgService         *services.OrganizationService
+}
+
+// NewCustomFieldHandler creates a new custom field handler
+func NewCustomFieldHandler(customFieldService *services.CustomFieldService, orgService *services.OrganizationService) *CustomFieldHandler {
+	return &CustomFieldHandler{
+		customFieldService: customFieldService,
+		orgService:         orgService,
+	}
+}
+
+// GetCustomFields handles getting an organization's custom fields, optionally
+// only those of one entity
+func (h *CustomFieldHandler) GetCustomFields(c *fiber.Ctx) error {
+	orgID, _, err := authorizeOrg(c, h.orgService)
+	if err != nil {
+		return err
+	}
+
+	entity := models.CustomFieldEntity(c.Query("entity"))
+	if entity != "" && !entity.IsValid() {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid entity"})
+	}
+
+	fields, err := h.customFieldService.GetFields(orgID, entity)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get custom fields"})
+	}
+
+	return c.JSON(fiber.Map{
+		"custom_fields": fields,
+	})
+}
+
+// CreateCustomField handles defining a custom field (admin only)
+func (h *CustomFieldHandler) CreateCustomField(c *fiber.Ctx) error {
+	orgID, role, err := authorizeOrg(c, h.orgService)
+	if err != nil {
+		return err
+	}
+
+	if role != models.RoleAdmin {
+		return c.Status(403).JSON(fiber.Map{"error": "Admin access required"})
+	}
+
+	var req models.CustomFieldCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	field, err := h.customFieldService.CreateField(orgID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCustomField) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create custom field"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":      "Custom field created successfully",
+		"custom_field": field,
+	})
+}
+
+// UpdateCustomField handles updating a custom field (admin only)
+func (h *CustomFieldHandler) UpdateCustomField(c *fiber.Ctx) error {
+	field, err := h.getAdminCustomField(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.CustomFieldUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	updatedField, err := h.customFieldService.UpdateField(field.ID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCustomField) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update custom field"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":      "Custom field updated successfully",
+		"custom_field": updatedField,
+	})
+}
+
+// DeleteCustomField handles deleting a custom field and its values (admin only)
+func (h *CustomFieldHandler) DeleteCustomField(c *fiber.Ctx) error {
+	field, err := h.getAdminCustomField(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.customFieldService.DeleteField(field.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete custom field"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Custom field deleted successfully",
+	})
+}
+
+// getAdminCustomField checks that the caller is an organization admin and
+// resolves the custom field from the route
+func (h *CustomFieldHandler) getAdminCustomField(c *fiber.Ctx) (*models.CustomField, error) {
+	orgID, role, err := authorizeOrg(c, h.orgService)
+	if err != nil {
+		return nil, err
+	}
+
+	if role != models.RoleAdmin {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Admin access required")
+	}
+
+	fieldID, err := uuid.Parse(c.Params("fieldId"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid custom field ID")
+	}
+
+	field, err := h.customFieldService.GetFieldByID(fieldID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Custom field not found")
+	}
+
+	if field.OrgID != orgID {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Custom field does not belong to this organization")
+	}
+
+	return field, nil
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"taskman-backend/internal/middleware"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// DependencyHandler handles blocked-by relationships between tasks
+type DependencyHandler struct {
+	dependencyService *services.DependencyService
+	taskService       *services.TaskService
+	orgService        *services.OrganizationService
+}
+
+// NewDependencyHandler creates a new dependency handler
+func NewDependencyHandler(dependencyService *services.DependencyService, taskService *services.TaskService, orgService *services.OrganizationService) *DependencyHandler {
+	return &DependencyHandler{
+		dependencyService: dependencyService,
+		taskService:       taskService,
+		orgService:        orgService,
+	}
+}
+
+// GetDependencies handles getting the tasks blocking and blocked by a task
+func (h *DependencyHandler) GetDependencies(c *fiber.Ctx) error {
+	task, _, err := authorizeTask(c, h.orgService, h.taskService, false)
+	if err != nil {
+		return err
+	}
+
+	blockedBy, err := h.dependencyService.GetBlockingTasks(task.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get blocking tasks"})
+	}
+
+	blocking, err := h.dependencyService.GetBlockedTasks(task.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get blocked tasks"})
+	}
+
+	return c.JSON(fiber.Map{
+		"blocked_by": blockedBy,
+		"blocking":   blocking,
+	})
+}
+
+// AddDependency handles marking a task as blocked by another task
+func (h *DependencyHandler) AddDependency(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	task, _, err := authorizeTask(c, h.orgService, h.taskService, true)
+	if err != nil {
+		return err
+	}
+
+	var req models.TaskDependencyCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if req.BlockedByTaskID == uuid.Nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Blocking task ID is required"})
+	}
+
+	dependency, err := h.dependencyService.AddDependency(task.ID, req.BlockedByTaskID, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidDependency) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to add dependency"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":    "Dependency added successfully",
+		"dependency": dependency,
+	})
+}
+
+// RemoveDependency handles removing a blocked-by relationship
+func (h *DependencyHandler) RemoveDependency(c *fiber.Ctx) error {
+	task, _, err := authorizeTask(c, h.orgService, h.taskService, true)
+	if err != nil {
+		return err
+	}
+
+	blockedByIDStr := c.Params("blockedById")
+	blockedByID, err := uuid.Parse(blockedByIDStr)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid blocking task ID"})
+	}
+
+	err = h.dependencyService.RemoveDependency(task.ID, blockedByID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Dependency not found"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Dependency removed successfully",
+	})
+}
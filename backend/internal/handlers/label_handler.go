@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// LabelHandler handles organization label requests
+type LabelHandler struct {
+	labelService *services.LabelService
+	orgService   *services.OrganizationService
+}
+
+// NewLabelHandler creates a new label handler
+func NewLabelHandler(labelService *services.LabelService, orgService *services.OrganizationService) *LabelHandler {
+	return &LabelHandler{
+		labelService: labelService,
+		orgService:   orgService,
+	}
+}
+
+// GetLabels handles getting an organization's labels
+func (h *LabelHandler) GetLabels(c *fiber.Ctx) error {
+	orgID, _, err := authorizeOrg(c, h.orgService)
+	if err != nil {
+		return err
+	}
+
+	labels, err := h.labelService.GetLabels(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get labels"})
+	}
+
+	return c.JSON(fiber.Map{
+		"labels": labels,
+	})
+}
+
+// CreateLabel handles creating a label (admin only)
+func (h *LabelHandler) CreateLabel(c *fiber.Ctx) error {
+	orgID, role, err := authorizeOrg(c, h.orgService)
+	if err != nil {
+		return err
+	}
+
+	if role != models.RoleAdmin {
+		return c.Status(403).JSON(fiber.Map{"error": "Admin access required"})
+	}
+
+	var req models.LabelCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	label, err := h.labelService.CreateLabel(orgID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidLabel) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create label"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Label created successfully",
+		"label":   label,
+	})
+}
+
+// UpdateLabel handles updating a label (admin only)
+func (h *LabelHandler) UpdateLabel(c *fiber.Ctx) error {
+	label, err := h.getAdminLabel(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.LabelUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	updatedLabel, err := h.labelService.UpdateLabel(label.ID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidLabel) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update label"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Label updated successfully",
+		"label":   updatedLabel,
+	})
+}
+
+// DeleteLabel handles deleting a label (admin only)
+func (h *LabelHandler) DeleteLabel(c *fiber.Ctx) error {
+	label, err := h.getAdminLabel(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.labelService.DeleteLabel(label.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete label"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Label deleted successfully",
+	})
+}
+
+// getAdminLabel checks that the caller is an organization admin and resolves
+// the label from the route
+func (h *LabelHandler) getAdminLabel(c *fiber.Ctx) (*models.Label, error) {
+	orgID, role, err := authorizeOrg(c, h.orgService)
+	if err != nil {
+		return nil, err
+	}
+
+	if role != models.RoleAdmin {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Admin access required")
+	}
+
+	labelID, err := uuid.Parse(c.Params("labelId"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid label ID")
+	}
+
+	label, err := h.labelService.GetLabelByID(labelID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Label not found")
+	}
+
+	if label.OrgID != orgID {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Label does not belong to this organization")
+	}
+
+	return label, nil
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"taskman-backend/internal/middleware"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// NotificationHandler handles notification inbox requests
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(notificationService *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// GetNotifications handles listing the current user's notifications
+func (h *NotificationHandler) GetNotifications(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	session := middleware.GetOrgSessionFromContext(c)
+	filter := &models.NotificationFilter{
+		Session:    session,
+		UnreadOnly: c.QueryBool("unread", false),
+		Page:       c.QueryInt("page", 1),
+		PerPage:    c.QueryInt("per_page", models.DefaultNotificationsPerPage),
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PerPage < 1 {
+		filter.PerPage = models.DefaultNotificationsPerPage
+	}
+	if filter.PerPage > models.MaxNotificationsPerPage {
+		filter.PerPage = models.MaxNotificationsPerPage
+	}
+
+	notifications, total, err := h.notificationService.GetNotifications(userID, filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get notifications"})
+	}
+
+	unread, err := h.notificationService.GetUnreadCount(userID, session)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get notifications"})
+	}
+
+	return c.JSON(fiber.Map{
+		"notifications": notifications,
+		"total":         total,
+		"unread_count":  unread,
+		"page":          filter.Page,
+		"per_page":      filter.PerPage,
+	})
+}
+
+// MarkNotificationRead handles marking one of the current user's notifications as read
+func (h *NotificationHandler) MarkNotificationRead(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	notificationID, err := uuid.Parse(c.Params("notificationId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid notification ID"})
+	}
+
+	notification, err := h.notificationService.MarkRead(userID, notificationID)
+	if err != nil {
+		if errors.Is(err, services.ErrNotificationNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Notification not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to mark notification as read"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":      "Notification marked as read",
+		"notification": notification,
+	})
+}
+
+// MarkAllNotificationsRead handles marking all of the current user's notifications as read
+func (h *NotificationHandler) MarkAllNotificationsRead(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	updated, err := h.notificationService.MarkAllRead(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to mark notifications as read"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Notifications marked as read",
+		"updated": updated,
+	})
+}
+
+// GetPreferences handles getting the current user's notification preferences
+func (h *NotificationHandler) GetPreferences(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	preferences, err := h.notificationService.GetPreferences(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get notification preferences"})
+	}
+
+	return c.JSON(fiber.Map{
+		"preferences": preferences,
+	})
+}
+
+// UpdatePreferences handles updating the current user's notification preferences
+func (h *NotificationHandler) UpdatePreferences(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	var req models.NotificationPreferencesUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	preferences, err := h.notificationService.UpdatePreferences(userID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPreferences) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update notification preferences"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":     "Notification preferences updated successfully",
+		"preferences": preferences,
+	})
+}
+
+// userIDs collects the IDs of a list of users
+func userIDs(users []models.UserResponse) []uuid.UUID {
+	ids := make([]uuid.UUID, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	return ids
+}
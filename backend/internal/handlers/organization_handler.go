@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"taskman-backend/internal/audit"
 	"taskman-backend/internal/middleware"
 	"taskman-backend/internal/models"
 	"taskman-backend/internal/services"
@@ -11,13 +13,15 @@ import (
 
 // OrganizationHandler handles organization-related requests
 type OrganizationHandler struct {
-	orgService *services.OrganizationService
+	orgService  *services.OrganizationService
+	auditLogger *audit.Logger
 }
 
 // NewOrganizationHandler creates a new organization handler
-func NewOrganizationHandler(orgService *services.OrganizationService) *OrganizationHandler {
+func NewOrganizationHandler(orgService *services.OrganizationService, auditLogger *audit.Logger) *OrganizationHandler {
 	return &OrganizationHandler{
-		orgService: orgService,
+		orgService:  orgService,
+		auditLogger: auditLogger,
 	}
 }
 
@@ -62,12 +66,23 @@ func (h *OrganizationHandler) JoinOrganization(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid or expired invite code"})
 	}
 
+	if org.SSOOnly && middleware.GetAuthMethodFromContext(c) != models.AuthMethodSSO {
+		return c.Status(403).JSON(fiber.Map{"error": "This organization requires single sign-on"})
+	}
+
 	// Add user as member
 	err = h.orgService.AddMember(org.ID, userID, models.RoleMember)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to join organization"})
 	}
 
+	h.auditLogger.RecordRequest(c, audit.Entry{
+		OrgID:      &org.ID,
+		Action:     audit.ActionMemberJoined,
+		TargetType: audit.TargetUser,
+		TargetID:   &userID,
+	})
+
 	return c.JSON(fiber.Map{
 		"message":      "Successfully joined organization",
 		"organization": org.ToResponse(),
@@ -86,8 +101,16 @@ func (h *OrganizationHandler) GetUserOrganizations(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to get organizations"})
 	}
 
+	// Hide organizations outside an API token's scope
+	visible := orgs[:0]
+	for _, org := range orgs {
+		if middleware.TokenAllowsOrg(c, org.ID) {
+			visible = append(visible, org)
+		}
+	}
+
 	return c.JSON(fiber.Map{
-		"organizations": orgs,
+		"organizations": visible,
 	})
 }
 
@@ -192,9 +215,19 @@ func (h *OrganizationHandler) RemoveMember(c *fiber.Ctx) error {
 	// Remove member
 	err = h.orgService.RemoveMember(orgID, memberID)
 	if err != nil {
+		if errors.Is(err, services.ErrLastAdmin) {
+			return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to remove member"})
 	}
 
+	h.auditLogger.RecordRequest(c, audit.Entry{
+		OrgID:      &orgID,
+		Action:     audit.ActionMemberRemoved,
+		TargetType: audit.TargetUser,
+		TargetID:   &memberID,
+	})
+
 	return c.JSON(fiber.Map{
 		"message": "Member removed successfully",
 	})
@@ -224,7 +257,9 @@ func (h *OrganizationHandler) UpdateOrganization(c *fiber.Ctx) error {
 	}
 
 	var req struct {
-		Name string `json:"name" validate:"omitempty,min=2,max=100"`
+		Name            string `json:"name" validate:"omitempty,min=2,max=100"`
+		SSOOnly         *bool  `json:"sso_only"`
+		RequireAdmin2FA *bool  `json:"require_admin_2fa"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -236,6 +271,19 @@ func (h *OrganizationHandler) UpdateOrganization(c *fiber.Ctx) error {
 	if req.Name != "" {
 		updates["name"] = req.Name
 	}
+	if req.SSOOnly != nil {
+		// Keep admins from locking themselves out with a password session
+		if *req.SSOOnly && middleware.GetAuthMethodFromContext(c) != models.AuthMethodSSO {
+			return c.Status(403).JSON(fiber.Map{"error": "Sign in with single sign-on to require it"})
+		}
+		updates["sso_only"] = *req.SSOOnly
+	}
+	if req.RequireAdmin2FA != nil {
+		if *req.RequireAdmin2FA && middleware.GetAuthMethodFromContext(c) != models.AuthMethodSSO && !middleware.GetTwoFactorFromContext(c) {
+			return c.Status(403).JSON(fiber.Map{"error": "Sign in with two-factor authentication to require it"})
+		}
+		updates["require_admin_2fa"] = *req.RequireAdmin2FA
+	}
 
 	if len(updates) == 0 {
 		return c.Status(400).JSON(fiber.Map{"error": "No fields to update"})
@@ -247,6 +295,14 @@ func (h *OrganizationHandler) UpdateOrganization(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to update organization"})
 	}
 
+	h.auditLogger.RecordRequest(c, audit.Entry{
+		OrgID:      &orgID,
+		Action:     audit.ActionOrganizationUpdated,
+		TargetType: audit.TargetOrganization,
+		TargetID:   &orgID,
+		Metadata:   updates,
+	})
+
 	// Get updated organization
 	org, err := h.orgService.GetOrganizationByID(orgID)
 	if err != nil {
@@ -258,3 +314,116 @@ func (h *OrganizationHandler) UpdateOrganization(c *fiber.Ctx) error {
 		"organization": org.ToResponse(),
 	})
 }
+
+// UpdateMemberRole handles changing a member's role (admin only)
+func (h *OrganizationHandler) UpdateMemberRole(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	orgIDStr := c.Params("orgId")
+	orgID, err := uuid.Parse(orgIDStr)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid organization ID"})
+	}
+
+	memberIDStr := c.Params("memberId")
+	memberID, err := uuid.Parse(memberIDStr)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid member ID"})
+	}
+
+	// Check if user is admin
+	isMember, role, err := h.orgService.IsMember(orgID, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to check membership"})
+	}
+
+	if !isMember || role != models.RoleAdmin {
+		return c.Status(403).JSON(fiber.Map{"error": "Admin access required"})
+	}
+
+	var req struct {
+		Role string `json:"role" validate:"required,oneof=member admin"`
+	}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if req.Role != models.RoleMember && req.Role != models.RoleAdmin {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid role"})
+	}
+
+	// Get current role of the member
+	isTargetMember, oldRole, err := h.orgService.IsMember(orgID, memberID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to check membership"})
+	}
+
+	if !isTargetMember {
+		return c.Status(404).JSON(fiber.Map{"error": "Member not found"})
+	}
+
+	err = h.orgService.UpdateMemberRole(orgID, memberID, req.Role)
+	if err != nil {
+		if errors.Is(err, services.ErrLastAdmin) {
+			return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update member role"})
+	}
+
+	h.auditLogger.RecordRequest(c, audit.Entry{
+		OrgID:      &orgID,
+		Action:     audit.ActionMemberRoleChanged,
+		TargetType: audit.TargetUser,
+		TargetID:   &memberID,
+		Metadata:   map[string]interface{}{"old_role": oldRole, "new_role": req.Role},
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Member role updated successfully",
+	})
+}
+
+// RegenerateInviteCode handles issuing a new invite code (admin only)
+func (h *OrganizationHandler) RegenerateInviteCode(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	orgIDStr := c.Params("orgId")
+	orgID, err := uuid.Parse(orgIDStr)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid organization ID"})
+	}
+
+	// Check if user is admin
+	isMember, role, err := h.orgService.IsMember(orgID, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to check membership"})
+	}
+
+	if !isMember || role != models.RoleAdmin {
+		return c.Status(403).JSON(fiber.Map{"error": "Admin access required"})
+	}
+
+	org, err := h.orgService.RegenerateInviteCode(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to regenerate invite code"})
+	}
+
+	h.auditLogger.RecordRequest(c, audit.Entry{
+		OrgID:      &orgID,
+		Action:     audit.ActionInviteRegenerated,
+		TargetType: audit.TargetOrganization,
+		TargetID:   &orgID,
+	})
+
+	return c.JSON(fiber.Map{
+		"message":      "Invite code regenerated successfully",
+		"organization": org.ToResponse(),
+	})
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"taskman-backend/internal/audit"
 	"taskman-backend/internal/middleware"
 	"taskman-backend/internal/models"
 	"taskman-backend/internal/services"
@@ -11,15 +13,21 @@ import (
 
 // ProjectHandler handles project-related requests
 type ProjectHandler struct {
-	projectService *services.ProjectService
-	orgService     *services.OrganizationService
+	projectService      *services.ProjectService
+	orgService          *services.OrganizationService
+	auditLogger         *audit.Logger
+	notificationService *services.NotificationService
+	webhookService      *services.WebhookService
 }
 
 // NewProjectHandler creates a new project handler
-func NewProjectHandler(projectService *services.ProjectService, orgService *services.OrganizationService) *ProjectHandler {
+func NewProjectHandler(projectService *services.ProjectService, orgService *services.OrganizationService, auditLogger *audit.Logger, notificationService *services.NotificationService, webhookService *services.WebhookService) *ProjectHandler {
 	return &ProjectHandler{
-		projectService: projectService,
-		orgService:     orgService,
+		projectService:      projectService,
+		orgService:          orgService,
+		auditLogger:         auditLogger,
+		notificationService: notificationService,
+		webhookService:      webhookService,
 	}
 }
 
@@ -53,12 +61,25 @@ func (h *ProjectHandler) CreateProject(c *fiber.Ctx) error {
 
 	project, err := h.projectService.CreateProject(&req, orgID, userID)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidLabel) || errors.Is(err, services.ErrInvalidCustomField) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to create project"})
 	}
 
+	h.notificationService.ProjectAssigneesChanged(project, nil, req.AssigneeIDs, userID)
+
+	response := project.ToResponse()
+	response.Labels, err = h.projectService.GetProjectLabels(project.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get project labels"})
+	}
+
+	h.webhookService.Publish(orgID, models.WebhookEventProjectCreated, models.ProjectCreatedData{Project: response, UserID: userID})
+
 	return c.Status(201).JSON(fiber.Map{
 		"message": "Project created successfully",
-		"project": project.ToResponse(),
+		"project": response,
 	})
 }
 
@@ -85,7 +106,9 @@ func (h *ProjectHandler) GetProjects(c *fiber.Ctx) error {
 		return c.Status(403).JSON(fiber.Map{"error": "Not a member of this organization"})
 	}
 
-	projects, err := h.projectService.GetProjectsByOrg(orgID, &userID)
+	filter := &models.ProjectFilter{CustomFields: parseCustomFieldFilter(c)}
+
+	projects, err := h.projectService.GetProjectsByOrg(orgID, &userID, filter)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to get projects"})
 	}
@@ -143,6 +166,11 @@ func (h *ProjectHandler) GetProject(c *fiber.Ctx) error {
 	response := project.ToResponse()
 	response.Assignees = assignees
 
+	response.Labels, err = h.projectService.GetProjectLabels(projectID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get project labels"})
+	}
+
 	return c.JSON(fiber.Map{
 		"project": response,
 	})
@@ -201,8 +229,19 @@ func (h *ProjectHandler) UpdateProject(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	var previousAssignees []models.UserResponse
+	if req.AssigneeIDs != nil {
+		previousAssignees, err = h.projectService.GetProjectAssignees(projectID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to get project assignees"})
+		}
+	}
+
 	updatedProject, err := h.projectService.UpdateProject(projectID, &req)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidLabel) || errors.Is(err, services.ErrInvalidCustomField) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to update project"})
 	}
 
@@ -212,9 +251,20 @@ func (h *ProjectHandler) UpdateProject(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to get project assignees"})
 	}
 
+	if req.AssigneeIDs != nil {
+		h.notificationService.ProjectAssigneesChanged(updatedProject, userIDs(previousAssignees), userIDs(assignees), userID)
+	}
+
 	response := updatedProject.ToResponse()
 	response.Assignees = assignees
 
+	response.Labels, err = h.projectService.GetProjectLabels(projectID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get project labels"})
+	}
+
+	h.webhookService.Publish(orgID, models.WebhookEventProjectUpdated, models.ProjectUpdatedData{Project: response, UserID: userID})
+
 	return c.JSON(fiber.Map{
 		"message": "Project updated successfully",
 		"project": response,
@@ -275,6 +325,15 @@ func (h *ProjectHandler) DeleteProject(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete project"})
 	}
 
+	h.auditLogger.RecordRequest(c, audit.Entry{
+		OrgID:      &orgID,
+		Action:     audit.ActionProjectDeleted,
+		TargetType: audit.TargetProject,
+		TargetID:   &projectID,
+		Metadata:   map[string]interface{}{"name": project.Name},
+	})
+	h.webhookService.Publish(orgID, models.WebhookEventProjectDeleted, models.ProjectDeletedData{ProjectID: projectID, OrgID: orgID, UserID: userID})
+
 	return c.JSON(fiber.Map{
 		"message": "Project deleted successfully",
 	})
@@ -331,19 +390,33 @@ func (h *ProjectHandler) MoveProject(c *fiber.Ctx) error {
 
 	var req struct {
 		Status models.ProjectStatus `json:"status" validate:"required"`
+		models.CardPosition
 	}
 
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	err = h.projectService.MoveProject(projectID, req.Status)
+	rank, err := h.projectService.MoveProject(projectID, req.Status, req.CardPosition)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidPosition) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to move project"})
 	}
 
+	h.webhookService.Publish(orgID, models.WebhookEventProjectMoved, models.ProjectMovedData{
+		ProjectID: projectID,
+		OrgID:     orgID,
+		OldStatus: string(project.Status),
+		NewStatus: string(req.Status),
+		Rank:      rank,
+		UserID:    userID,
+	})
+
 	return c.JSON(fiber.Map{
 		"message": "Project moved successfully",
+		"rank":    rank,
 	})
 }
 
@@ -373,17 +446,44 @@ func (h *ProjectHandler) BulkMoveProjects(c *fiber.Ctx) error {
 	var req struct {
 		ProjectIDs []uuid.UUID          `json:"project_ids" validate:"required,min=1"`
 		Status     models.ProjectStatus `json:"status" validate:"required"`
+		models.CardPosition
 	}
 
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	err = h.projectService.BulkMoveProjects(req.ProjectIDs, req.Status)
+	// Validate that all projects belong to the organization
+	projects := make([]*models.Project, 0, len(req.ProjectIDs))
+	for _, projectID := range req.ProjectIDs {
+		project, err := h.projectService.GetProjectByID(projectID)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "Project not found"})
+		}
+		if project.OrgID != orgID {
+			return c.Status(403).JSON(fiber.Map{"error": "Project does not belong to this organization"})
+		}
+		projects = append(projects, project)
+	}
+
+	err = h.projectService.BulkMoveProjects(orgID, req.ProjectIDs, req.Status, req.CardPosition)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidPosition) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to move projects"})
 	}
 
+	for _, project := range projects {
+		h.webhookService.Publish(orgID, models.WebhookEventProjectMoved, models.ProjectMovedData{
+			ProjectID: project.ID,
+			OrgID:     orgID,
+			OldStatus: string(project.Status),
+			NewStatus: string(req.Status),
+			UserID:    userID,
+		})
+	}
+
 	return c.JSON(fiber.Map{
 		"message": "Projects moved successfully",
 	})
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"taskman-backend/internal/middleware"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// CloneProject handles creating a project from another one, optionally
+// with its tasks, assignees and labels, and deadlines shifted to a new
+// start date
+func (h *ProjectHandler) CloneProject(c *fiber.Ctx) error {
+	source, _, err := authorizeProject(c, h.orgService, h.projectService)
+	if err != nil {
+		return err
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	var req models.ProjectCloneRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	project, err := h.projectService.CloneProject(source.ID, &req, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidProjectCopy) || errors.Is(err, services.ErrInvalidLabel) || errors.Is(err, services.ErrInvalidCustomField) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to clone project"})
+	}
+
+	return h.respondProjectCopied(c, project, userID)
+}
+
+// GetTemplates handles listing an organization's project templates
+func (h *ProjectHandler) GetTemplates(c *fiber.Ctx) error {
+	orgID, _, err := authorizeOrg(c, h.orgService)
+	if err != nil {
+		return err
+	}
+
+	templates, err := h.projectService.GetTemplates(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get templates"})
+	}
+
+	responses := make([]models.ProjectTemplateResponse, len(templates))
+	for i := range templates {
+		responses[i] = templates[i].ToResponse()
+	}
+
+	return c.JSON(fiber.Map{
+		"templates": responses,
+	})
+}
+
+// GetTemplate handles getting a project template with its snapshot
+func (h *ProjectHandler) GetTemplate(c *fiber.Ctx) error {
+	template, _, err := h.getTemplate(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"template": template,
+	})
+}
+
+// CreateTemplate handles saving a project as a template of its organization
+func (h *ProjectHandler) CreateTemplate(c *fiber.Ctx) error {
+	project, _, err := authorizeProject(c, h.orgService, h.projectService)
+	if err != nil {
+		return err
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	var req models.ProjectTemplateCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	template, err := h.projectService.CreateTemplate(project.ID, &req, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidProjectCopy) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create template"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":  "Template created successfully",
+		"template": template.ToResponse(),
+	})
+}
+
+// InstantiateTemplate handles creating a project from a template
+func (h *ProjectHandler) InstantiateTemplate(c *fiber.Ctx) error {
+	template, _, err := h.getTemplate(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	var req models.ProjectCloneRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	project, err := h.projectService.InstantiateTemplate(template.ID, &req, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidProjectCopy) || errors.Is(err, services.ErrInvalidLabel) || errors.Is(err, services.ErrInvalidCustomField) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create project from template"})
+	}
+
+	return h.respondProjectCopied(c, project, userID)
+}
+
+// DeleteTemplate handles deleting a project template (creator or admin)
+func (h *ProjectHandler) DeleteTemplate(c *fiber.Ctx) error {
+	template, role, err := h.getTemplate(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	if template.CreatedBy != userID && role != models.RoleAdmin {
+		return c.Status(403).JSON(fiber.Map{"error": "Not authorized to delete this template"})
+	}
+
+	if err := h.projectService.DeleteTemplate(template.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete template"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Template deleted successfully",
+	})
+}
+
+// respondProjectCopied notifies the assignees of a cloned or instantiated
+// project, announces it to webhooks and sends it to the client
+func (h *ProjectHandler) respondProjectCopied(c *fiber.Ctx, project *models.Project, userID uuid.UUID) error {
+	assignees, err := h.projectService.GetProjectAssignees(project.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get project assignees"})
+	}
+
+	assigneeIDs := make([]uuid.UUID, len(assignees))
+	for i, assignee := range assignees {
+		assigneeIDs[i] = assignee.ID
+	}
+	h.notificationService.ProjectAssigneesChanged(project, nil, assigneeIDs, userID)
+
+	response := project.ToResponse()
+	response.Labels, err = h.projectService.GetProjectLabels(project.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get project labels"})
+	}
+
+	h.webhookService.Publish(project.OrgID, models.WebhookEventProjectCreated, models.ProjectCreatedData{Project: response, UserID: userID})
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Project created successfully",
+		"project": response,
+	})
+}
+
+// getTemplate resolves a template of the organization in the route, with
+// the caller's role
+func (h *ProjectHandler) getTemplate(c *fiber.Ctx) (*models.ProjectTemplate, string, error) {
+	orgID, role, err := authorizeOrg(c, h.orgService)
+	if err != nil {
+		return nil, "", err
+	}
+
+	templateID, err := uuid.Parse(c.Params("templateId"))
+	if err != nil {
+		return nil, "", fiber.NewError(fiber.StatusBadRequest, "Invalid template ID")
+	}
+
+	template, err := h.projectService.GetTemplateByID(templateID)
+	if err != nil {
+		return nil, "", fiber.NewError(fiber.StatusNotFound, "Template not found")
+	}
+
+	if template.OrgID != orgID {
+		return nil, "", fiber.NewError(fiber.StatusForbidden, "Template does not belong to this organization")
+	}
+
+	return template, role, nil
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"taskman-backend/internal/middleware"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RecurringTaskHandler handles recurring task requests
+type RecurringTaskHandler struct {
+	recurringTaskService *services.RecurringTaskService
+	projectService       *services.ProjectService
+	orgService           *services.OrganizationService
+}
+
+// NewRecurringTaskHandler creates a new recurring task handler
+func NewRecurringTaskHandler(recurringTaskService *services.RecurringTaskService, projectService *services.ProjectService, orgService *services.OrganizationService) *RecurringTaskHandler {
+	return &RecurringTaskHandler{
+		recurringTaskService: recurringTaskService,
+		projectService:       projectService,
+		orgService:           orgService,
+	}
+}
+
+// GetRecurringTasks handles getting a project's recurring tasks
+func (h *RecurringTaskHandler) GetRecurringTasks(c *fiber.Ctx) error {
+	project, _, err := authorizeProject(c, h.orgService, h.projectService)
+	if err != nil {
+		return err
+	}
+
+	recurring, err := h.recurringTaskService.GetRecurringTasks(project.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get recurring tasks"})
+	}
+
+	return c.JSON(fiber.Map{
+		"recurring_tasks": recurring,
+	})
+}
+
+// CreateRecurringTask handles creating a recurring task (project assignees)
+func (h *RecurringTaskHandler) CreateRecurringTask(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	project, _, err := authorizeProject(c, h.orgService, h.projectService)
+	if err != nil {
+		return err
+	}
+
+	// Check if user is project assignee
+	isAssignee, err := h.projectService.IsProjectAssignee(project.ID, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to check project assignment"})
+	}
+
+	if !isAssignee {
+		return c.Status(403).JSON(fiber.Map{"error": "Not assigned to this project"})
+	}
+
+	var req models.RecurringTaskCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	recurring, err := h.recurringTaskService.CreateRecurringTask(&req, project.ID, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRecurrence) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create recurring task"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":        "Recurring task created successfully",
+		"recurring_task": recurring,
+	})
+}
+
+// UpdateRecurringTask handles updating a recurring task (admin or its creator)
+func (h *RecurringTaskHandler) UpdateRecurringTask(c *fiber.Ctx) error {
+	recurring, err := h.getOwnRecurringTask(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.RecurringTaskUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	updated, err := h.recurringTaskService.UpdateRecurringTask(recurring.ID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRecurrence) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update recurring task"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":        "Recurring task updated successfully",
+		"recurring_task": updated,
+	})
+}
+
+// DeleteRecurringTask handles deleting a recurring task (admin or its creator)
+func (h *RecurringTaskHandler) DeleteRecurringTask(c *fiber.Ctx) error {
+	recurring, err := h.getOwnRecurringTask(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.recurringTaskService.DeleteRecurringTask(recurring.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete recurring task"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Recurring task deleted successfully",
+	})
+}
+
+// getOwnRecurringTask resolves the recurring task from the route and checks
+// that it belongs to the project and that the caller created it or is an admin
+func (h *RecurringTaskHandler) getOwnRecurringTask(c *fiber.Ctx) (*models.RecurringTask, error) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid user context")
+	}
+
+	project, role, err := authorizeProject(c, h.orgService, h.projectService)
+	if err != nil {
+		return nil, err
+	}
+
+	recurringID, err := uuid.Parse(c.Params("recurringId"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid recurring task ID")
+	}
+
+	recurring, err := h.recurringTaskService.GetRecurringTaskByID(recurringID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Recurring task not found")
+	}
+
+	if recurring.ProjectID != project.ID {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Recurring task does not belong to this project")
+	}
+
+	if role != models.RoleAdmin && recurring.CreatedBy != userID {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Not authorized to change this recurring task")
+	}
+
+	return recurring, nil
+}
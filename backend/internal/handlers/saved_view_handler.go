@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"errors"
+	"taskman-backend/internal/middleware"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// SavedViewHandler handles saved task list views
+type SavedViewHandler struct {
+	savedViewService *services.SavedViewService
+	projectService   *services.ProjectService
+	orgService       *services.OrganizationService
+}
+
+// NewSavedViewHandler creates a new saved view handler
+func NewSavedViewHandler(savedViewService *services.SavedViewService, projectService *services.ProjectService, orgService *services.OrganizationService) *SavedViewHandler {
+	return &SavedViewHandler{
+		savedViewService: savedViewService,
+		projectService:   projectService,
+		orgService:       orgService,
+	}
+}
+
+// GetMyTaskViews handles listing the current user's views of /me/tasks
+func (h *SavedViewHandler) GetMyTaskViews(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	views, err := h.savedViewService.GetMyTaskViews(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get views"})
+	}
+
+	return c.JSON(fiber.Map{
+		"views": views,
+	})
+}
+
+// CreateMyTaskView handles saving a view of /me/tasks
+func (h *SavedViewHandler) CreateMyTaskView(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	return h.createView(c, userID, uuid.Nil, nil)
+}
+
+// UpdateMyTaskView handles updating one of the current user's views of
+// /me/tasks
+func (h *SavedViewHandler) UpdateMyTaskView(c *fiber.Ctx) error {
+	view, err := h.getMyTaskView(c)
+	if err != nil {
+		return err
+	}
+
+	return h.updateView(c, view, uuid.Nil)
+}
+
+// DeleteMyTaskView handles deleting one of the current user's views of
+// /me/tasks
+func (h *SavedViewHandler) DeleteMyTaskView(c *fiber.Ctx) error {
+	view, err := h.getMyTaskView(c)
+	if err != nil {
+		return err
+	}
+
+	return h.deleteView(c, view)
+}
+
+// GetProjectViews handles listing the views of a project's task list: the
+// caller's own and those shared with the organization
+func (h *SavedViewHandler) GetProjectViews(c *fiber.Ctx) error {
+	project, _, err := authorizeProject(c, h.orgService, h.projectService)
+	if err != nil {
+		return err
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	views, err := h.savedViewService.GetProjectViews(project.ID, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get views"})
+	}
+
+	return c.JSON(fiber.Map{
+		"views": views,
+	})
+}
+
+// CreateProjectView handles saving a view of a project's task list
+func (h *SavedViewHandler) CreateProjectView(c *fiber.Ctx) error {
+	project, _, err := authorizeProject(c, h.orgService, h.projectService)
+	if err != nil {
+		return err
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	return h.createView(c, userID, project.OrgID, &project.ID)
+}
+
+// UpdateProjectView handles updating a view of a project's task list. Only
+// its owner can change a view, shared or not.
+func (h *SavedViewHandler) UpdateProjectView(c *fiber.Ctx) error {
+	view, project, _, err := h.getProjectView(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	if view.UserID != userID {
+		return c.Status(403).JSON(fiber.Map{"error": "Only the owner can change this view"})
+	}
+
+	return h.updateView(c, view, project.OrgID)
+}
+
+// DeleteProjectView handles deleting a view of a project's task list. Admins
+// can also remove views shared with the organization.
+func (h *SavedViewHandler) DeleteProjectView(c *fiber.Ctx) error {
+	view, _, role, err := h.getProjectView(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	if view.UserID != userID && role != models.RoleAdmin {
+		return c.Status(403).JSON(fiber.Map{"error": "Only the owner or an admin can delete this view"})
+	}
+
+	return h.deleteView(c, view)
+}
+
+// createView saves a view from the request body
+func (h *SavedViewHandler) createView(c *fiber.Ctx, userID, orgID uuid.UUID, projectID *uuid.UUID) error {
+	var req models.SavedViewCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	view, err := h.savedViewService.CreateView(userID, orgID, projectID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSavedView) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create view"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "View created successfully",
+		"view":    view,
+	})
+}
+
+// updateView applies the request body to a view
+func (h *SavedViewHandler) updateView(c *fiber.Ctx, view *models.SavedView, orgID uuid.UUID) error {
+	var req models.SavedViewUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	updatedView, err := h.savedViewService.UpdateView(view.ID, orgID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSavedView) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update view"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "View updated successfully",
+		"view":    updatedView,
+	})
+}
+
+// deleteView deletes a view
+func (h *SavedViewHandler) deleteView(c *fiber.Ctx, view *models.SavedView) error {
+	if err := h.savedViewService.DeleteView(view.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete view"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "View deleted successfully",
+	})
+}
+
+// getMyTaskView resolves one of the caller's views of /me/tasks from the
+// route
+func (h *SavedViewHandler) getMyTaskView(c *fiber.Ctx) (*models.SavedView, error) {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid user context")
+	}
+
+	viewID, err := uuid.Parse(c.Params("viewId"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid view ID")
+	}
+
+	view, err := h.savedViewService.GetViewByID(viewID)
+	if err != nil || view.UserID != userID || view.ProjectID != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "View not found")
+	}
+
+	return view, nil
+}
+
+// getProjectView resolves a view of the project in the route that the caller
+// can see, with the project and the caller's role
+func (h *SavedViewHandler) getProjectView(c *fiber.Ctx) (*models.SavedView, *models.Project, string, error) {
+	project, role, err := authorizeProject(c, h.orgService, h.projectService)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return nil, nil, "", fiber.NewError(fiber.StatusUnauthorized, "Invalid user context")
+	}
+
+	viewID, err := uuid.Parse(c.Params("viewId"))
+	if err != nil {
+		return nil, nil, "", fiber.NewError(fiber.StatusBadRequest, "Invalid view ID")
+	}
+
+	view, err := h.savedViewService.GetViewByID(viewID)
+	if err != nil || view.ProjectID == nil || *view.ProjectID != project.ID || (view.UserID != userID && !view.Shared) {
+		return nil, nil, "", fiber.NewError(fiber.StatusNotFound, "View not found")
+	}
+
+	return view, project, role, nil
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"strings"
+	"taskman-backend/internal/middleware"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxSearchQueryLength bounds search queries
+const maxSearchQueryLength = 200
+
+// SearchHandler handles searching an organization
+type SearchHandler struct {
+	searchService *services.SearchService
+	orgService    *services.OrganizationService
+}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler(searchService *services.SearchService, orgService *services.OrganizationService) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+		orgService:    orgService,
+	}
+}
+
+// Search handles full-text search over an organization's tasks and
+// projects. Results can be narrowed by type, project_id, status (comma
+// separated) and assignee_id, which may be "me".
+func (h *SearchHandler) Search(c *fiber.Ctx) error {
+	orgID, _, err := authorizeOrg(c, h.orgService)
+	if err != nil {
+		return err
+	}
+
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Search query is required"})
+	}
+	if len(query) > maxSearchQueryLength {
+		return c.Status(400).JSON(fiber.Map{"error": "Search query is too long"})
+	}
+
+	filter := &models.SearchFilter{
+		Page:    c.QueryInt("page", 1),
+		PerPage: c.QueryInt("per_page", models.DefaultSearchPerPage),
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PerPage < 1 {
+		filter.PerPage = models.DefaultSearchPerPage
+	}
+	if filter.PerPage > models.MaxSearchPerPage {
+		filter.PerPage = models.MaxSearchPerPage
+	}
+
+	if types := c.Query("type"); types != "" {
+		for _, value := range strings.Split(types, ",") {
+			searchType := strings.TrimSpace(value)
+			if searchType != models.SearchTypeTask && searchType != models.SearchTypeProject {
+				return c.Status(400).JSON(fiber.Map{"error": "Invalid type"})
+			}
+			filter.Types = append(filter.Types, searchType)
+		}
+	}
+
+	if projectIDStr := c.Query("project_id"); projectIDStr != "" {
+		projectID, err := uuid.Parse(projectIDStr)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid project ID"})
+		}
+		filter.ProjectID = &projectID
+	}
+
+	if statuses := c.Query("status"); statuses != "" {
+		for _, value := range strings.Split(statuses, ",") {
+			if status := strings.TrimSpace(value); status != "" {
+				filter.Statuses = append(filter.Statuses, status)
+			}
+		}
+	}
+
+	if assignee := c.Query("assignee_id"); assignee != "" {
+		var assigneeID uuid.UUID
+		if assignee == "me" {
+			assigneeID, err = middleware.GetUserIDFromContext(c)
+		} else {
+			assigneeID, err = uuid.Parse(assignee)
+		}
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid assignee ID"})
+		}
+		filter.AssigneeID = &assigneeID
+	}
+
+	results, err := h.searchService.Search(orgID, query, filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to search"})
+	}
+
+	return c.JSON(fiber.Map{
+		"results":  results,
+		"page":     filter.Page,
+		"per_page": filter.PerPage,
+	})
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"taskman-backend/internal/audit"
+	"taskman-backend/internal/auth"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/oidc"
+	"taskman-backend/internal/services"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ssoStateCookie holds the state of the login a browser started, so that a
+// callback is only accepted from that same browser
+const ssoStateCookie = "sso_state"
+
+// ssoCookiePath scopes the state cookie to the SSO routes
+const ssoCookiePath = "/api/v1/auth/sso"
+
+// SSOHandler handles single sign-on logins through OpenID Connect providers
+type SSOHandler struct {
+	ssoService  *services.SSOService
+	providers   map[string]*oidc.Provider
+	jwtManager  *auth.JWTManager
+	auditLogger *audit.Logger
+}
+
+// NewSSOHandler creates a new SSO handler for the configured providers
+func NewSSOHandler(ssoService *services.SSOService, providers []*oidc.Provider, jwtManager *auth.JWTManager, auditLogger *audit.Logger) *SSOHandler {
+	byName := make(map[string]*oidc.Provider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+
+	return &SSOHandler{
+		ssoService:  ssoService,
+		providers:   byName,
+		jwtManager:  jwtManager,
+		auditLogger: auditLogger,
+	}
+}
+
+// GetProviders handles listing the providers users can sign in with
+func (h *SSOHandler) GetProviders(c *fiber.Ctx) error {
+	names := make([]string, 0, len(h.providers))
+	for name := range h.providers {
+		names = append(names, name)
+	}
+
+	return c.JSON(fiber.Map{
+		"providers": names,
+	})
+}
+
+// Login handles starting a login by redirecting to the provider
+func (h *SSOHandler) Login(c *fiber.Ctx) error {
+	provider, ok := h.providers[c.Params("provider")]
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"error": "Unknown identity provider"})
+	}
+
+	login, err := h.ssoService.StartLogin(provider.Name())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to start login"})
+	}
+
+	authURL, err := provider.AuthCodeURL(login.State, login.Nonce, oidc.S256Challenge(login.CodeVerifier))
+	if err != nil {
+		log.Printf("SSO provider %s unavailable: %v", provider.Name(), err)
+		return c.Status(502).JSON(fiber.Map{"error": "Identity provider unavailable"})
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     ssoStateCookie,
+		Value:    login.State,
+		Path:     ssoCookiePath,
+		Expires:  login.ExpiresAt,
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+// Callback handles the provider's authorization response, given either as
+// query parameters or as a JSON body forwarded by the frontend, and signs
+// the user in
+func (h *SSOHandler) Callback(c *fiber.Ctx) error {
+	provider, ok := h.providers[c.Params("provider")]
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"error": "Unknown identity provider"})
+	}
+
+	if errorCode := c.Query("error"); errorCode != "" {
+		return c.Status(401).JSON(fiber.Map{"error": "Sign-in was rejected by the identity provider: " + errorCode})
+	}
+
+	req := models.SSOCallbackRequest{Code: c.Query("code"), State: c.Query("state")}
+	if req.Code == "" && c.Method() == fiber.MethodPost {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	if req.Code == "" || req.State == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Code and state are required"})
+	}
+
+	// The state must come back to the browser that started the login, or an
+	// attacker could finish their own login in someone else's browser
+	browserState := c.Cookies(ssoStateCookie)
+	c.Cookie(&fiber.Cookie{
+		Name:     ssoStateCookie,
+		Path:     ssoCookiePath,
+		Expires:  time.Unix(0, 0),
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	if browserState == "" || subtle.ConstantTimeCompare([]byte(browserState), []byte(req.State)) != 1 {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid or expired login"})
+	}
+
+	login, err := h.ssoService.ConsumeLoginState(provider.Name(), req.State)
+	if err != nil {
+		if errors.Is(err, services.ErrSSOLogin) {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid or expired login"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to complete login"})
+	}
+
+	claims, err := provider.Exchange(req.Code, login.CodeVerifier)
+	if err == nil && claims.Nonce != login.Nonce {
+		err = errors.New("nonce mismatch")
+	}
+	if err != nil {
+		log.Printf("SSO login through %s failed: %v", provider.Name(), err)
+		h.auditLogger.RecordRequest(c, audit.Entry{
+			Action:     audit.ActionLoginFailed,
+			TargetType: audit.TargetUser,
+			Metadata:   map[string]interface{}{"reason": "sso_failed", "provider": provider.Name()},
+		})
+		return c.Status(401).JSON(fiber.Map{"error": "Single sign-on failed"})
+	}
+
+	user, created, err := h.ssoService.SignIn(provider.Name(), claims)
+	if err != nil {
+		if errors.Is(err, services.ErrSSOLogin) {
+			h.auditLogger.RecordRequest(c, audit.Entry{
+				ActorEmail: claims.Email,
+				Action:     audit.ActionLoginFailed,
+				TargetType: audit.TargetUser,
+				Metadata:   map[string]interface{}{"reason": "sso_rejected", "provider": provider.Name()},
+			})
+			return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to sign in"})
+	}
+
+	token, err := h.jwtManager.GenerateTokenForMethod(user.ID, user.Email, models.AuthMethodSSO, false)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to generate token"})
+	}
+
+	h.auditLogger.RecordRequest(c, audit.Entry{
+		ActorID:    &user.ID,
+		ActorEmail: user.Email,
+		Action:     audit.ActionLogin,
+		TargetType: audit.TargetUser,
+		TargetID:   &user.ID,
+		Metadata:   map[string]interface{}{"method": models.AuthMethodSSO, "provider": provider.Name(), "created": created},
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Login successful",
+		"user":    user.ToResponse(),
+		"token":   token,
+	})
+}
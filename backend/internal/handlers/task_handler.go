@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"taskman-backend/internal/middleware"
 	"taskman-backend/internal/models"
 	"taskman-backend/internal/services"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -11,51 +16,38 @@ import (
 
 // TaskHandler handles task-related requests
 type TaskHandler struct {
-	taskService    *services.TaskService
-	projectService *services.ProjectService
-	orgService     *services.OrganizationService
+	taskService         *services.TaskService
+	projectService      *services.ProjectService
+	orgService          *services.OrganizationService
+	notificationService *services.NotificationService
+	webhookService      *services.WebhookService
 }
 
 // NewTaskHandler creates a new task handler
-func NewTaskHandler(taskService *services.TaskService, projectService *services.ProjectService, orgService *services.OrganizationService) *TaskHandler {
+func NewTaskHandler(taskService *services.TaskService, projectService *services.ProjectService, orgService *services.OrganizationService, notificationService *services.NotificationService, webhookService *services.WebhookService) *TaskHandler {
 	return &TaskHandler{
-		taskService:    taskService,
-		projectService: projectService,
-		orgService:     orgService,
+		taskService:         taskService,
+		projectService:      projectService,
+		orgService:          orgService,
+		notificationService: notificationService,
+		webhookService:      webhookService,
 	}
 }
 
 // CreateTask handles creating a new task
 func (h *TaskHandler) CreateTask(c *fiber.Ctx) error {
-	userID, err := middleware.GetUserIDFromContext(c)
+	project, _, err := authorizeProject(c, h.orgService, h.projectService)
 	if err != nil {
-		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+		return err
 	}
 
-	orgIDStr := c.Params("orgId")
-	orgID, err := uuid.Parse(orgIDStr)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid organization ID"})
-	}
-
-	projectIDStr := c.Params("projectId")
-	projectID, err := uuid.Parse(projectIDStr)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid project ID"})
-	}
-
-	// Check if user is member
-	isMember, _, err := h.orgService.IsMember(orgID, userID)
+	userID, err := middleware.GetUserIDFromContext(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to check membership"})
-	}
-
-	if !isMember {
-		return c.Status(403).JSON(fiber.Map{"error": "Not a member of this organization"})
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
 	}
 
 	// Check if user is project assignee
-	isAssignee, err := h.projectService.IsProjectAssignee(projectID, userID)
+	isAssignee, err := h.projectService.IsProjectAssignee(project.ID, userID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to check project assignment"})
 	}
@@ -69,47 +61,48 @@ func (h *TaskHandler) CreateTask(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	task, err := h.taskService.CreateTask(&req, projectID, userID)
+	task, err := h.taskService.CreateTask(&req, project.ID, userID)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidParentTask) || errors.Is(err, services.ErrInvalidTask) || errors.Is(err, services.ErrInvalidLabel) ||
+			errors.Is(err, services.ErrInvalidCustomField) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to create task"})
 	}
 
+	h.notificationService.TaskAssigneesChanged(task, nil, req.AssigneeIDs, userID)
+
+	response := task.ToResponse()
+	if err := h.taskService.EnrichTaskResponse(&response); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get task details"})
+	}
+
+	h.webhookService.Publish(project.OrgID, models.WebhookEventTaskCreated, models.TaskCreatedData{Task: response, UserID: userID})
+
 	return c.Status(201).JSON(fiber.Map{
 		"message": "Task created successfully",
-		"task":    task.ToResponse(),
+		"task":    response,
 	})
 }
 
 // GetTasks handles getting all tasks for a project
 func (h *TaskHandler) GetTasks(c *fiber.Ctx) error {
-	userID, err := middleware.GetUserIDFromContext(c)
-	if err != nil {
-		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
-	}
-
-	orgIDStr := c.Params("orgId")
-	orgID, err := uuid.Parse(orgIDStr)
+	project, _, err := authorizeProject(c, h.orgService, h.projectService)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid organization ID"})
+		return err
 	}
 
-	projectIDStr := c.Params("projectId")
-	projectID, err := uuid.Parse(projectIDStr)
+	userID, err := middleware.GetUserIDFromContext(c)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid project ID"})
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
 	}
 
-	// Check if user is member
-	isMember, _, err := h.orgService.IsMember(orgID, userID)
+	filter, err := parseTaskFilter(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to check membership"})
-	}
-
-	if !isMember {
-		return c.Status(403).JSON(fiber.Map{"error": "Not a member of this organization"})
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	tasks, err := h.taskService.GetTasksByProject(projectID, &userID)
+	tasks, err := h.taskService.GetTasksByProject(project.ID, &userID, filter)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to get tasks"})
 	}
@@ -119,53 +112,99 @@ func (h *TaskHandler) GetTasks(c *fiber.Ctx) error {
 	})
 }
 
-// GetTask handles getting a specific task
-func (h *TaskHandler) GetTask(c *fiber.Ctx) error {
+// GetMyTasks handles listing the tasks assigned to the current user across
+// their organizations. It accepts status (comma separated), due (overdue or
+// this_week), include_done, group_by (org or project), sort and pagination.
+func (h *TaskHandler) GetMyTasks(c *fiber.Ctx) error {
 	userID, err := middleware.GetUserIDFromContext(c)
 	if err != nil {
 		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
 	}
 
-	orgIDStr := c.Params("orgId")
-	orgID, err := uuid.Parse(orgIDStr)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid organization ID"})
+	filter := &models.MyTasksFilter{
+		Session:     middleware.GetOrgSessionFromContext(c),
+		Due:         c.Query("due"),
+		IncludeDone: c.QueryBool("include_done", false),
+		GroupBy:     c.Query("group_by"),
+		Page:        c.QueryInt("page", 1),
+		PerPage:     c.QueryInt("per_page", models.DefaultMyTasksPerPage),
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PerPage < 1 {
+		filter.PerPage = models.DefaultMyTasksPerPage
+	}
+	if filter.PerPage > models.MaxMyTasksPerPage {
+		filter.PerPage = models.MaxMyTasksPerPage
 	}
 
-	projectIDStr := c.Params("projectId")
-	projectID, err := uuid.Parse(projectIDStr)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid project ID"})
+	if statuses := c.Query("status"); statuses != "" {
+		for _, value := range strings.Split(statuses, ",") {
+			if status := strings.TrimSpace(value); status != "" {
+				filter.Statuses = append(filter.Statuses, models.TaskStatus(status))
+			}
+		}
 	}
 
-	taskIDStr := c.Params("taskId")
-	taskID, err := uuid.Parse(taskIDStr)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid task ID"})
+	switch filter.Due {
+	case "", models.MyTasksDueOverdue, models.MyTasksDueThisWeek:
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid due filter"})
 	}
 
-	// Check if user is member
-	isMember, _, err := h.orgService.IsMember(orgID, userID)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to check membership"})
+	switch filter.GroupBy {
+	case "", models.MyTasksGroupByOrg, models.MyTasksGroupByProject:
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid grouping"})
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		var ok bool
+		if filter.Sort, ok = models.ParseTaskSort(sort, models.MyTaskSorts); !ok {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid sort"})
+		}
 	}
 
-	if !isMember {
-		return c.Status(403).JSON(fiber.Map{"error": "Not a member of this organization"})
+	if orgIDStr := c.Query("org_id"); orgIDStr != "" {
+		orgID, err := uuid.Parse(orgIDStr)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid organization ID"})
+		}
+		filter.OrgIDs = []uuid.UUID{orgID}
+	} else if identity := middleware.GetAPITokenFromContext(c); identity != nil {
+		// Scoped API tokens only see their organizations
+		filter.OrgIDs = identity.OrgIDs
 	}
 
-	task, err := h.taskService.GetTaskByID(taskID)
+	tasks, total, err := h.taskService.GetMyTasks(userID, filter, time.Now())
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Task not found"})
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get tasks"})
 	}
 
-	// Verify task belongs to project
-	if task.ProjectID != projectID {
-		return c.Status(403).JSON(fiber.Map{"error": "Task does not belong to this project"})
+	response := fiber.Map{
+		"total":    total,
+		"page":     filter.Page,
+		"per_page": filter.PerPage,
+	}
+	if filter.GroupBy != "" {
+		response["groups"] = services.GroupMyTasks(tasks, filter.GroupBy)
+	} else {
+		response["tasks"] = tasks
+	}
+
+	return c.JSON(response)
+}
+
+// GetTask handles getting a specific task
+func (h *TaskHandler) GetTask(c *fiber.Ctx) error {
+	task, _, err := authorizeTask(c, h.orgService, h.taskService, false)
+	if err != nil {
+		return err
 	}
 
 	// Get assignees
-	assignees, err := h.taskService.GetTaskAssignees(taskID)
+	assignees, err := h.taskService.GetTaskAssignees(task.ID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to get task assignees"})
 	}
@@ -173,63 +212,48 @@ func (h *TaskHandler) GetTask(c *fiber.Ctx) error {
 	response := task.ToResponse()
 	response.Assignees = assignees
 
+	if err := h.taskService.EnrichTaskResponse(&response); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get task details"})
+	}
+
 	return c.JSON(fiber.Map{
 		"task": response,
 	})
 }
 
-// UpdateTask handles updating a task
-func (h *TaskHandler) UpdateTask(c *fiber.Ctx) error {
-	userID, err := middleware.GetUserIDFromContext(c)
-	if err != nil {
-		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
-	}
-
-	orgIDStr := c.Params("orgId")
-	orgID, err := uuid.Parse(orgIDStr)
+// GetSubtasks handles getting the direct subtasks of a task
+func (h *TaskHandler) GetSubtasks(c *fiber.Ctx) error {
+	task, _, err := authorizeTask(c, h.orgService, h.taskService, false)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid organization ID"})
+		return err
 	}
 
-	projectIDStr := c.Params("projectId")
-	projectID, err := uuid.Parse(projectIDStr)
+	subtasks, err := h.taskService.GetSubtasks(task.ID)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid project ID"})
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get subtasks"})
 	}
 
-	taskIDStr := c.Params("taskId")
-	taskID, err := uuid.Parse(taskIDStr)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid task ID"})
-	}
+	return c.JSON(fiber.Map{
+		"subtasks": subtasks,
+	})
+}
 
-	// Check if user is member
-	isMember, _, err := h.orgService.IsMember(orgID, userID)
+// UpdateTask handles updating a task
+func (h *TaskHandler) UpdateTask(c *fiber.Ctx) error {
+	task, role, err := authorizeTask(c, h.orgService, h.taskService, true)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to check membership"})
+		return err
 	}
+	taskID := task.ID
 
-	if !isMember {
-		return c.Status(403).JSON(fiber.Map{"error": "Not a member of this organization"})
-	}
-
-	// Check if user is task assignee or creator
-	task, err := h.taskService.GetTaskByID(taskID)
+	userID, err := middleware.GetUserIDFromContext(c)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Task not found"})
-	}
-
-	if task.ProjectID != projectID {
-		return c.Status(403).JSON(fiber.Map{"error": "Task does not belong to this project"})
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
 	}
 
-	isAssignee, err := h.taskService.IsTaskAssignee(taskID, userID)
+	orgID, err := h.taskService.GetProjectOrgID(task.ProjectID)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to check task assignment"})
-	}
-
-	if !isAssignee && task.CreatedBy != userID {
-		return c.Status(403).JSON(fiber.Map{"error": "Not assigned to this task"})
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get project"})
 	}
 
 	var req models.TaskUpdateRequest
@@ -237,9 +261,22 @@ func (h *TaskHandler) UpdateTask(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	updatedTask, err := h.taskService.UpdateTask(taskID, &req)
+	var previousAssignees []models.UserResponse
+	if req.AssigneeIDs != nil {
+		previousAssignees, err = h.taskService.GetTaskAssignees(taskID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to get task assignees"})
+		}
+	}
+
+	opts := services.MoveOptions{IsAdmin: role == models.RoleAdmin, OverrideWIPLimit: req.OverrideWIPLimit}
+	updatedTask, warnings, err := h.taskService.UpdateTask(taskID, &req, opts)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to update task"})
+		if errors.Is(err, services.ErrInvalidParentTask) || errors.Is(err, services.ErrInvalidTask) || errors.Is(err, services.ErrInvalidLabel) ||
+			errors.Is(err, services.ErrInvalidCustomField) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return moveErrorResponse(c, err, "Failed to update task")
 	}
 
 	// Get assignees
@@ -248,75 +285,60 @@ func (h *TaskHandler) UpdateTask(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to get task assignees"})
 	}
 
+	if req.AssigneeIDs != nil {
+		h.notificationService.TaskAssigneesChanged(updatedTask, userIDs(previousAssignees), userIDs(assignees), userID)
+	}
+	h.notificationService.TaskStatusChanged(updatedTask, task.Status, updatedTask.Status, userID)
+
 	response := updatedTask.ToResponse()
 	response.Assignees = assignees
 
-	return c.JSON(fiber.Map{
-		"message": "Task updated successfully",
-		"task":    response,
-	})
-}
-
-// DeleteTask handles deleting a task
-func (h *TaskHandler) DeleteTask(c *fiber.Ctx) error {
-	userID, err := middleware.GetUserIDFromContext(c)
-	if err != nil {
-		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
-	}
-
-	orgIDStr := c.Params("orgId")
-	orgID, err := uuid.Parse(orgIDStr)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid organization ID"})
+	if err := h.taskService.EnrichTaskResponse(&response); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get task details"})
 	}
 
-	projectIDStr := c.Params("projectId")
-	projectID, err := uuid.Parse(projectIDStr)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid project ID"})
-	}
+	h.webhookService.Publish(orgID, models.WebhookEventTaskUpdated, models.TaskUpdatedData{Task: response, UserID: userID})
 
-	taskIDStr := c.Params("taskId")
-	taskID, err := uuid.Parse(taskIDStr)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid task ID"})
+	result := fiber.Map{
+		"message": "Task updated successfully",
+		"task":    response,
 	}
-
-	// Check if user is member
-	isMember, _, err := h.orgService.IsMember(orgID, userID)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to check membership"})
+	if len(warnings) > 0 {
+		result["warnings"] = warnings
 	}
 
-	if !isMember {
-		return c.Status(403).JSON(fiber.Map{"error": "Not a member of this organization"})
-	}
+	return c.JSON(result)
+}
 
-	// Check if user is task creator or admin
-	task, err := h.taskService.GetTaskByID(taskID)
+// DeleteTask handles deleting a task
+func (h *TaskHandler) DeleteTask(c *fiber.Ctx) error {
+	task, role, err := authorizeTask(c, h.orgService, h.taskService, false)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Task not found"})
+		return err
 	}
 
-	if task.ProjectID != projectID {
-		return c.Status(403).JSON(fiber.Map{"error": "Task does not belong to this project"})
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
 	}
 
 	// Check if user is creator or admin
-	isAdmin, _, err := h.orgService.IsMember(orgID, userID)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to check membership"})
+	if task.CreatedBy != userID && role != models.RoleAdmin {
+		return c.Status(403).JSON(fiber.Map{"error": "Not authorized to delete this task"})
 	}
 
-	if task.CreatedBy != userID && !isAdmin {
-		return c.Status(403).JSON(fiber.Map{"error": "Not authorized to delete this task"})
+	orgID, err := h.taskService.GetProjectOrgID(task.ProjectID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get project"})
 	}
 
-	err = h.taskService.DeleteTask(taskID)
+	err = h.taskService.DeleteTask(task.ID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete task"})
 	}
 
+	h.webhookService.Publish(orgID, models.WebhookEventTaskDeleted, models.TaskDeletedData{TaskID: task.ID, ProjectID: task.ProjectID, UserID: userID})
+
 	return c.JSON(fiber.Map{
 		"message": "Task deleted successfully",
 	})
@@ -324,47 +346,20 @@ func (h *TaskHandler) DeleteTask(c *fiber.Ctx) error {
 
 // MoveTask handles moving a task to a different status
 func (h *TaskHandler) MoveTask(c *fiber.Ctx) error {
-	userID, err := middleware.GetUserIDFromContext(c)
-	if err != nil {
-		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
-	}
-
-	orgIDStr := c.Params("orgId")
-	orgID, err := uuid.Parse(orgIDStr)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid organization ID"})
-	}
-
-	projectIDStr := c.Params("projectId")
-	projectID, err := uuid.Parse(projectIDStr)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid project ID"})
-	}
-
-	taskIDStr := c.Params("taskId")
-	taskID, err := uuid.Parse(taskIDStr)
+	task, role, err := authorizeTask(c, h.orgService, h.taskService, false)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid task ID"})
+		return err
 	}
+	taskID := task.ID
 
-	// Check if user is member
-	isMember, _, err := h.orgService.IsMember(orgID, userID)
+	userID, err := middleware.GetUserIDFromContext(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to check membership"})
-	}
-
-	if !isMember {
-		return c.Status(403).JSON(fiber.Map{"error": "Not a member of this organization"})
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
 	}
 
-	// Get task to verify it belongs to the project
-	task, err := h.taskService.GetTaskByID(taskID)
+	orgID, err := h.taskService.GetProjectOrgID(task.ProjectID)
 	if err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Task not found"})
-	}
-
-	if task.ProjectID != projectID {
-		return c.Status(403).JSON(fiber.Map{"error": "Task does not belong to this project"})
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get project"})
 	}
 
 	// Check if user is task assignee
@@ -382,43 +377,43 @@ func (h *TaskHandler) MoveTask(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	err = h.taskService.MoveTask(taskID, req.Status)
+	opts := services.MoveOptions{IsAdmin: role == models.RoleAdmin, OverrideWIPLimit: req.OverrideWIPLimit, Position: req.CardPosition}
+	moved, err := h.taskService.MoveTask(taskID, req.Status, opts)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to move task"})
+		return moveErrorResponse(c, err, "Failed to move task")
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "Task moved successfully",
+	h.notificationService.TaskStatusChanged(task, task.Status, req.Status, userID)
+	h.webhookService.Publish(orgID, models.WebhookEventTaskMoved, models.TaskMovedData{
+		TaskID:    taskID,
+		ProjectID: task.ProjectID,
+		OldStatus: string(task.Status),
+		NewStatus: string(req.Status),
+		Rank:      moved.Rank,
+		UserID:    userID,
 	})
-}
 
-// BulkMoveTasks handles moving multiple tasks to a different status
-func (h *TaskHandler) BulkMoveTasks(c *fiber.Ctx) error {
-	userID, err := middleware.GetUserIDFromContext(c)
-	if err != nil {
-		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	result := fiber.Map{
+		"message": "Task moved successfully",
+		"rank":    moved.Rank,
 	}
-
-	orgIDStr := c.Params("orgId")
-	orgID, err := uuid.Parse(orgIDStr)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid organization ID"})
+	if len(moved.Warnings) > 0 {
+		result["warnings"] = moved.Warnings
 	}
 
-	projectIDStr := c.Params("projectId")
-	projectID, err := uuid.Parse(projectIDStr)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid project ID"})
-	}
+	return c.JSON(result)
+}
 
-	// Check if user is member
-	isMember, _, err := h.orgService.IsMember(orgID, userID)
+// BulkMoveTasks handles moving multiple tasks to a different status
+func (h *TaskHandler) BulkMoveTasks(c *fiber.Ctx) error {
+	project, role, err := authorizeProject(c, h.orgService, h.projectService)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to check membership"})
+		return err
 	}
 
-	if !isMember {
-		return c.Status(403).JSON(fiber.Map{"error": "Not a member of this organization"})
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
 	}
 
 	var req models.TaskBulkMoveRequest
@@ -427,22 +422,147 @@ func (h *TaskHandler) BulkMoveTasks(c *fiber.Ctx) error {
 	}
 
 	// Validate that all tasks belong to the project
+	tasks := make([]*models.Task, 0, len(req.TaskIDs))
 	for _, taskID := range req.TaskIDs {
 		task, err := h.taskService.GetTaskByID(taskID)
 		if err != nil {
 			return c.Status(404).JSON(fiber.Map{"error": "Task not found"})
 		}
-		if task.ProjectID != projectID {
+		if task.ProjectID != project.ID {
 			return c.Status(403).JSON(fiber.Map{"error": "Task does not belong to this project"})
 		}
+		tasks = append(tasks, task)
 	}
 
-	err = h.taskService.BulkMoveTasks(req.TaskIDs, req.Status)
+	opts := services.MoveOptions{IsAdmin: role == models.RoleAdmin, OverrideWIPLimit: req.OverrideWIPLimit, Position: req.CardPosition}
+	moved, err := h.taskService.BulkMoveTasks(req.TaskIDs, req.Status, opts)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to move tasks"})
+		return moveErrorResponse(c, err, "Failed to move tasks")
 	}
 
-	return c.JSON(fiber.Map{
+	for _, task := range tasks {
+		h.notificationService.TaskStatusChanged(task, task.Status, req.Status, userID)
+		h.webhookService.Publish(project.OrgID, models.WebhookEventTaskMoved, models.TaskMovedData{
+			TaskID:    task.ID,
+			ProjectID: project.ID,
+			OldStatus: string(task.Status),
+			NewStatus: string(req.Status),
+			Rank:      moved.Ranks[task.ID],
+			UserID:    userID,
+		})
+	}
+
+	result := fiber.Map{
 		"message": "Tasks moved successfully",
+	}
+	if len(moved.Warnings) > 0 {
+		result["warnings"] = moved.Warnings
+	}
+
+	return c.JSON(result)
+}
+
+// moveErrorResponse renders the errors a task status change can fail with
+func moveErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	if errors.Is(err, services.ErrInvalidPosition) {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	var blockedErr *services.BlockedTaskError
+	if errors.As(err, &blockedErr) {
+		return c.Status(409).JSON(fiber.Map{"error": blockedErr.Error(), "task_id": blockedErr.TaskID, "blocked_by": blockedErr.BlockedBy})
+	}
+	var statusErr *services.InvalidStatusError
+	if errors.As(err, &statusErr) {
+		return c.Status(422).JSON(fiber.Map{"error": statusErr.Error(), "allowed_statuses": statusErr.Allowed})
+	}
+	var wipErr *services.WIPLimitError
+	if errors.As(err, &wipErr) {
+		return c.Status(409).JSON(fiber.Map{"error": wipErr.Error(), "exceeded": wipErr.Exceeded})
+	}
+	var transitionErr *services.TransitionNotAllowedError
+	if errors.As(err, &transitionErr) {
+		return c.Status(422).JSON(fiber.Map{
+			"error":               transitionErr.Error(),
+			"from":                transitionErr.From,
+			"to":                  transitionErr.To,
+			"allowed_transitions": transitionErr.Allowed,
+		})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": fallback})
+}
+
+// parseTaskFilter builds a task filter from query parameters. List values
+// are comma separated.
+func parseTaskFilter(c *fiber.Ctx) (*models.TaskFilter, error) {
+	filter := &models.TaskFilter{}
+
+	if priorities := c.Query("priority"); priorities != "" {
+		for _, value := range strings.Split(priorities, ",") {
+			priority := models.TaskPriority(strings.TrimSpace(value))
+			if !priority.IsValid() {
+				return nil, errors.New("Invalid priority")
+			}
+			filter.Priorities = append(filter.Priorities, priority)
+		}
+	}
+
+	if labels := c.Query("labels"); labels != "" {
+		for _, value := range strings.Split(labels, ",") {
+			labelID, err := uuid.Parse(strings.TrimSpace(value))
+			if err != nil {
+				return nil, errors.New("Invalid label ID")
+			}
+			filter.LabelIDs = append(filter.LabelIDs, labelID)
+		}
+	}
+
+	bounds := []struct {
+		param  string
+		target **int
+	}{
+		{"min_points", &filter.MinStoryPoints},
+		{"max_points", &filter.MaxStoryPoints},
+		{"min_estimate", &filter.MinEstimate},
+		{"max_estimate", &filter.MaxEstimate},
+	}
+	for _, bound := range bounds {
+		value := c.Query(bound.param)
+		if value == "" {
+			continue
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 0 {
+			return nil, fmt.Errorf("Invalid %s", bound.param)
+		}
+		*bound.target = &number
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		var ok bool
+		if filter.Sort, ok = models.ParseTaskSort(sort, models.ProjectTaskSorts); !ok {
+			return nil, errors.New("Invalid sort")
+		}
+	}
+
+	filter.CustomFields = parseCustomFieldFilter(c)
+
+	return filter, nil
+}
+
+// parseCustomFieldFilter collects custom field filters given as query
+// parameters of the form cf.<key>=<value>
+func parseCustomFieldFilter(c *fiber.Ctx) map[string]string {
+	var values map[string]string
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		name := string(key)
+		if !strings.HasPrefix(name, "cf.") || len(name) == len("cf.") {
+			return
+		}
+		if values == nil {
+			values = make(map[string]string)
+		}
+		values[strings.TrimPrefix(name, "cf.")] = string(value)
 	})
+
+	return values
 }
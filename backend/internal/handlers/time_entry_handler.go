@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"taskman-backend/internal/middleware"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/services"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// timesheetDateLayout is the format of the timesheet range parameters
+const timesheetDateLayout = "2006-01-02"
+
+// maxTimesheetDays is the longest range a timesheet report may cover
+const maxTimesheetDays = 366
+
+// TimeEntryHandler handles time tracking requests
+type TimeEntryHandler struct {
+	timeEntryService *services.TimeEntryService
+	taskService      *services.TaskService
+	orgService       *services.OrganizationService
+}
+
+// NewTimeEntryHandler creates a new time entry handler
+func NewTimeEntryHandler(timeEntryService *services.TimeEntryService, taskService *services.TaskService, orgService *services.OrganizationService) *TimeEntryHandler {
+	return &TimeEntryHandler{
+		timeEntryService: timeEntryService,
+		taskService:      taskService,
+		orgService:       orgService,
+	}
+}
+
+// StartTimer handles starting a timer on a task
+func (h *TimeEntryHandler) StartTimer(c *fiber.Ctx) error {
+	task, _, err := authorizeTask(c, h.orgService, h.taskService, false)
+	if err != nil {
+		return err
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	var req models.TimerStartRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	entry, err := h.timeEntryService.StartTimer(task.ID, userID, req.Note)
+	if err != nil {
+		var runningErr *services.TimerRunningError
+		if errors.As(err, &runningErr) {
+			return c.Status(409).JSON(fiber.Map{
+				"error":   err.Error(),
+				"running": runningErr.Running,
+			})
+		}
+		if errors.Is(err, services.ErrInvalidTimeEntry) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to start timer"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":    "Timer started successfully",
+		"time_entry": entry,
+	})
+}
+
+// StopTimer handles stopping the caller's running timer
+func (h *TimeEntryHandler) StopTimer(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	running, err := h.timeEntryService.GetRunningTimer(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to stop timer"})
+	}
+	if running == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "No running timer"})
+	}
+	if err := h.authorizeTimer(c, running); err != nil {
+		return err
+	}
+
+	entry, err := h.timeEntryService.StopTimer(userID)
+	if err != nil {
+		if errors.Is(err, services.ErrNoRunningTimer) {
+			return c.Status(404).JSON(fiber.Map{"error": "No running timer"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to stop timer"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Timer stopped successfully",
+		"time_entry": entry,
+	})
+}
+
+// GetRunningTimer handles getting the caller's running timer, if any
+func (h *TimeEntryHandler) GetRunningTimer(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	entry, err := h.timeEntryService.GetRunningTimer(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get running timer"})
+	}
+	if entry != nil && h.authorizeTimer(c, entry) != nil {
+		// A timer in an organization the request cannot access is not shown
+		entry = nil
+	}
+
+	return c.JSON(fiber.Map{
+		"time_entry": entry,
+	})
+}
+
+// authorizeTimer checks that the request may act on the organization a
+// running timer's task belongs to, which the route does not name
+func (h *TimeEntryHandler) authorizeTimer(c *fiber.Ctx, entry *models.TimeEntry) error {
+	task, err := h.taskService.GetTaskByID(entry.TaskID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Task not found")
+	}
+
+	orgID, err := h.taskService.GetProjectOrgID(task.ProjectID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Project not found")
+	}
+
+	return authorizeResourceOrg(c, h.orgService, orgID)
+}
+
+// GetTimeEntries handles getting a task's time entries
+func (h *TimeEntryHandler) GetTimeEntries(c *fiber.Ctx) error {
+	task, _, err := authorizeTask(c, h.orgService, h.taskService, false)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.timeEntryService.GetTaskEntries(task.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get time entries"})
+	}
+
+	return c.JSON(fiber.Map{
+		"time_entries": entries,
+	})
+}
+
+// CreateTimeEntry handles logging time on a task manually
+func (h *TimeEntryHandler) CreateTimeEntry(c *fiber.Ctx) error {
+	task, _, err := authorizeTask(c, h.orgService, h.taskService, false)
+	if err != nil {
+		return err
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	var req models.TimeEntryCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	entry, err := h.timeEntryService.CreateEntry(task.ID, userID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTimeEntry) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create time entry"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message":    "Time entry created successfully",
+		"time_entry": entry,
+	})
+}
+
+// UpdateTimeEntry handles updating a time entry (its owner or an admin)
+func (h *TimeEntryHandler) UpdateTimeEntry(c *fiber.Ctx) error {
+	entry, err := h.getOwnEntry(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.TimeEntryUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	updatedEntry, err := h.timeEntryService.UpdateEntry(entry.ID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTimeEntry) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update time entry"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Time entry updated successfully",
+		"time_entry": updatedEntry,
+	})
+}
+
+// DeleteTimeEntry handles deleting a time entry (its owner or an admin)
+func (h *TimeEntryHandler) DeleteTimeEntry(c *fiber.Ctx) error {
+	entry, err := h.getOwnEntry(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.timeEntryService.DeleteEntry(entry.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete time entry"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Time entry deleted successfully",
+	})
+}
+
+// GetTimesheet handles the timesheet report of an organization, as JSON or
+// CSV. Members only see their own time; admins may see everyone's.
+func (h *TimeEntryHandler) GetTimesheet(c *fiber.Ctx) error {
+	orgID, role, err := authorizeOrg(c, h.orgService)
+	if err != nil {
+		return err
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	filter, err := parseTimesheetFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if role != models.RoleAdmin {
+		if filter.UserID != nil && *filter.UserID != userID {
+			return c.Status(403).JSON(fiber.Map{"error": "Admin access required"})
+		}
+		filter.UserID = &userID
+	}
+
+	rows, err := h.timeEntryService.GetTimesheet(orgID, filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get timesheet"})
+	}
+
+	switch c.Query("format", "json") {
+	case "json":
+		return c.JSON(fiber.Map{
+			"from":      filter.From.Format(timesheetDateLayout),
+			"to":        filter.To.AddDate(0, 0, -1).Format(timesheetDateLayout),
+			"timesheet": rows,
+		})
+	case "csv":
+		if err := services.WriteTimesheetCSV(c.Response().BodyWriter(), rows); err != nil {
+			c.Response().ResetBody()
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to export timesheet"})
+		}
+
+		filename := fmt.Sprintf("timesheet-%s-%s.csv", filter.From.Format("20060102"), filter.To.AddDate(0, 0, -1).Format("20060102"))
+		c.Set(fiber.HeaderContentType, "text/csv")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+		return nil
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid export format"})
+	}
+}
+
+// getOwnEntry resolves the time entry from the route and checks that it
+// belongs to the task and that the caller owns it or is an admin
+func (h *TimeEntryHandler) getOwnEntry(c *fiber.Ctx) (*models.TimeEntry, error) {
+	task, _, err := authorizeTask(c, h.orgService, h.taskService, false)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid user context")
+	}
+
+	entryID, err := uuid.Parse(c.Params("entryId"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid time entry ID")
+	}
+
+	entry, err := h.timeEntryService.GetEntryByID(entryID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Time entry not found")
+	}
+
+	if entry.TaskID != task.ID {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Time entry does not belong to this task")
+	}
+
+	if entry.UserID == userID {
+		return entry, nil
+	}
+
+	_, role, err := authorizeOrg(c, h.orgService)
+	if err != nil {
+		return nil, err
+	}
+
+	if role != models.RoleAdmin {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Not your time entry")
+	}
+
+	return entry, nil
+}
+
+// parseTimesheetFilter builds a timesheet filter from query parameters. The
+// from and to dates are inclusive.
+func parseTimesheetFilter(c *fiber.Ctx) (*models.TimesheetFilter, error) {
+	from, err := time.Parse(timesheetDateLayout, c.Query("from"))
+	if err != nil {
+		return nil, errors.New("Invalid from date, expected YYYY-MM-DD")
+	}
+
+	to, err := time.Parse(timesheetDateLayout, c.Query("to"))
+	if err != nil {
+		return nil, errors.New("Invalid to date, expected YYYY-MM-DD")
+	}
+
+	if to.Before(from) {
+		return nil, errors.New("Invalid date range")
+	}
+
+	if to.Sub(from) >= maxTimesheetDays*24*time.Hour {
+		return nil, fmt.Errorf("Date range cannot exceed %d days", maxTimesheetDays)
+	}
+
+	filter := &models.TimesheetFilter{
+		From: from,
+		To:   to.AddDate(0, 0, 1),
+	}
+
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			return nil, errors.New("Invalid user ID")
+		}
+		filter.UserID = &userID
+	}
+
+	if projectIDStr := c.Query("project_id"); projectIDStr != "" {
+		projectID, err := uuid.Parse(projectIDStr)
+		if err != nil {
+			return nil, errors.New("Invalid project ID")
+		}
+		filter.ProjectID = &projectID
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"errors"
+	"taskman-backend/internal/audit"
+	"taskman-backend/internal/auth"
+	"taskman-backend/internal/middleware"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/ratelimit"
+	"taskman-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TwoFactorHandler handles two-factor authentication setup and the second
+// step of password logins
+type TwoFactorHandler struct {
+	twoFactorService *services.TwoFactorService
+	userService      *services.UserService
+	jwtManager       *auth.JWTManager
+	loginGuard       *ratelimit.LoginGuard
+	auditLogger      *audit.Logger
+}
+
+// NewTwoFactorHandler creates a new two-factor handler
+func NewTwoFactorHandler(twoFactorService *services.TwoFactorService, userService *services.UserService, jwtManager *auth.JWTManager, loginGuard *ratelimit.LoginGuard, auditLogger *audit.Logger) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+		userService:      userService,
+		jwtManager:       jwtManager,
+		loginGuard:       loginGuard,
+		auditLogger:      auditLogger,
+	}
+}
+
+// GetStatus handles getting the caller's two-factor setup
+func (h *TwoFactorHandler) GetStatus(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	status, err := h.twoFactorService.GetStatus(userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get two-factor status"})
+	}
+
+	return c.JSON(fiber.Map{
+		"two_factor": status,
+	})
+}
+
+// Enroll handles generating a TOTP secret for the caller to add to their
+// authenticator app
+func (h *TwoFactorHandler) Enroll(c *fiber.Ctx) error {
+	userID, err := requireSession(c)
+	if err != nil {
+		return err
+	}
+
+	user, err := h.userService.GetUserByID(userID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "User not found"})
+	}
+
+	enrollment, err := h.twoFactorService.BeginEnrollment(userID, user.Email)
+	if err != nil {
+		if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+			return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to start two-factor enrollment"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Add the secret to your authenticator app, then confirm with a code",
+		"enrollment": enrollment,
+	})
+}
+
+// Enable handles confirming enrollment with a code. The recovery codes are
+// only ever returned here and when regenerated.
+func (h *TwoFactorHandler) Enable(c *fiber.Ctx) error {
+	userID, err := requireSession(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	codes, err := h.twoFactorService.Enable(userID, req.Code)
+	if err != nil {
+		return twoFactorError(c, err, "Failed to enable two-factor authentication")
+	}
+
+	h.auditLogger.RecordRequest(c, audit.Entry{
+		Action:     audit.ActionTwoFactorEnabled,
+		TargetType: audit.TargetUser,
+		TargetID:   &userID,
+	})
+
+	return c.JSON(fiber.Map{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// Disable handles turning off two-factor authentication
+func (h *TwoFactorHandler) Disable(c *fiber.Ctx) error {
+	userID, err := requireSession(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.twoFactorService.Disable(userID, req.Code); err != nil {
+		return twoFactorError(c, err, "Failed to disable two-factor authentication")
+	}
+
+	h.auditLogger.RecordRequest(c, audit.Entry{
+		Action:     audit.ActionTwoFactorDisabled,
+		TargetType: audit.TargetUser,
+		TargetID:   &userID,
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes handles replacing the caller's recovery codes
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	userID, err := requireSession(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		return twoFactorError(c, err, "Failed to regenerate recovery codes")
+	}
+
+	return c.JSON(fiber.Map{
+		"message":        "Recovery codes regenerated",
+		"recovery_codes": codes,
+	})
+}
+
+// Verify handles the second step of a password login, exchanging a
+// challenge token and a TOTP or recovery code for a session token. Wrong
+// codes count against the client's address as well as the user.
+func (h *TwoFactorHandler) Verify(c *fiber.Ctx) error {
+	if wait := h.loginGuard.Check(c.IP(), ""); wait > 0 {
+		return tooManyAttempts(c, wait)
+	}
+
+	var req models.TwoFactorVerifyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	claims, err := h.jwtManager.ValidateChallengeToken(req.ChallengeToken)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid or expired challenge"})
+	}
+
+	usedRecoveryCode, err := h.twoFactorService.Verify(claims.UserID, req.Code)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTwoFactorCode) || errors.Is(err, services.ErrTwoFactorLocked) {
+			h.auditLogger.RecordRequest(c, audit.Entry{
+				ActorID:    &claims.UserID,
+				ActorEmail: claims.Email,
+				Action:     audit.ActionLoginFailed,
+				TargetType: audit.TargetUser,
+				TargetID:   &claims.UserID,
+				Metadata:   map[string]interface{}{"reason": "invalid_2fa_code"},
+			})
+			h.loginGuard.Failure(c.IP(), "")
+		}
+		return twoFactorError(c, err, "Failed to verify two-factor code")
+	}
+
+	user, err := h.userService.GetUserByID(claims.UserID)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid or expired challenge"})
+	}
+
+	token, err := h.jwtManager.GenerateTokenForMethod(user.ID, user.Email, models.AuthMethodPassword, true)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to generate token"})
+	}
+
+	h.auditLogger.RecordRequest(c, audit.Entry{
+		ActorID:    &user.ID,
+		ActorEmail: user.Email,
+		Action:     audit.ActionLogin,
+		TargetType: audit.TargetUser,
+		TargetID:   &user.ID,
+		Metadata:   map[string]interface{}{"two_factor": true, "recovery_code": usedRecoveryCode},
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Login successful",
+		"user":    user.ToResponse(),
+		"token":   token,
+	})
+}
+
+// twoFactorError maps two-factor errors to responses
+func twoFactorError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		return c.Status(401).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrTwoFactorLocked):
+		return c.Status(429).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrTwoFactorRequired):
+		return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrTwoFactorNotEnrolled), errors.Is(err, services.ErrTwoFactorNotEnabled):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": fallback})
+}
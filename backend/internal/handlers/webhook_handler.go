@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"errors"
+	"taskman-backend/internal/middleware"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Pagination defaults for listing webhook deliveries
+const (
+	defaultDeliveriesPerPage = 50
+	maxDeliveriesPerPage     = 200
+)
+
+// WebhookHandler handles organization webhook requests
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+	orgService     *services.OrganizationService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookService *services.WebhookService, orgService *services.OrganizationService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		orgService:     orgService,
+	}
+}
+
+// GetWebhooks handles getting an organization's webhooks (admin only)
+func (h *WebhookHandler) GetWebhooks(c *fiber.Ctx) error {
+	orgID, role, err := authorizeOrg(c, h.orgService)
+	if err != nil {
+		return err
+	}
+
+	if role != models.RoleAdmin {
+		return c.Status(403).JSON(fiber.Map{"error": "Admin access required"})
+	}
+
+	webhooks, err := h.webhookService.GetWebhooks(orgID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get webhooks"})
+	}
+
+	return c.JSON(fiber.Map{
+		"webhooks": webhooks,
+	})
+}
+
+// CreateWebhook handles creating a webhook (admin only). The signing secret
+// is only ever returned here.
+func (h *WebhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	orgID, role, err := authorizeOrg(c, h.orgService)
+	if err != nil {
+		return err
+	}
+
+	if role != models.RoleAdmin {
+		return c.Status(403).JSON(fiber.Map{"error": "Admin access required"})
+	}
+
+	var req models.WebhookCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(orgID, userID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidWebhook) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create webhook"})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Webhook created successfully",
+		"webhook": webhook,
+		"secret":  webhook.Secret,
+	})
+}
+
+// UpdateWebhook handles updating a webhook (admin only)
+func (h *WebhookHandler) UpdateWebhook(c *fiber.Ctx) error {
+	webhook, err := h.getAdminWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.WebhookUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	updated, err := h.webhookService.UpdateWebhook(webhook.ID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidWebhook) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update webhook"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Webhook updated successfully",
+		"webhook": updated,
+	})
+}
+
+// DeleteWebhook handles deleting a webhook (admin only)
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	webhook, err := h.getAdminWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.webhookService.DeleteWebhook(webhook.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete webhook"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// GetDeliveries handles listing a webhook's delivery log (admin only)
+func (h *WebhookHandler) GetDeliveries(c *fiber.Ctx) error {
+	webhook, err := h.getAdminWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	filter := &models.WebhookDeliveryFilter{
+		Status:  c.Query("status"),
+		Page:    c.QueryInt("page", 1),
+		PerPage: c.QueryInt("per_page", defaultDeliveriesPerPage),
+	}
+	switch filter.Status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryFailed:
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid delivery status"})
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PerPage < 1 {
+		filter.PerPage = defaultDeliveriesPerPage
+	}
+	if filter.PerPage > maxDeliveriesPerPage {
+		filter.PerPage = maxDeliveriesPerPage
+	}
+
+	deliveries, total, err := h.webhookService.GetDeliveries(webhook.ID, filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get webhook deliveries"})
+	}
+
+	return c.JSON(fiber.Map{
+		"deliveries": deliveries,
+		"total":      total,
+		"page":       filter.Page,
+		"per_page":   filter.PerPage,
+	})
+}
+
+// SendTestEvent handles sending a test event to a webhook (admin only)
+func (h *WebhookHandler) SendTestEvent(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	webhook, err := h.getAdminWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	delivery, err := h.webhookService.SendTestEvent(webhook, userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to send test event"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "Test event sent",
+		"delivery": delivery,
+	})
+}
+
+// getAdminWebhook checks that the caller is an organization admin and
+// resolves the webhook from the route
+func (h *WebhookHandler) getAdminWebhook(c *fiber.Ctx) (*models.Webhook, error) {
+	orgID, role, err := authorizeOrg(c, h.orgService)
+	if err != nil {
+		return nil, err
+	}
+
+	if role != models.RoleAdmin {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Admin access required")
+	}
+
+	webhookID, err := uuid.Parse(c.Params("webhookId"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid webhook ID")
+	}
+
+	webhook, err := h.webhookService.GetWebhookByID(webhookID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Webhook not found")
+	}
+
+	if webhook.OrgID != orgID {
+		return nil, fiber.NewError(fiber.StatusForbidden, "Webhook does not belong to this organization")
+	}
+
+	return webhook, nil
+}
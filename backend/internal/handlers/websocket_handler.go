@@ -2,8 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
+	"sync"
+	"taskman-backend/internal/middleware"
 	"taskman-backend/internal/models"
+	"taskman-backend/internal/services"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -11,15 +15,97 @@ import (
 	"github.com/google/uuid"
 )
 
+// Outgoing messages wait in a per-connection queue of wsSendBuffer messages.
+// A client whose queue fills up, or that takes longer than wsWriteTimeout to
+// accept a message, is disconnected rather than holding up everyone else.
+const (
+	wsSendBuffer   = 64
+	wsWriteTimeout = 10 * time.Second
+)
+
+// wsClient is an open connection with its queue of outgoing messages
+type wsClient struct {
+	conn     *websocket.Conn
+	send     chan models.WebSocketMessage
+	done     chan struct{}
+	finished chan struct{}
+	stopOnce sync.Once
+}
+
+// newWSClient wraps a connection and starts its writer
+func newWSClient(conn *websocket.Conn) *wsClient {
+	client := &wsClient{
+		conn:     conn,
+		send:     make(chan models.WebSocketMessage, wsSendBuffer),
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+	go client.writeLoop()
+	return client
+}
+
+// writeLoop writes queued messages to the connection until the client is
+// stopped. A failed write closes the connection so its read loop ends.
+func (c *wsClient) writeLoop() {
+	defer close(c.finished)
+	for {
+		select {
+		case msg := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteJSON(msg); err != nil {
+				log.Printf("Failed to send message to client: %v", err)
+				c.conn.Close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// enqueue queues a message without blocking, disconnecting the client if its
+// queue is full
+func (c *wsClient) enqueue(msg models.WebSocketMessage) {
+	select {
+	case c.send <- msg:
+	case <-c.done:
+	default:
+		log.Printf("WebSocket client is not keeping up; disconnecting")
+		c.conn.Close()
+	}
+}
+
+// stop ends the writer and waits for it, so the connection is not written to
+// once its handler returns
+func (c *wsClient) stop() {
+	c.stopOnce.Do(func() { close(c.done) })
+	<-c.finished
+}
+
 // WebSocketHandler handles WebSocket connections
 type WebSocketHandler struct {
-	clients map[uuid.UUID]map[*websocket.Conn]bool // orgID -> connections
+	// mu guards the connection maps; messages are queued on each client and
+	// written by its own goroutine
+	mu                  sync.Mutex
+	clients             map[uuid.UUID]map[*wsClient]bool // orgID -> connections
+	users               map[uuid.UUID]map[*wsClient]bool // userID -> connections
+	taskService         *services.TaskService
+	projectService      *services.ProjectService
+	orgService          *services.OrganizationService
+	notificationService *services.NotificationService
+	webhookService      *services.WebhookService
 }
 
 // NewWebSocketHandler creates a new WebSocket handler
-func NewWebSocketHandler() *WebSocketHandler {
+func NewWebSocketHandler(taskService *services.TaskService, projectService *services.ProjectService, orgService *services.OrganizationService, notificationService *services.NotificationService, webhookService *services.WebhookService) *WebSocketHandler {
 	return &WebSocketHandler{
-		clients: make(map[uuid.UUID]map[*websocket.Conn]bool),
+		clients:             make(map[uuid.UUID]map[*wsClient]bool),
+		users:               make(map[uuid.UUID]map[*wsClient]bool),
+		taskService:         taskService,
+		projectService:      projectService,
+		orgService:          orgService,
+		notificationService: notificationService,
+		webhookService:      webhookService,
 	}
 }
 
@@ -43,12 +129,17 @@ func (h *WebSocketHandler) HandleWebSocket(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid organization ID"})
 	}
 
+	// Moves over the socket are changes, which read-only API tokens cannot make
+	canWrite := middleware.TokenAllowsWrite(c)
+
 	// Upgrade connection
 	return websocket.New(func(c *websocket.Conn) {
 		defer c.Close()
 
 		// Add client to organization
-		h.addClient(orgID, c)
+		client := newWSClient(c)
+		h.addClient(orgID, userIDUUID, client)
+		defer client.stop()
 
 		// Send welcome message
 		welcomeMsg := models.WebSocketMessage{
@@ -65,7 +156,7 @@ func (h *WebSocketHandler) HandleWebSocket(c *fiber.Ctx) error {
 			err := c.ReadJSON(&msg)
 			if err != nil {
 				log.Printf("WebSocket read error: %v", err)
-				h.removeClient(orgID, c)
+				h.removeClient(orgID, userIDUUID, client)
 				break
 			}
 
@@ -73,10 +164,15 @@ func (h *WebSocketHandler) HandleWebSocket(c *fiber.Ctx) error {
 			msg.UserID = userIDUUID
 			msg.Timestamp = time.Now()
 
+			if !canWrite && (msg.Type == models.MessageTypeTaskMoved || msg.Type == models.MessageTypeProjectMoved) {
+				h.sendError(client, "forbidden", "This token is read-only")
+				continue
+			}
+
 			// Handle different message types
 			switch msg.Type {
 			case models.MessageTypeTaskMoved:
-				h.handleTaskMoved(orgID, msg)
+				h.handleTaskMoved(client, orgID, msg)
 			case models.MessageTypeProjectMoved:
 				h.handleProjectMoved(orgID, msg)
 			default:
@@ -86,45 +182,91 @@ func (h *WebSocketHandler) HandleWebSocket(c *fiber.Ctx) error {
 	})(c)
 }
 
-// addClient adds a client to an organization
-func (h *WebSocketHandler) addClient(orgID uuid.UUID, conn *websocket.Conn) {
+// addClient adds a client to an organization and to its user
+func (h *WebSocketHandler) addClient(orgID, userID uuid.UUID, client *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	if h.clients[orgID] == nil {
-		h.clients[orgID] = make(map[*websocket.Conn]bool)
+		h.clients[orgID] = make(map[*wsClient]bool)
 	}
-	h.clients[orgID][conn] = true
+	h.clients[orgID][client] = true
+
+	if h.users[userID] == nil {
+		h.users[userID] = make(map[*wsClient]bool)
+	}
+	h.users[userID][client] = true
 }
 
-// removeClient removes a client from an organization
-func (h *WebSocketHandler) removeClient(orgID uuid.UUID, conn *websocket.Conn) {
+// removeClient removes a client from an organization and from its user
+func (h *WebSocketHandler) removeClient(orgID, userID uuid.UUID, client *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	if h.clients[orgID] != nil {
-		delete(h.clients[orgID], conn)
+		delete(h.clients[orgID], client)
 		if len(h.clients[orgID]) == 0 {
 			delete(h.clients, orgID)
 		}
 	}
+
+	if h.users[userID] != nil {
+		delete(h.users[userID], client)
+		if len(h.users[userID]) == 0 {
+			delete(h.users, userID)
+		}
+	}
 }
 
 // sendMessageToOrg sends a message to all clients in an organization
 func (h *WebSocketHandler) sendMessageToOrg(orgID uuid.UUID, msg models.WebSocketMessage) {
-	if h.clients[orgID] != nil {
-		for conn := range h.clients[orgID] {
-			err := conn.WriteJSON(msg)
-			if err != nil {
-				log.Printf("Failed to send message to client: %v", err)
-				conn.Close()
-				delete(h.clients[orgID], conn)
-			}
-		}
+	for _, client := range h.connections(h.clients, orgID) {
+		client.enqueue(msg)
+	}
+}
+
+// sendMessageToUser sends a message to all of a user's clients
+func (h *WebSocketHandler) sendMessageToUser(userID uuid.UUID, msg models.WebSocketMessage) {
+	for _, client := range h.connections(h.users, userID) {
+		client.enqueue(msg)
+	}
+}
+
+// connections lists the clients registered under id in one of the
+// connection maps
+func (h *WebSocketHandler) connections(byID map[uuid.UUID]map[*wsClient]bool, id uuid.UUID) []*wsClient {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	clients := make([]*wsClient, 0, len(byID[id]))
+	for client := range byID[id] {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// PushNotification sends a new notification to its user's open connections.
+// Users connected to another server instance see it on their next fetch.
+func (h *WebSocketHandler) PushNotification(notification *models.Notification) {
+	dataBytes, _ := json.Marshal(models.NotificationData{Notification: *notification})
+	msg := models.WebSocketMessage{
+		Type:      models.MessageTypeNotification,
+		Data:      dataBytes,
+		Timestamp: time.Now(),
+		UserID:    notification.UserID,
 	}
+
+	h.sendMessageToUser(notification.UserID, msg)
 }
 
 // BroadcastTaskMoved broadcasts a task moved event
-func (h *WebSocketHandler) BroadcastTaskMoved(orgID uuid.UUID, taskID uuid.UUID, projectID uuid.UUID, oldStatus, newStatus string, userID uuid.UUID) {
+func (h *WebSocketHandler) BroadcastTaskMoved(orgID uuid.UUID, taskID uuid.UUID, projectID uuid.UUID, oldStatus, newStatus, rank string, userID uuid.UUID) {
 	data := models.TaskMovedData{
 		TaskID:    taskID,
 		ProjectID: projectID,
 		OldStatus: oldStatus,
 		NewStatus: newStatus,
+		Rank:      rank,
 		UserID:    userID,
 	}
 
@@ -140,12 +282,13 @@ func (h *WebSocketHandler) BroadcastTaskMoved(orgID uuid.UUID, taskID uuid.UUID,
 }
 
 // BroadcastProjectMoved broadcasts a project moved event
-func (h *WebSocketHandler) BroadcastProjectMoved(orgID uuid.UUID, projectID uuid.UUID, oldStatus, newStatus string, userID uuid.UUID) {
+func (h *WebSocketHandler) BroadcastProjectMoved(orgID uuid.UUID, projectID uuid.UUID, oldStatus, newStatus, rank string, userID uuid.UUID) {
 	data := models.ProjectMovedData{
 		ProjectID: projectID,
 		OrgID:     orgID,
 		OldStatus: oldStatus,
 		NewStatus: newStatus,
+		Rank:      rank,
 		UserID:    userID,
 	}
 
@@ -160,10 +303,84 @@ func (h *WebSocketHandler) BroadcastProjectMoved(orgID uuid.UUID, projectID uuid
 	h.sendMessageToOrg(orgID, msg)
 }
 
-// handleTaskMoved handles task moved messages
-func (h *WebSocketHandler) handleTaskMoved(orgID uuid.UUID, msg models.WebSocketMessage) {
-	// Broadcast to all clients in the organization
-	h.sendMessageToOrg(orgID, msg)
+// handleTaskMoved handles task moved messages. The move goes through the same
+// checks as the HTTP move endpoint; failures are reported to the sender only.
+func (h *WebSocketHandler) handleTaskMoved(client *wsClient, orgID uuid.UUID, msg models.WebSocketMessage) {
+	var data models.TaskMovedData
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		h.sendError(client, "invalid_message", "Invalid task moved data")
+		return
+	}
+
+	isMember, role, err := h.orgService.IsMember(orgID, msg.UserID)
+	if err != nil || !isMember {
+		h.sendError(client, "forbidden", "Not a member of this organization")
+		return
+	}
+
+	task, err := h.taskService.GetTaskByID(data.TaskID)
+	if err != nil || task.ProjectID != data.ProjectID {
+		h.sendError(client, "not_found", "Task not found")
+		return
+	}
+
+	project, err := h.projectService.GetProjectByID(task.ProjectID)
+	if err != nil || project.OrgID != orgID {
+		h.sendError(client, "not_found", "Task not found")
+		return
+	}
+
+	isAssignee, err := h.taskService.IsTaskAssignee(task.ID, msg.UserID)
+	if err != nil || !isAssignee {
+		h.sendError(client, "forbidden", "Not assigned to this task")
+		return
+	}
+
+	opts := services.MoveOptions{IsAdmin: role == models.RoleAdmin, OverrideWIPLimit: data.OverrideWIPLimit, Position: data.CardPosition}
+	moved, err := h.taskService.MoveTask(task.ID, models.TaskStatus(data.NewStatus), opts)
+	if err != nil {
+		var transitionErr *services.TransitionNotAllowedError
+		var blockedErr *services.BlockedTaskError
+		var wipErr *services.WIPLimitError
+		switch {
+		case errors.As(err, &wipErr):
+			h.sendError(client, "wip_limit_exceeded", wipErr.Error())
+		case errors.As(err, &transitionErr):
+			h.sendError(client, "transition_not_allowed", transitionErr.Error())
+		case errors.As(err, &blockedErr):
+			h.sendError(client, "task_blocked", blockedErr.Error())
+		case errors.Is(err, services.ErrInvalidStatus):
+			h.sendError(client, "invalid_status", err.Error())
+		case errors.Is(err, services.ErrInvalidPosition):
+			h.sendError(client, "invalid_position", err.Error())
+		default:
+			h.sendError(client, "internal_error", "Failed to move task")
+		}
+		return
+	}
+
+	h.BroadcastTaskMoved(orgID, task.ID, task.ProjectID, string(task.Status), data.NewStatus, moved.Rank, msg.UserID)
+	h.notificationService.TaskStatusChanged(task, task.Status, models.TaskStatus(data.NewStatus), msg.UserID)
+	h.webhookService.Publish(orgID, models.WebhookEventTaskMoved, models.TaskMovedData{
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		OldStatus: string(task.Status),
+		NewStatus: data.NewStatus,
+		Rank:      moved.Rank,
+		UserID:    msg.UserID,
+	})
+}
+
+// sendError sends an error event to a single client
+func (h *WebSocketHandler) sendError(client *wsClient, code, message string) {
+	dataBytes, _ := json.Marshal(models.ErrorData{Message: message, Code: code})
+	msg := models.WebSocketMessage{
+		Type:      models.MessageTypeError,
+		Data:      dataBytes,
+		Timestamp: time.Now(),
+	}
+
+	client.enqueue(msg)
 }
 
 // handleProjectMoved handles project moved messages
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"errors"
+	"taskman-backend/internal/middleware"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// WorkflowHandler handles per-project workflow requests
+type WorkflowHandler struct {
+	workflowService *services.WorkflowService
+	projectService  *services.ProjectService
+	orgService      *services.OrganizationService
+}
+
+// NewWorkflowHandler creates a new workflow handler
+func NewWorkflowHandler(workflowService *services.WorkflowService, projectService *services.ProjectService, orgService *services.OrganizationService) *WorkflowHandler {
+	return &WorkflowHandler{
+		workflowService: workflowService,
+		projectService:  projectService,
+		orgService:      orgService,
+	}
+}
+
+// GetWorkflow handles getting a project's workflow columns and transitions
+func (h *WorkflowHandler) GetWorkflow(c *fiber.Ctx) error {
+	project, _, err := authorizeProject(c, h.orgService, h.projectService)
+	if err != nil {
+		return err
+	}
+
+	workflow, err := h.workflowService.GetWorkflow(project.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to get workflow"})
+	}
+
+	return c.JSON(fiber.Map{
+		"workflow": workflow,
+	})
+}
+
+// UpdateWorkflow handles replacing a project's workflow (admin or project creator)
+func (h *WorkflowHandler) UpdateWorkflow(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserIDFromContext(c)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid user context"})
+	}
+
+	project, role, err := authorizeProject(c, h.orgService, h.projectService)
+	if err != nil {
+		return err
+	}
+
+	if role != models.RoleAdmin && project.CreatedBy != userID {
+		return c.Status(403).JSON(fiber.Map{"error": "Not authorized to change this project's workflow"})
+	}
+
+	var req models.WorkflowUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	workflow, err := h.workflowService.UpdateWorkflow(project.ID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidWorkflow) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update workflow"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "Workflow updated successfully",
+		"workflow": workflow,
+	})
+}
@@ -3,13 +3,21 @@ package middleware
 import (
 	"strings"
 	"taskman-backend/internal/auth"
+	"taskman-backend/internal/models"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
-// AuthMiddleware validates JWT tokens
-func AuthMiddleware(jwtManager *auth.JWTManager) fiber.Handler {
+// TokenAuthenticator resolves API tokens (personal access tokens and service
+// account tokens) to the identity they act as
+type TokenAuthenticator interface {
+	AuthenticateToken(token, ipAddress string) (*models.TokenIdentity, error)
+}
+
+// AuthMiddleware validates JWT tokens and API tokens. API tokens are limited
+// to their organizations and, when read-only, to safe methods.
+func AuthMiddleware(jwtManager *auth.JWTManager, tokens TokenAuthenticator) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
@@ -23,6 +31,28 @@ func AuthMiddleware(jwtManager *auth.JWTManager) fiber.Handler {
 		}
 
 		token := tokenParts[1]
+		if strings.HasPrefix(token, models.APITokenPrefix) {
+			identity, err := tokens.AuthenticateToken(token, c.IP())
+			if err != nil {
+				return c.Status(401).JSON(fiber.Map{"error": "Invalid token"})
+			}
+
+			if identity.Permission != models.APITokenWrite && !isSafeMethod(c.Method()) {
+				return c.Status(403).JSON(fiber.Map{"error": "This token is read-only"})
+			}
+
+			c.Locals("user_id", identity.UserID)
+			c.Locals("user_email", identity.Email)
+			c.Locals("auth_method", identity.AuthMethod)
+			c.Locals("two_factor", identity.TwoFactor)
+			c.Locals("api_token", identity)
+
+			if orgID, ok := requestOrgID(c); ok && !TokenAllowsOrg(c, orgID) {
+				return c.Status(403).JSON(fiber.Map{"error": "This token cannot access this organization"})
+			}
+			return c.Next()
+		}
+
 		claims, err := jwtManager.ValidateToken(token)
 		if err != nil {
 			return c.Status(401).JSON(fiber.Map{"error": "Invalid token"})
@@ -31,10 +61,66 @@ func AuthMiddleware(jwtManager *auth.JWTManager) fiber.Handler {
 		// Set user information in context
 		c.Locals("user_id", claims.UserID)
 		c.Locals("user_email", claims.Email)
+		c.Locals("auth_method", claims.AuthMethod)
+		c.Locals("two_factor", claims.TwoFactor)
+		return c.Next()
+	}
+}
+
+// OrgPolicy tells what an organization requires of a member's session
+type OrgPolicy interface {
+	AccessPolicy(orgID, userID uuid.UUID) (*models.OrgAccessPolicy, error)
+}
+
+// OrgPolicyMiddleware refuses requests to organizations whose policy the
+// session does not meet: SSO-only organizations need a single sign-on
+// session, and organizations requiring two-factor authentication for admins
+// need their admins to have passed it. Single sign-on satisfies the
+// two-factor requirement, as the identity provider enforces its own. Service
+// account tokens are always allowed. It must run after AuthMiddleware.
+func OrgPolicyMiddleware(policy OrgPolicy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		orgID, ok := requestOrgID(c)
+		if !ok || orgID == uuid.Nil {
+			return c.Next()
+		}
+
+		if err := CheckOrgPolicy(c, policy, orgID); err != nil {
+			return err
+		}
+
 		return c.Next()
 	}
 }
 
+// CheckOrgPolicy checks a session against an organization's policy the way
+// OrgPolicyMiddleware does, for organizations found from a resource rather
+// than the route. It returns a *fiber.Error when the session falls short.
+func CheckOrgPolicy(c *fiber.Ctx, policy OrgPolicy, orgID uuid.UUID) error {
+	method := GetAuthMethodFromContext(c)
+	if method == models.AuthMethodServiceAccount {
+		return nil
+	}
+
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid user context")
+	}
+
+	required, err := policy.AccessPolicy(orgID, userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to check organization policy")
+	}
+	if required.SSOOnly && method != models.AuthMethodSSO {
+		return fiber.NewError(fiber.StatusForbidden, "This organization requires single sign-on")
+	}
+	if required.TwoFactor && method != models.AuthMethodSSO && !GetTwoFactorFromContext(c) {
+		return fiber.NewError(fiber.StatusForbidden, "This organization requires admins to sign in with two-factor authentication")
+	}
+
+	return nil
+}
+
 // OptionalAuthMiddleware validates JWT tokens but doesn't require them
 func OptionalAuthMiddleware(jwtManager *auth.JWTManager) fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -58,6 +144,8 @@ func OptionalAuthMiddleware(jwtManager *auth.JWTManager) fiber.Handler {
 		// Set user information in context
 		c.Locals("user_id", claims.UserID)
 		c.Locals("user_email", claims.Email)
+		c.Locals("auth_method", claims.AuthMethod)
+		c.Locals("two_factor", claims.TwoFactor)
 		return c.Next()
 	}
 }
@@ -91,3 +179,103 @@ func GetUserEmailFromContext(c *fiber.Ctx) (string, error) {
 
 	return userEmailStr, nil
 }
+
+// GetAuthMethodFromContext returns how the request's user signed in
+func GetAuthMethodFromContext(c *fiber.Ctx) string {
+	method, _ := c.Locals("auth_method").(string)
+	if method == "" {
+		return models.AuthMethodPassword
+	}
+	return method
+}
+
+// GetTwoFactorFromContext reports whether the request's user passed
+// two-factor authentication when signing in
+func GetTwoFactorFromContext(c *fiber.Ctx) bool {
+	twoFactor, _ := c.Locals("two_factor").(bool)
+	return twoFactor
+}
+
+// GetOrgSessionFromContext describes the request's session for checking
+// organization policies in queries across organizations
+func GetOrgSessionFromContext(c *fiber.Ctx) models.OrgSession {
+	method := GetAuthMethodFromContext(c)
+	return models.OrgSession{
+		Exempt:    method == models.AuthMethodServiceAccount,
+		SSO:       method == models.AuthMethodSSO,
+		TwoFactor: GetTwoFactorFromContext(c),
+	}
+}
+
+// GetAPITokenFromContext returns the API token the request authenticated
+// with, or nil when it used a JWT
+func GetAPITokenFromContext(c *fiber.Ctx) *models.TokenIdentity {
+	identity, _ := c.Locals("api_token").(*models.TokenIdentity)
+	return identity
+}
+
+// TokenAllowsOrg reports whether the request may act on an organization.
+// Requests authenticated with a JWT or an unscoped API token always may.
+func TokenAllowsOrg(c *fiber.Ctx, orgID uuid.UUID) bool {
+	identity := GetAPITokenFromContext(c)
+	if identity == nil || len(identity.OrgIDs) == 0 {
+		return true
+	}
+
+	for _, id := range identity.OrgIDs {
+		if id == orgID {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenAllowsWrite reports whether the request may make changes
+func TokenAllowsWrite(c *fiber.Ctx) bool {
+	identity := GetAPITokenFromContext(c)
+	return identity == nil || identity.Permission == models.APITokenWrite
+}
+
+// requestOrgID finds the organization a request targets, from the
+// /organizations/:orgId path segment or the org_id query parameter. Routes
+// that name no organization, such as joining or creating one, resolve to
+// uuid.Nil so that scoped tokens cannot use them.
+func requestOrgID(c *fiber.Ctx) (uuid.UUID, bool) {
+	segments := strings.Split(strings.Trim(c.Path(), "/"), "/")
+	for i, segment := range segments {
+		if segment != "organizations" {
+			continue
+		}
+		if i+1 == len(segments) {
+			// Listing organizations is filtered by the handler
+			if c.Method() == fiber.MethodGet {
+				break
+			}
+			return uuid.Nil, true
+		}
+		orgID, err := uuid.Parse(segments[i+1])
+		if err != nil {
+			return uuid.Nil, true
+		}
+		return orgID, true
+	}
+
+	if orgIDStr := c.Query("org_id"); orgIDStr != "" {
+		orgID, err := uuid.Parse(orgIDStr)
+		if err != nil {
+			return uuid.Nil, true
+		}
+		return orgID, true
+	}
+
+	return uuid.Nil, false
+}
+
+// isSafeMethod reports whether an HTTP method only reads
+func isSafeMethod(method string) bool {
+	switch method {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return true
+	}
+	return false
+}
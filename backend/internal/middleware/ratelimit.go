@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"taskman-backend/internal/ratelimit"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RateLimit is one limit that requests count against. Requests for which
+// Key reports false, or for which Limit is unlimited, skip it.
+type RateLimit struct {
+	// Name separates the buckets of route groups that share a key
+	Name  string
+	Limit ratelimit.Limit
+	Key   func(c *fiber.Ctx) (string, bool)
+}
+
+// RateLimitMiddleware refuses requests over any of the limits with 429.
+// Refused requests are not charged against the other limits. It sets the
+// RateLimit-* headers for the limit closest to running out, and Retry-After
+// on refusals. Limits keyed by user or organization must run after
+// AuthMiddleware.
+func RateLimitMiddleware(limiter *ratelimit.Limiter, limits ...RateLimit) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var keys []ratelimit.BucketKey
+		for _, limit := range limits {
+			if limit.Limit.Unlimited() {
+				continue
+			}
+			key, ok := limit.Key(c)
+			if !ok {
+				continue
+			}
+			keys = append(keys, ratelimit.BucketKey{Key: "rate:" + limit.Name + ":" + key, Limit: limit.Limit})
+		}
+
+		var tightest *ratelimit.Decision
+		var refused *ratelimit.Decision
+		decisions := limiter.Take(keys)
+		for i := range decisions {
+			decision := &decisions[i]
+			if !decision.Allowed {
+				if refused == nil || decision.RetryAfter > refused.RetryAfter {
+					refused = decision
+				}
+			}
+			if tightest == nil || decision.Remaining < tightest.Remaining {
+				tightest = decision
+			}
+		}
+
+		if refused != nil {
+			setRateLimitHeaders(c, refused)
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(ceilSeconds(refused.RetryAfter)))
+			return c.Status(429).JSON(fiber.Map{"error": "Rate limit exceeded, slow down"})
+		}
+		if tightest != nil {
+			setRateLimitHeaders(c, tightest)
+		}
+
+		return c.Next()
+	}
+}
+
+// RateLimitByClient keys limits by the API token a request used, or else by
+// its user, so that each token gets its own budget
+func RateLimitByClient(c *fiber.Ctx) (string, bool) {
+	if identity := GetAPITokenFromContext(c); identity != nil {
+		return "token:" + identity.TokenID.String(), true
+	}
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return "", false
+	}
+	return "user:" + userID.String(), true
+}
+
+// OrgMembership tells whether a user belongs to an organization
+type OrgMembership interface {
+	IsMember(orgID, userID uuid.UUID) (bool, string, error)
+}
+
+// orgMembershipTTL is how long RateLimitByOrg relies on a membership lookup
+const orgMembershipTTL = time.Minute
+
+// RateLimitByOrg keys limits by the organization a request targets, shared
+// by all of its members. Requests that name no organization, or that come
+// from outside it, skip it, so that outsiders cannot spend an organization's
+// budget. Memberships are cached for orgMembershipTTL, so that counting a
+// request does not cost a lookup.
+func RateLimitByOrg(members OrgMembership) func(c *fiber.Ctx) (string, bool) {
+	cache := &membershipCache{members: members, entries: make(map[membershipKey]membershipEntry)}
+	return func(c *fiber.Ctx) (string, bool) {
+		orgID, ok := requestOrgID(c)
+		if !ok || orgID == uuid.Nil {
+			return "", false
+		}
+		userID, err := GetUserIDFromContext(c)
+		if err != nil {
+			return "", false
+		}
+		if !cache.isMember(orgID, userID, time.Now()) {
+			return "", false
+		}
+		return "org:" + orgID.String(), true
+	}
+}
+
+// membershipCache remembers membership lookups for orgMembershipTTL
+type membershipCache struct {
+	members   OrgMembership
+	mu        sync.Mutex
+	entries   map[membershipKey]membershipEntry
+	lastSweep time.Time
+}
+
+type membershipKey struct {
+	orgID  uuid.UUID
+	userID uuid.UUID
+}
+
+type membershipEntry struct {
+	isMember  bool
+	expiresAt time.Time
+}
+
+// isMember reports whether a user belongs to an organization, looking it up
+// when the cached answer is missing or expired. Failed lookups count as not
+// a member and are not cached.
+func (m *membershipCache) isMember(orgID, userID uuid.UUID, now time.Time) bool {
+	key := membershipKey{orgID: orgID, userID: userID}
+
+	m.mu.Lock()
+	entry, ok := m.entries[key]
+	m.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.isMember
+	}
+
+	isMember, _, err := m.members.IsMember(orgID, userID)
+	if err != nil {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if now.Sub(m.lastSweep) >= orgMembershipTTL {
+		m.lastSweep = now
+		for k, e := range m.entries {
+			if !now.Before(e.expiresAt) {
+				delete(m.entries, k)
+			}
+		}
+	}
+	m.entries[key] = membershipEntry{isMember: isMember, expiresAt: now.Add(orgMembershipTTL)}
+
+	return isMember
+}
+
+// RateLimitByIP keys limits by client address, for unauthenticated routes
+func RateLimitByIP(c *fiber.Ctx) (string, bool) {
+	return "ip:" + c.IP(), true
+}
+
+// setRateLimitHeaders sets the IETF draft RateLimit headers
+func setRateLimitHeaders(c *fiber.Ctx, decision *ratelimit.Decision) {
+	c.Set("RateLimit-Limit", strconv.Itoa(decision.Limit.Requests))
+	c.Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+	c.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", decision.Limit.Requests, ceilSeconds(decision.Limit.Per)))
+}
+
+// ceilSeconds rounds a duration up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// API token permissions
+const (
+	APITokenRead  = "read"
+	APITokenWrite = "write"
+)
+
+// API token prefixes, which tell them apart from JWTs and from each other
+const (
+	APITokenPrefix            = "tm_"
+	PersonalTokenPrefix       = "tm_pat_"
+	ServiceAccountTokenPrefix = "tm_sat_"
+)
+
+// APIToken is a long-lived credential for scripts and integrations. Personal
+// access tokens act as the user who created them; service account tokens act
+// as the service account's user. Only a hash of the token is stored.
+type APIToken struct {
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID           uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	ServiceAccountID *uuid.UUID `json:"service_account_id,omitempty" gorm:"type:uuid;index"`
+	Name             string     `json:"name" gorm:"not null"`
+	Prefix           string     `json:"prefix" gorm:"not null"`
+	TokenHash        string     `json:"-" gorm:"uniqueIndex;not null"`
+
+	// OrgIDs limits the token to these organizations; empty allows all of
+	// the user's organizations
+	OrgIDs     UUIDList `json:"org_ids" gorm:"type:jsonb;not null;default:'[]'"`
+	Permission string   `json:"permission" gorm:"not null;default:'read'"`
+
+	// AuthMethod is how the token's creator signed in, which decides
+	// whether it may be used in organizations that require single sign-on
+	AuthMethod string `json:"auth_method" gorm:"not null;default:'password'"`
+	TwoFactor  bool   `json:"two_factor" gorm:"not null;default:false"`
+
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Expired reports whether the token can no longer be used at t
+func (t *APIToken) Expired(at time.Time) bool {
+	return t.ExpiresAt != nil && !at.Before(*t.ExpiresAt)
+}
+
+// APITokenCreateRequest represents the request to create an API token.
+// Tokens expire after ExpiresInDays, or after 90 days when it is omitted or 0.
+type APITokenCreateRequest struct {
+	Name          string      `json:"name" validate:"required,min=1,max=100"`
+	Permission    string      `json:"permission" validate:"required,oneof=read write"`
+	OrgIDs        []uuid.UUID `json:"org_ids,omitempty"`
+	ExpiresInDays *int        `json:"expires_in_days,omitempty" validate:"omitempty,min=0,max=365"`
+}
+
+// ServiceAccount is a non-human member of an organization that automation
+// authenticates as with its API tokens. It is backed by a user that cannot
+// log in with a password.
+type ServiceAccount struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrgID       uuid.UUID `json:"org_id" gorm:"type:uuid;not null;index"`
+	UserID      uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex"`
+	Name        string    `json:"name" gorm:"not null"`
+	Description string    `json:"description"`
+	Role        string    `json:"role" gorm:"-"`
+	CreatedBy   uuid.UUID `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ServiceAccountCreateRequest represents the request to create a service account
+type ServiceAccountCreateRequest struct {
+	Name        string `json:"name" validate:"required,min=1,max=100"`
+	Description string `json:"description" validate:"max=500"`
+	Role        string `json:"role,omitempty" validate:"omitempty,oneof=member admin"`
+}
+
+// TokenIdentity is who an API token acts as and what it may do
+type TokenIdentity struct {
+	TokenID    uuid.UUID
+	UserID     uuid.UUID
+	Email      string
+	OrgIDs     []uuid.UUID
+	Permission string
+	AuthMethod string
+	TwoFactor  bool
+}
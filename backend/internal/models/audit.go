@@ -0,0 +1,35 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditLog represents an immutable audit trail entry
+type AuditLog struct {
+	ID         uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrgID      *uuid.UUID      `json:"org_id,omitempty" gorm:"type:uuid;index"`
+	ActorID    *uuid.UUID      `json:"actor_id,omitempty" gorm:"type:uuid;index"`
+	ActorEmail string          `json:"actor_email,omitempty"`
+	Action     string          `json:"action" gorm:"not null;index"`
+	TargetType string          `json:"target_type,omitempty"`
+	TargetID   *uuid.UUID      `json:"target_id,omitempty" gorm:"type:uuid"`
+	Metadata   json.RawMessage `json:"metadata,omitempty" gorm:"type:jsonb"`
+	IPAddress  string          `json:"ip_address,omitempty"`
+	UserAgent  string          `json:"user_agent,omitempty"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"index"`
+}
+
+// AuditLogFilter represents the filters accepted when listing audit entries
+type AuditLogFilter struct {
+	Action     string
+	ActorID    *uuid.UUID
+	TargetType string
+	TargetID   *uuid.UUID
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	PerPage    int
+}
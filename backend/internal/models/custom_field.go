@@ -0,0 +1,138 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CustomFieldType represents the kind of value a custom field holds
+type CustomFieldType string
+
+const (
+	CustomFieldTypeText   CustomFieldType = "text"
+	CustomFieldTypeNumber CustomFieldType = "number"
+	CustomFieldTypeDate   CustomFieldType = "date"
+	CustomFieldTypeSelect CustomFieldType = "select"
+	CustomFieldTypeUser   CustomFieldType = "user"
+)
+
+// IsValid reports whether the type is one of the known field types
+func (t CustomFieldType) IsValid() bool {
+	switch t {
+	case CustomFieldTypeText, CustomFieldTypeNumber, CustomFieldTypeDate, CustomFieldTypeSelect, CustomFieldTypeUser:
+		return true
+	}
+	return false
+}
+
+// CustomFieldEntity represents what a custom field is attached to
+type CustomFieldEntity string
+
+const (
+	CustomFieldEntityTask    CustomFieldEntity = "task"
+	CustomFieldEntityProject CustomFieldEntity = "project"
+)
+
+// IsValid reports whether the entity is one of the known entities
+func (e CustomFieldEntity) IsValid() bool {
+	return e == CustomFieldEntityTask || e == CustomFieldEntityProject
+}
+
+// CustomField represents an organization-defined field on tasks or projects.
+// Values are stored on the task or project under the field's key.
+type CustomField struct {
+	ID        uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrgID     uuid.UUID         `json:"org_id" gorm:"type:uuid;not null;uniqueIndex:idx_custom_fields_org_entity_key"`
+	Entity    CustomFieldEntity `json:"entity" gorm:"not null;uniqueIndex:idx_custom_fields_org_entity_key"`
+	Key       string            `json:"key" gorm:"not null;uniqueIndex:idx_custom_fields_org_entity_key"`
+	Name      string            `json:"name" gorm:"not null"`
+	Type      CustomFieldType   `json:"type" gorm:"not null"`
+	Options   StringList        `json:"options" gorm:"type:jsonb"`
+	Required  bool              `json:"required" gorm:"not null;default:false"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// CustomFieldCreateRequest represents the request to define a custom field.
+// Options lists the allowed values of select fields.
+type CustomFieldCreateRequest struct {
+	Entity   CustomFieldEntity `json:"entity" validate:"required,oneof=task project"`
+	Key      string            `json:"key" validate:"required,max=50"`
+	Name     string            `json:"name" validate:"required,max=100"`
+	Type     CustomFieldType   `json:"type" validate:"required,oneof=text number date select user"`
+	Options  []string          `json:"options,omitempty"`
+	Required bool              `json:"required"`
+}
+
+// CustomFieldUpdateRequest represents the request to update a custom field.
+// The key, entity and type of a field cannot change.
+type CustomFieldUpdateRequest struct {
+	Name     *string  `json:"name,omitempty" validate:"omitempty,max=100"`
+	Options  []string `json:"options,omitempty"`
+	Required *bool    `json:"required,omitempty"`
+}
+
+// CustomFieldValues holds the custom field values of a task or project by
+// field key, stored as a JSONB object
+type CustomFieldValues map[string]interface{}
+
+// Value implements driver.Valuer
+func (v CustomFieldValues) Value() (driver.Value, error) {
+	if v == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (v *CustomFieldValues) Scan(value interface{}) error {
+	*v = CustomFieldValues{}
+	switch data := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(data, v)
+	case string:
+		return json.Unmarshal([]byte(data), v)
+	default:
+		return fmt.Errorf("cannot scan %T into CustomFieldValues", value)
+	}
+}
+
+// StringList is a list of strings stored as a JSONB array
+type StringList []string
+
+// Value implements driver.Valuer
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (l *StringList) Scan(value interface{}) error {
+	*l = StringList{}
+	switch data := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(data, l)
+	case string:
+		return json.Unmarshal([]byte(data), l)
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", value)
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Label represents an organization-scoped tag for tasks and projects
+type Label struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrgID     uuid.UUID `json:"org_id" gorm:"type:uuid;not null;uniqueIndex:idx_labels_org_name"`
+	Name      string    `json:"name" gorm:"not null;uniqueIndex:idx_labels_org_name"`
+	Color     string    `json:"color" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TaskLabel represents a label attached to a task
+type TaskLabel struct {
+	TaskID  uuid.UUID `json:"task_id" gorm:"type:uuid;primaryKey"`
+	LabelID uuid.UUID `json:"label_id" gorm:"type:uuid;primaryKey"`
+}
+
+// ProjectLabel represents a label attached to a project
+type ProjectLabel struct {
+	ProjectID uuid.UUID `json:"project_id" gorm:"type:uuid;primaryKey"`
+	LabelID   uuid.UUID `json:"label_id" gorm:"type:uuid;primaryKey"`
+}
+
+// LabelCreateRequest represents the request to create a label
+type LabelCreateRequest struct {
+	Name  string `json:"name" validate:"required,min=1,max=50"`
+	Color string `json:"color" validate:"required,hexcolor"`
+}
+
+// LabelUpdateRequest represents the request to update a label
+type LabelUpdateRequest struct {
+	Name  *string `json:"name,omitempty" validate:"omitempty,min=1,max=50"`
+	Color *string `json:"color,omitempty" validate:"omitempty,hexcolor"`
+}
@@ -0,0 +1,166 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationType identifies the event a notification is about
+type NotificationType string
+
+const (
+	NotificationTaskAssigned        NotificationType = "task_assigned"
+	NotificationTaskUnassigned      NotificationType = "task_unassigned"
+	NotificationTaskStatusChanged   NotificationType = "task_status_changed"
+	NotificationProjectAssigned     NotificationType = "project_assigned"
+	NotificationDeadlineApproaching NotificationType = "deadline_approaching"
+	NotificationDeadlineOverdue     NotificationType = "deadline_overdue"
+)
+
+// Notification represents an in-app notification for a user
+type Notification struct {
+	ID        uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID        `json:"user_id" gorm:"type:uuid;not null;index"`
+	OrgID     *uuid.UUID       `json:"org_id,omitempty" gorm:"type:uuid"`
+	Type      NotificationType `json:"type" gorm:"not null"`
+	Title     string           `json:"title" gorm:"not null"`
+	Body      string           `json:"body"`
+	ProjectID *uuid.UUID       `json:"project_id,omitempty" gorm:"type:uuid"`
+	TaskID    *uuid.UUID       `json:"task_id,omitempty" gorm:"type:uuid"`
+	ActorID   *uuid.UUID       `json:"actor_id,omitempty" gorm:"type:uuid"`
+	ReadAt    *time.Time       `json:"read_at"`
+	CreatedAt time.Time        `json:"created_at" gorm:"index"`
+
+	// EmailPending marks a notification to be emailed once the user is
+	// outside their quiet hours; EmailedAt is set once it went out, on its
+	// own or in a digest
+	EmailPending bool       `json:"-" gorm:"not null;default:false"`
+	EmailedAt    *time.Time `json:"-"`
+}
+
+// NotificationTypes lists every notification type, in the order preferences
+// are shown
+var NotificationTypes = []NotificationType{
+	NotificationTaskAssigned,
+	NotificationTaskUnassigned,
+	NotificationTaskStatusChanged,
+	NotificationProjectAssigned,
+	NotificationDeadlineApproaching,
+	NotificationDeadlineOverdue,
+}
+
+// IsValid reports whether t is a known notification type
+func (t NotificationType) IsValid() bool {
+	for _, known := range NotificationTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationChannel is how a user wants to receive a type of notification
+type NotificationChannel string
+
+const (
+	// NotificationChannelInApp keeps the notification in the inbox only
+	NotificationChannelInApp NotificationChannel = "in_app"
+	// NotificationChannelEmail keeps it in the inbox and emails it
+	NotificationChannelEmail NotificationChannel = "email"
+	// NotificationChannelNone drops the notification
+	NotificationChannelNone NotificationChannel = "none"
+)
+
+// IsValid reports whether c is a known channel
+func (c NotificationChannel) IsValid() bool {
+	switch c {
+	case NotificationChannelInApp, NotificationChannelEmail, NotificationChannelNone:
+		return true
+	}
+	return false
+}
+
+// DefaultNotificationChannel is the channel used for a type the user has no
+// preference for. Deadline reminders are emailed; everything else stays in app.
+func DefaultNotificationChannel(t NotificationType) NotificationChannel {
+	switch t {
+	case NotificationDeadlineApproaching, NotificationDeadlineOverdue:
+		return NotificationChannelEmail
+	}
+	return NotificationChannelInApp
+}
+
+// NotificationPreference is a user's channel for one notification type
+type NotificationPreference struct {
+	UserID    uuid.UUID           `json:"-" gorm:"type:uuid;primary_key"`
+	Type      NotificationType    `json:"type" gorm:"primary_key"`
+	Channel   NotificationChannel `json:"channel" gorm:"not null"`
+	UpdatedAt time.Time           `json:"-"`
+}
+
+// NotificationSettings holds a user's quiet hours and digest settings.
+// Quiet hours are local "HH:MM" times in Timezone and may wrap past
+// midnight; emails are held back until they end.
+type NotificationSettings struct {
+	UserID          uuid.UUID  `json:"-" gorm:"type:uuid;primary_key"`
+	Timezone        string     `json:"timezone" gorm:"not null;default:'UTC'"`
+	QuietHoursStart string     `json:"quiet_hours_start"`
+	QuietHoursEnd   string     `json:"quiet_hours_end"`
+	DigestEnabled   bool       `json:"digest_enabled" gorm:"not null;default:false"`
+	DigestHour      int        `json:"digest_hour" gorm:"not null;default:8"`
+	LastDigestAt    *time.Time `json:"last_digest_at"`
+	UpdatedAt       time.Time  `json:"-"`
+}
+
+// NotificationPreferencesResponse represents a user's full notification
+// preferences, with defaults filled in for types they have not set
+type NotificationPreferencesResponse struct {
+	Channels map[NotificationType]NotificationChannel `json:"channels"`
+	NotificationSettings
+}
+
+// NotificationPreferencesUpdateRequest represents the request to update
+// notification preferences. Omitted fields are left unchanged; empty quiet
+// hours turn them off.
+type NotificationPreferencesUpdateRequest struct {
+	Channels        map[NotificationType]NotificationChannel `json:"channels,omitempty"`
+	Timezone        *string                                  `json:"timezone,omitempty"`
+	QuietHoursStart *string                                  `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   *string                                  `json:"quiet_hours_end,omitempty"`
+	DigestEnabled   *bool                                    `json:"digest_enabled,omitempty"`
+	DigestHour      *int                                     `json:"digest_hour,omitempty" validate:"omitempty,min=0,max=23"`
+}
+
+// Pagination defaults for listing notifications
+const (
+	DefaultNotificationsPerPage = 20
+	MaxNotificationsPerPage     = 100
+)
+
+// NotificationFilter represents the filters accepted when listing notifications
+type NotificationFilter struct {
+	Session    OrgSession
+	UnreadOnly bool
+	Page       int
+	PerPage    int
+}
+
+// Deadline reminder kinds
+const (
+	DeadlineReminderApproaching = "approaching"
+	DeadlineReminderOverdue     = "overdue"
+)
+
+// DeadlineReminder records that a user was reminded of a task or project
+// deadline, so each kind of reminder goes out once per deadline. Moving the
+// deadline allows new reminders.
+type DeadlineReminder struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_deadline_reminders_unique"`
+	TargetType string    `json:"target_type" gorm:"not null;uniqueIndex:idx_deadline_reminders_unique"`
+	TargetID   uuid.UUID `json:"target_id" gorm:"type:uuid;not null;uniqueIndex:idx_deadline_reminders_unique"`
+	Kind       string    `json:"kind" gorm:"not null;uniqueIndex:idx_deadline_reminders_unique"`
+	Deadline   time.Time `json:"deadline" gorm:"not null;uniqueIndex:idx_deadline_reminders_unique"`
+	SentAt     time.Time `json:"sent_at" gorm:"not null"`
+}
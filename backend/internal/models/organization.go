@@ -9,14 +9,16 @@ import (
 
 // Organization represents an organization in the system
 type Organization struct {
-	ID            uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	Name          string         `json:"name" gorm:"not null"`
-	CreatedBy     uuid.UUID      `json:"created_by" gorm:"not null"`
-	InviteCode    string         `json:"invite_code" gorm:"uniqueIndex;not null"`
-	CodeExpiresAt time.Time      `json:"code_expires_at" gorm:"not null"`
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+	ID              uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name            string         `json:"name" gorm:"not null"`
+	CreatedBy       uuid.UUID      `json:"created_by" gorm:"not null"`
+	InviteCode      string         `json:"invite_code" gorm:"uniqueIndex;not null"`
+	CodeExpiresAt   time.Time      `json:"code_expires_at" gorm:"not null"`
+	SSOOnly         bool           `json:"sso_only" gorm:"not null;default:false"`
+	RequireAdmin2FA bool           `json:"require_admin_2fa" gorm:"column:require_admin_2fa;not null;default:false"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relationships
 	CreatedByUser User        `json:"created_by_user" gorm:"foreignKey:CreatedBy;references:ID"`
@@ -48,28 +50,51 @@ type OrganizationJoinRequest struct {
 
 // OrganizationResponse represents the organization data returned to the client
 type OrganizationResponse struct {
-	ID            uuid.UUID `json:"id"`
-	Name          string    `json:"name"`
-	CreatedBy     uuid.UUID `json:"created_by"`
-	InviteCode    string    `json:"invite_code"`
-	CodeExpiresAt time.Time `json:"code_expires_at"`
-	CreatedAt     time.Time `json:"created_at"`
-	MemberCount   int       `json:"member_count"`
-	Role          string    `json:"role,omitempty"`
+	ID              uuid.UUID `json:"id"`
+	Name            string    `json:"name"`
+	CreatedBy       uuid.UUID `json:"created_by"`
+	InviteCode      string    `json:"invite_code"`
+	CodeExpiresAt   time.Time `json:"code_expires_at"`
+	SSOOnly         bool      `json:"sso_only"`
+	RequireAdmin2FA bool      `json:"require_admin_2fa" gorm:"column:require_admin_2fa"`
+	CreatedAt       time.Time `json:"created_at"`
+	MemberCount     int       `json:"member_count"`
+	Role            string    `json:"role,omitempty"`
 }
 
 // ToResponse converts an Organization to OrganizationResponse
 func (o *Organization) ToResponse() OrganizationResponse {
 	return OrganizationResponse{
-		ID:            o.ID,
-		Name:          o.Name,
-		CreatedBy:     o.CreatedBy,
-		InviteCode:    o.InviteCode,
-		CodeExpiresAt: o.CodeExpiresAt,
-		CreatedAt:     o.CreatedAt,
+		ID:              o.ID,
+		Name:            o.Name,
+		CreatedBy:       o.CreatedBy,
+		InviteCode:      o.InviteCode,
+		CodeExpiresAt:   o.CodeExpiresAt,
+		SSOOnly:         o.SSOOnly,
+		RequireAdmin2FA: o.RequireAdmin2FA,
+		CreatedAt:       o.CreatedAt,
 	}
 }
 
+// OrgAccessPolicy is what an organization requires of a member's session
+type OrgAccessPolicy struct {
+	SSOOnly bool
+
+	// TwoFactor is set when the member is an admin and the organization
+	// requires two-factor authentication for admins
+	TwoFactor bool
+}
+
+// OrgSession is how a request's session signed in, for filtering out the
+// organizations whose access policy it does not meet when listing across
+// organizations
+type OrgSession struct {
+	// Exempt is set for service accounts, which no policy applies to
+	Exempt    bool
+	SSO       bool
+	TwoFactor bool
+}
+
 // MemberRole constants
 const (
 	RoleMember = "member"
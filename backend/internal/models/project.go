@@ -7,7 +7,9 @@ import (
 	"gorm.io/gorm"
 )
 
-// ProjectStatus represents the status of a project
+// ProjectStatus represents the status of a project. Unlike task statuses,
+// which follow each project's workflow, project statuses are a fixed set
+// shared by every organization's project board.
 type ProjectStatus string
 
 const (
@@ -18,16 +20,18 @@ const (
 
 // Project represents a project in the system
 type Project struct {
-	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	OrgID       uuid.UUID      `json:"org_id" gorm:"not null"`
-	Name        string         `json:"name" gorm:"not null"`
-	Description string         `json:"description"`
-	Status      ProjectStatus  `json:"status" gorm:"not null;default:'idea'"`
-	CreatedBy   uuid.UUID      `json:"created_by" gorm:"not null"`
-	Deadline    *time.Time     `json:"deadline"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID           uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrgID        uuid.UUID         `json:"org_id" gorm:"not null"`
+	Name         string            `json:"name" gorm:"not null"`
+	Description  string            `json:"description"`
+	Status       ProjectStatus     `json:"status" gorm:"not null;default:'idea'"`
+	CreatedBy    uuid.UUID         `json:"created_by" gorm:"not null"`
+	Deadline     *time.Time        `json:"deadline"`
+	Rank         string            `json:"rank" gorm:"type:varchar(64) COLLATE \"C\";not null;default:''"`
+	CustomFields CustomFieldValues `json:"custom_fields" gorm:"type:jsonb;not null;default:'{}'"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt    `json:"-" gorm:"index"`
 
 	// Relationships
 	Organization  Organization      `json:"organization" gorm:"foreignKey:OrgID;references:ID"`
@@ -49,62 +53,81 @@ type ProjectAssignee struct {
 
 // ProjectCreateRequest represents the request to create a new project
 type ProjectCreateRequest struct {
-	Name        string      `json:"name" validate:"required,min=2,max=100"`
-	Description string      `json:"description" validate:"max=500"`
-	Deadline    *time.Time  `json:"deadline"`
-	AssigneeIDs []uuid.UUID `json:"assignee_ids"`
-	Status      string      `json:"status" validate:"oneof=idea in-progress finished"`
+	Name         string                 `json:"name" validate:"required,min=2,max=100"`
+	Description  string                 `json:"description" validate:"max=500"`
+	Deadline     *time.Time             `json:"deadline"`
+	AssigneeIDs  []uuid.UUID            `json:"assignee_ids"`
+	Status       string                 `json:"status" validate:"oneof=idea in-progress finished"`
+	LabelIDs     []uuid.UUID            `json:"label_ids,omitempty"`
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
 }
 
 // ProjectUpdateRequest represents the request to update a project
 type ProjectUpdateRequest struct {
-	Name        *string        `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
-	Description *string        `json:"description,omitempty" validate:"omitempty,max=500"`
-	Status      *ProjectStatus `json:"status,omitempty"`
-	Deadline    *time.Time     `json:"deadline,omitempty"`
-	AssigneeIDs []uuid.UUID    `json:"assignee_ids,omitempty"`
+	Name         *string                `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+	Description  *string                `json:"description,omitempty" validate:"omitempty,max=500"`
+	Status       *ProjectStatus         `json:"status,omitempty"`
+	Deadline     *time.Time             `json:"deadline,omitempty"`
+	AssigneeIDs  []uuid.UUID            `json:"assignee_ids,omitempty"`
+	LabelIDs     []uuid.UUID            `json:"label_ids,omitempty"`
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+}
+
+// ProjectFilter narrows the project list of an organization. CustomFields
+// matches custom field values by key.
+type ProjectFilter struct {
+	CustomFields map[string]string
 }
 
 // ProjectResponse represents the project data returned to the client
 type ProjectResponse struct {
-	ID          uuid.UUID      `json:"id"`
-	OrgID       uuid.UUID      `json:"org_id"`
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	Status      ProjectStatus  `json:"status"`
-	CreatedBy   uuid.UUID      `json:"created_by"`
-	Deadline    *time.Time     `json:"deadline"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	Assignees   []UserResponse `json:"assignees"`
-	TaskCount   int            `json:"task_count"`
+	ID           uuid.UUID         `json:"id"`
+	OrgID        uuid.UUID         `json:"org_id"`
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	Status       ProjectStatus     `json:"status"`
+	CreatedBy    uuid.UUID         `json:"created_by"`
+	Deadline     *time.Time        `json:"deadline"`
+	Rank         string            `json:"rank"`
+	CustomFields CustomFieldValues `json:"custom_fields"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	Assignees    []UserResponse    `json:"assignees"`
+	Labels       []Label           `json:"labels"`
+	TaskCount    int               `json:"task_count"`
+	Overdue      bool              `json:"overdue"`
 }
 
 // ProjectQueryResult represents the result from database query (without assignees)
 type ProjectQueryResult struct {
-	ID          uuid.UUID     `json:"id"`
-	OrgID       uuid.UUID     `json:"org_id"`
-	Name        string        `json:"name"`
-	Description string        `json:"description"`
-	Status      ProjectStatus `json:"status"`
-	CreatedBy   uuid.UUID     `json:"created_by"`
-	Deadline    *time.Time    `json:"deadline"`
-	CreatedAt   time.Time     `json:"created_at"`
-	UpdatedAt   time.Time     `json:"updated_at"`
-	TaskCount   int           `json:"task_count"`
+	ID           uuid.UUID         `json:"id"`
+	OrgID        uuid.UUID         `json:"org_id"`
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	Status       ProjectStatus     `json:"status"`
+	CreatedBy    uuid.UUID         `json:"created_by"`
+	Deadline     *time.Time        `json:"deadline"`
+	Rank         string            `json:"rank"`
+	CustomFields CustomFieldValues `json:"custom_fields"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	TaskCount    int               `json:"task_count"`
 }
 
 // ToResponse converts a Project to ProjectResponse
 func (p *Project) ToResponse() ProjectResponse {
 	return ProjectResponse{
-		ID:          p.ID,
-		OrgID:       p.OrgID,
-		Name:        p.Name,
-		Description: p.Description,
-		Status:      p.Status,
-		CreatedBy:   p.CreatedBy,
-		Deadline:    p.Deadline,
-		CreatedAt:   p.CreatedAt,
-		UpdatedAt:   p.UpdatedAt,
+		ID:           p.ID,
+		OrgID:        p.OrgID,
+		Name:         p.Name,
+		Description:  p.Description,
+		Status:       p.Status,
+		CreatedBy:    p.CreatedBy,
+		Deadline:     p.Deadline,
+		Rank:         p.Rank,
+		CustomFields: p.CustomFields,
+		CreatedAt:    p.CreatedAt,
+		UpdatedAt:    p.UpdatedAt,
+		Overdue:      IsOverdue(p.Deadline, p.Status == ProjectStatusFinished, time.Now()),
 	}
 }
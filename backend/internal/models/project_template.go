@@ -0,0 +1,128 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProjectTemplate is a project saved as a blueprint for new projects in its
+// organization. The project's setup and tasks are kept as a snapshot, so
+// later changes to the source project do not affect the template.
+type ProjectTemplate struct {
+	ID          uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrgID       uuid.UUID       `json:"org_id" gorm:"type:uuid;not null;index"`
+	Name        string          `json:"name" gorm:"not null"`
+	Description string          `json:"description"`
+	CreatedBy   uuid.UUID       `json:"created_by" gorm:"type:uuid;not null"`
+	Snapshot    ProjectSnapshot `json:"snapshot" gorm:"type:jsonb;not null"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// ProjectSnapshot captures what a new project copies from another: its
+// description, deadline, custom fields, labels, assignees, workflow and
+// tasks. Deadlines are kept as offsets from the start of the day (UTC) the
+// source project was created.
+type ProjectSnapshot struct {
+	Description           string                    `json:"description"`
+	DeadlineOffsetMinutes *int                      `json:"deadline_offset_minutes,omitempty"`
+	CustomFields          CustomFieldValues         `json:"custom_fields"`
+	LabelIDs              []uuid.UUID               `json:"label_ids"`
+	AssigneeIDs           []uuid.UUID               `json:"assignee_ids"`
+	Columns               []WorkflowColumnInput     `json:"columns"`
+	Transitions           []WorkflowTransitionInput `json:"transitions"`
+	Tasks                 []TaskSnapshot            `json:"tasks"`
+}
+
+// TaskSnapshot captures a task of a project snapshot, in board order. Tasks
+// refer to their parent and blockers by Ref, their index in the snapshot.
+type TaskSnapshot struct {
+	Ref                   int               `json:"ref"`
+	ParentRef             *int              `json:"parent_ref,omitempty"`
+	Name                  string            `json:"name"`
+	Description           string            `json:"description"`
+	Priority              TaskPriority      `json:"priority"`
+	StoryPoints           *int              `json:"story_points,omitempty"`
+	EstimateMinutes       *int              `json:"estimate_minutes,omitempty"`
+	DeadlineOffsetMinutes *int              `json:"deadline_offset_minutes,omitempty"`
+	CustomFields          CustomFieldValues `json:"custom_fields"`
+	LabelIDs              []uuid.UUID       `json:"label_ids,omitempty"`
+	AssigneeIDs           []uuid.UUID       `json:"assignee_ids,omitempty"`
+	Checklist             []string          `json:"checklist,omitempty"`
+	BlockedByRefs         []int             `json:"blocked_by_refs,omitempty"`
+}
+
+// Value implements driver.Valuer
+func (s ProjectSnapshot) Value() (driver.Value, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (s *ProjectSnapshot) Scan(value interface{}) error {
+	*s = ProjectSnapshot{}
+	switch data := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(data, s)
+	case string:
+		return json.Unmarshal([]byte(data), s)
+	default:
+		return fmt.Errorf("cannot scan %T into ProjectSnapshot", value)
+	}
+}
+
+// ProjectCloneRequest represents the request to create a project from
+// another project or from a template. New tasks start in the first column
+// of the workflow with their checklists unchecked. StartDate shifts task
+// deadlines so they keep their distance from the project's start; without
+// it a clone keeps the original deadlines and a template starts today.
+type ProjectCloneRequest struct {
+	Name             string     `json:"name" validate:"required,min=2,max=100"`
+	IncludeTasks     bool       `json:"include_tasks"`
+	IncludeAssignees bool       `json:"include_assignees"`
+	IncludeLabels    bool       `json:"include_labels"`
+	StartDate        *time.Time `json:"start_date,omitempty"`
+}
+
+// ProjectTemplateCreateRequest represents the request to save a project as a
+// template
+type ProjectTemplateCreateRequest struct {
+	Name        string `json:"name" validate:"required,min=2,max=100"`
+	Description string `json:"description" validate:"max=500"`
+}
+
+// ProjectTemplateResponse represents a template returned in lists, without
+// its snapshot
+type ProjectTemplateResponse struct {
+	ID          uuid.UUID `json:"id"`
+	OrgID       uuid.UUID `json:"org_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedBy   uuid.UUID `json:"created_by"`
+	TaskCount   int       `json:"task_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ToResponse converts a ProjectTemplate to a ProjectTemplateResponse
+func (t *ProjectTemplate) ToResponse() ProjectTemplateResponse {
+	return ProjectTemplateResponse{
+		ID:          t.ID,
+		OrgID:       t.OrgID,
+		Name:        t.Name,
+		Description: t.Description,
+		CreatedBy:   t.CreatedBy,
+		TaskCount:   len(t.Snapshot.Tasks),
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecurringTask is a task template that the scheduler turns into a new task
+// on every occurrence of its recurrence rule
+type RecurringTask struct {
+	ID              uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProjectID       uuid.UUID    `json:"project_id" gorm:"type:uuid;not null;index"`
+	CreatedBy       uuid.UUID    `json:"created_by" gorm:"type:uuid;not null"`
+	Name            string       `json:"name" gorm:"not null"`
+	Description     string       `json:"description"`
+	Priority        TaskPriority `json:"priority" gorm:"not null;default:'medium'"`
+	StoryPoints     *int         `json:"story_points"`
+	EstimateMinutes *int         `json:"estimate_minutes"`
+	AssigneeIDs     UUIDList     `json:"assignee_ids" gorm:"type:jsonb;not null;default:'[]'"`
+	LabelIDs        UUIDList     `json:"label_ids" gorm:"type:jsonb;not null;default:'[]'"`
+
+	// Recurrence rule
+	Frequency string     `json:"frequency" gorm:"not null"`
+	Interval  int        `json:"interval" gorm:"not null;default:1"`
+	ByWeekday StringList `json:"by_weekday" gorm:"type:jsonb;not null;default:'[]'"`
+	StartsAt  time.Time  `json:"starts_at" gorm:"not null"`
+	Until     *time.Time `json:"until"`
+	Count     *int       `json:"count"`
+
+	// DueAfterMinutes sets each task's deadline relative to its occurrence.
+	// Without it the deadline is the next occurrence.
+	DueAfterMinutes *int `json:"due_after_minutes"`
+
+	// Schedule state; NextRunAt is nil once the rule has no occurrences left
+	NextRunAt       *time.Time `json:"next_run_at" gorm:"index"`
+	LastRunAt       *time.Time `json:"last_run_at"`
+	OccurrenceCount int        `json:"occurrence_count" gorm:"not null;default:0"`
+	LastError       string     `json:"last_error,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RecurringTaskCreateRequest represents the request to create a recurring task
+type RecurringTaskCreateRequest struct {
+	Name            string       `json:"name" validate:"required,min=1,max=200"`
+	Description     string       `json:"description" validate:"max=1000"`
+	Priority        TaskPriority `json:"priority,omitempty" validate:"omitempty,oneof=low medium high urgent"`
+	StoryPoints     *int         `json:"story_points,omitempty" validate:"omitempty,min=0"`
+	EstimateMinutes *int         `json:"estimate_minutes,omitempty" validate:"omitempty,min=0"`
+	AssigneeIDs     []uuid.UUID  `json:"assignee_ids"`
+	LabelIDs        []uuid.UUID  `json:"label_ids,omitempty"`
+	Frequency       string       `json:"frequency" validate:"required,oneof=daily weekly monthly"`
+	Interval        int          `json:"interval,omitempty" validate:"omitempty,min=1"`
+	ByWeekday       []string     `json:"by_weekday,omitempty"`
+	StartsAt        time.Time    `json:"starts_at" validate:"required"`
+	Until           *time.Time   `json:"until,omitempty"`
+	Count           *int         `json:"count,omitempty" validate:"omitempty,min=1"`
+	DueAfterMinutes *int         `json:"due_after_minutes,omitempty" validate:"omitempty,min=0"`
+}
+
+// RecurringTaskUpdateRequest represents the request to update a recurring
+// task. Changing the rule reschedules the next occurrence.
+type RecurringTaskUpdateRequest struct {
+	Name            *string       `json:"name,omitempty" validate:"omitempty,min=1,max=200"`
+	Description     *string       `json:"description,omitempty" validate:"omitempty,max=1000"`
+	Priority        *TaskPriority `json:"priority,omitempty" validate:"omitempty,oneof=low medium high urgent"`
+	StoryPoints     *int          `json:"story_points,omitempty" validate:"omitempty,min=0"`
+	EstimateMinutes *int          `json:"estimate_minutes,omitempty" validate:"omitempty,min=0"`
+	AssigneeIDs     []uuid.UUID   `json:"assignee_ids,omitempty"`
+	LabelIDs        []uuid.UUID   `json:"label_ids,omitempty"`
+	Frequency       *string       `json:"frequency,omitempty" validate:"omitempty,oneof=daily weekly monthly"`
+	Interval        *int          `json:"interval,omitempty" validate:"omitempty,min=1"`
+	ByWeekday       []string      `json:"by_weekday,omitempty"`
+	StartsAt        *time.Time    `json:"starts_at,omitempty"`
+	Until           *time.Time    `json:"until,omitempty"`
+	Count           *int          `json:"count,omitempty" validate:"omitempty,min=1"`
+	DueAfterMinutes *int          `json:"due_after_minutes,omitempty" validate:"omitempty,min=0"`
+}
+
+// UUIDList is a list of UUIDs stored as a JSONB array
+type UUIDList []uuid.UUID
+
+// Value implements driver.Valuer
+func (l UUIDList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (l *UUIDList) Scan(value interface{}) error {
+	*l = UUIDList{}
+	switch data := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(data, l)
+	case string:
+		return json.Unmarshal([]byte(data), l)
+	default:
+		return fmt.Errorf("cannot scan %T into UUIDList", value)
+	}
+}
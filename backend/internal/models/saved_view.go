@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SavedView is a named task list setup: filters, sort, grouping and visible
+// columns. Views with a project apply to its task list and may be shared
+// with the whole organization; views without one apply to the owner's
+// /me/tasks list and are always private.
+type SavedView struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	ProjectID *uuid.UUID `json:"project_id" gorm:"type:uuid;index"`
+	Name      string     `json:"name" gorm:"not null"`
+	Shared    bool       `json:"shared" gorm:"not null;default:false"`
+	Filters   StringMap  `json:"filters" gorm:"type:jsonb;not null;default:'{}'"`
+	Sort      string     `json:"sort" gorm:"not null;default:''"`
+	GroupBy   string     `json:"group_by" gorm:"not null;default:''"`
+	Columns   StringList `json:"columns" gorm:"type:jsonb;not null;default:'[]'"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// SavedViewCreateRequest represents the request to save a view. Filters are
+// the list's query parameters; Sort is a field, prefixed with "-" for
+// descending order.
+type SavedViewCreateRequest struct {
+	Name    string            `json:"name" validate:"required,max=100"`
+	Shared  bool              `json:"shared"`
+	Filters map[string]string `json:"filters"`
+	Sort    string            `json:"sort"`
+	GroupBy string            `json:"group_by"`
+	Columns []string          `json:"columns"`
+}
+
+// SavedViewUpdateRequest represents the request to update a view. Filters
+// and Columns replace the saved ones when given.
+type SavedViewUpdateRequest struct {
+	Name    *string           `json:"name,omitempty" validate:"omitempty,max=100"`
+	Shared  *bool             `json:"shared,omitempty"`
+	Filters map[string]string `json:"filters,omitempty"`
+	Sort    *string           `json:"sort,omitempty"`
+	GroupBy *string           `json:"group_by,omitempty"`
+	Columns []string          `json:"columns,omitempty"`
+}
+
+// StringMap is a string-to-string map stored as a JSONB object
+type StringMap map[string]string
+
+// Value implements driver.Valuer
+func (m StringMap) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (m *StringMap) Scan(value interface{}) error {
+	*m = StringMap{}
+	switch data := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(data, m)
+	case string:
+		return json.Unmarshal([]byte(data), m)
+	default:
+		return fmt.Errorf("cannot scan %T into StringMap", value)
+	}
+}
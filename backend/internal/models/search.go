@@ -0,0 +1,40 @@
+package models
+
+import "github.com/google/uuid"
+
+// Search result types
+const (
+	SearchTypeTask    = "task"
+	SearchTypeProject = "project"
+)
+
+// Pagination defaults for searching
+const (
+	DefaultSearchPerPage = 20
+	MaxSearchPerPage     = 100
+)
+
+// SearchFilter narrows an organization search. Empty fields match
+// everything; Statuses match task or project statuses.
+type SearchFilter struct {
+	Types      []string
+	ProjectID  *uuid.UUID
+	Statuses   []string
+	AssigneeID *uuid.UUID
+	Page       int
+	PerPage    int
+}
+
+// SearchResult is a task or project matching a search. NameHighlight and
+// Snippet are HTML-escaped, with matched words wrapped in <mark> tags.
+type SearchResult struct {
+	Type          string    `json:"type"`
+	ID            uuid.UUID `json:"id"`
+	ProjectID     uuid.UUID `json:"project_id"`
+	ProjectName   string    `json:"project_name"`
+	Name          string    `json:"name"`
+	Status        string    `json:"status"`
+	Rank          float64   `json:"rank"`
+	NameHighlight string    `json:"name_highlight"`
+	Snippet       string    `json:"snippet"`
+}
@@ -1,13 +1,15 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-// TaskStatus represents the status of a task
+// TaskStatus represents the status of a task: the key of a column in its
+// project's workflow. The constants are the keys of the default workflow.
 type TaskStatus string
 
 const (
@@ -16,23 +18,51 @@ const (
 	TaskStatusDone       TaskStatus = "done"
 )
 
+// TaskPriority represents how urgent a task is
+type TaskPriority string
+
+const (
+	TaskPriorityLow    TaskPriority = "low"
+	TaskPriorityMedium TaskPriority = "medium"
+	TaskPriorityHigh   TaskPriority = "high"
+	TaskPriorityUrgent TaskPriority = "urgent"
+)
+
+// IsValid reports whether the priority is one of the known priorities
+func (p TaskPriority) IsValid() bool {
+	switch p {
+	case TaskPriorityLow, TaskPriorityMedium, TaskPriorityHigh, TaskPriorityUrgent:
+		return true
+	}
+	return false
+}
+
 // Task represents a task in the system
 type Task struct {
-	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	ProjectID   uuid.UUID      `json:"project_id" gorm:"not null"`
-	Name        string         `json:"name" gorm:"not null"`
-	Description string         `json:"description"`
-	Status      TaskStatus     `json:"status" gorm:"not null;default:'not-started'"`
-	CreatedBy   uuid.UUID      `json:"created_by" gorm:"not null"`
-	Deadline    *time.Time     `json:"deadline"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID              uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProjectID       uuid.UUID         `json:"project_id" gorm:"not null"`
+	ParentTaskID    *uuid.UUID        `json:"parent_task_id" gorm:"type:uuid;index"`
+	Name            string            `json:"name" gorm:"not null"`
+	Description     string            `json:"description"`
+	Status          TaskStatus        `json:"status" gorm:"not null;default:'not-started'"`
+	Priority        TaskPriority      `json:"priority" gorm:"not null;default:'medium'"`
+	StoryPoints     *int              `json:"story_points"`
+	EstimateMinutes *int              `json:"estimate_minutes"`
+	CreatedBy       uuid.UUID         `json:"created_by" gorm:"not null"`
+	Deadline        *time.Time        `json:"deadline"`
+	Rank            string            `json:"rank" gorm:"type:varchar(64) COLLATE \"C\";not null;default:''"`
+	CustomFields    CustomFieldValues `json:"custom_fields" gorm:"type:jsonb;not null;default:'{}'"`
+	RecurringTaskID *uuid.UUID        `json:"recurring_task_id" gorm:"type:uuid;uniqueIndex:idx_tasks_recurrence_occurrence"`
+	OccurrenceAt    *time.Time        `json:"occurrence_at" gorm:"uniqueIndex:idx_tasks_recurrence_occurrence"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt    `json:"-" gorm:"index"`
 
 	// Relationships
-	Project       Project        `json:"project" gorm:"foreignKey:ProjectID;references:ID"`
-	CreatedByUser User           `json:"created_by_user" gorm:"foreignKey:CreatedBy;references:ID"`
-	Assignees     []TaskAssignee `json:"assignees" gorm:"foreignKey:TaskID;references:ID"`
+	Project        Project         `json:"project" gorm:"foreignKey:ProjectID;references:ID"`
+	CreatedByUser  User            `json:"created_by_user" gorm:"foreignKey:CreatedBy;references:ID"`
+	Assignees      []TaskAssignee  `json:"assignees" gorm:"foreignKey:TaskID;references:ID"`
+	ChecklistItems []ChecklistItem `json:"checklist_items" gorm:"foreignKey:TaskID;references:ID"`
 }
 
 // TaskAssignee represents a task assignee
@@ -46,72 +76,304 @@ type TaskAssignee struct {
 	User User `json:"user" gorm:"foreignKey:UserID;references:ID"`
 }
 
+// ChecklistItem represents an ordered checklist entry inside a task
+type ChecklistItem struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TaskID     uuid.UUID  `json:"task_id" gorm:"type:uuid;not null;index"`
+	Text       string     `json:"text" gorm:"not null"`
+	Done       bool       `json:"done" gorm:"not null;default:false"`
+	AssigneeID *uuid.UUID `json:"assignee_id" gorm:"type:uuid"`
+	Position   int        `json:"position" gorm:"not null;default:0"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TaskDependency represents a "task is blocked by another task" relationship
+type TaskDependency struct {
+	TaskID          uuid.UUID `json:"task_id" gorm:"type:uuid;primaryKey"`
+	BlockedByTaskID uuid.UUID `json:"blocked_by_task_id" gorm:"type:uuid;primaryKey"`
+	CreatedBy       uuid.UUID `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// TaskDependencyCreateRequest represents the request to mark a task as blocked by another
+type TaskDependencyCreateRequest struct {
+	BlockedByTaskID uuid.UUID `json:"blocked_by_task_id" validate:"required"`
+}
+
 // TaskCreateRequest represents the request to create a new task
 type TaskCreateRequest struct {
-	Name        string      `json:"name" validate:"required,min=2,max=100"`
-	Description string      `json:"description" validate:"max=500"`
-	Deadline    *time.Time  `json:"deadline"`
-	AssigneeIDs []uuid.UUID `json:"assignee_ids"`
+	Name            string                 `json:"name" validate:"required,min=2,max=100"`
+	Description     string                 `json:"description" validate:"max=500"`
+	Deadline        *time.Time             `json:"deadline"`
+	AssigneeIDs     []uuid.UUID            `json:"assignee_ids"`
+	ParentTaskID    *uuid.UUID             `json:"parent_task_id,omitempty"`
+	Priority        TaskPriority           `json:"priority,omitempty" validate:"omitempty,oneof=low medium high urgent"`
+	StoryPoints     *int                   `json:"story_points,omitempty" validate:"omitempty,min=0"`
+	EstimateMinutes *int                   `json:"estimate_minutes,omitempty" validate:"omitempty,min=0"`
+	LabelIDs        []uuid.UUID            `json:"label_ids,omitempty"`
+	CustomFields    map[string]interface{} `json:"custom_fields,omitempty"`
+
+	// Set by the scheduler for tasks created from a recurring task
+	RecurringTaskID *uuid.UUID `json:"-"`
+	OccurrenceAt    *time.Time `json:"-"`
 }
 
-// TaskUpdateRequest represents the request to update a task
+// TaskUpdateRequest represents the request to update a task.
+// Setting ParentTaskID to the nil UUID detaches the task from its parent.
+// OverrideWIPLimit lets admins change the status past a column's WIP limits.
 type TaskUpdateRequest struct {
-	Name        *string     `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
-	Description *string     `json:"description,omitempty" validate:"omitempty,max=500"`
-	Status      *TaskStatus `json:"status,omitempty"`
-	Deadline    *time.Time  `json:"deadline,omitempty"`
-	AssigneeIDs []uuid.UUID `json:"assignee_ids,omitempty"`
+	Name             *string                `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+	Description      *string                `json:"description,omitempty" validate:"omitempty,max=500"`
+	Status           *TaskStatus            `json:"status,omitempty"`
+	Deadline         *time.Time             `json:"deadline,omitempty"`
+	AssigneeIDs      []uuid.UUID            `json:"assignee_ids,omitempty"`
+	ParentTaskID     *uuid.UUID             `json:"parent_task_id,omitempty"`
+	Priority         *TaskPriority          `json:"priority,omitempty" validate:"omitempty,oneof=low medium high urgent"`
+	StoryPoints      *int                   `json:"story_points,omitempty" validate:"omitempty,min=0"`
+	EstimateMinutes  *int                   `json:"estimate_minutes,omitempty" validate:"omitempty,min=0"`
+	LabelIDs         []uuid.UUID            `json:"label_ids,omitempty"`
+	CustomFields     map[string]interface{} `json:"custom_fields,omitempty"`
+	OverrideWIPLimit bool                   `json:"override_wip_limit,omitempty"`
+}
+
+// TaskFilter narrows the task list of a project. Empty fields match every
+// task; LabelIDs matches tasks carrying any of the labels and CustomFields
+// matches custom field values by key.
+type TaskFilter struct {
+	// Sort, when set, orders the list ahead of the board order
+	Sort           *TaskSort
+	CustomFields   map[string]string
+	Priorities     []TaskPriority
+	LabelIDs       []uuid.UUID
+	MinStoryPoints *int
+	MaxStoryPoints *int
+	MinEstimate    *int
+	MaxEstimate    *int
+}
+
+// "My work" due date filters
+const (
+	MyTasksDueOverdue  = "overdue"
+	MyTasksDueThisWeek = "this_week"
+)
+
+// "My work" groupings
+const (
+	MyTasksGroupByOrg     = "org"
+	MyTasksGroupByProject = "project"
+)
+
+// Task list sort fields. A sort names one of them, prefixed with "-" for
+// descending order.
+const (
+	TaskSortRank        = "rank"
+	TaskSortName        = "name"
+	TaskSortDeadline    = "deadline"
+	TaskSortPriority    = "priority"
+	TaskSortStoryPoints = "story_points"
+	TaskSortCreatedAt   = "created_at"
+	TaskSortUpdatedAt   = "updated_at"
+)
+
+// ProjectTaskSorts are the fields a project's task list can be sorted by
+var ProjectTaskSorts = []string{
+	TaskSortRank, TaskSortName, TaskSortDeadline, TaskSortPriority,
+	TaskSortStoryPoints, TaskSortCreatedAt, TaskSortUpdatedAt,
+}
+
+// MyTaskSorts are the fields the current user's tasks can be sorted by.
+// Ranks only order tasks within a project, so they are left out.
+var MyTaskSorts = []string{
+	TaskSortName, TaskSortDeadline, TaskSortPriority,
+	TaskSortStoryPoints, TaskSortCreatedAt, TaskSortUpdatedAt,
 }
 
-// TaskMoveRequest represents the request to move a task (drag and drop)
+// TaskSort orders a task list by a field
+type TaskSort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseTaskSort reads a sort such as "-deadline", reporting false when it
+// names none of the given fields
+func ParseTaskSort(value string, fields []string) (*TaskSort, bool) {
+	sort := &TaskSort{Field: strings.TrimPrefix(value, "-")}
+	sort.Desc = sort.Field != value
+	for _, field := range fields {
+		if field == sort.Field {
+			return sort, true
+		}
+	}
+	return nil, false
+}
+
+// Pagination defaults for listing the current user's tasks
+const (
+	DefaultMyTasksPerPage = 50
+	MaxMyTasksPerPage     = 200
+)
+
+// MyTasksFilter narrows the tasks assigned to a user across their
+// organizations. Tasks in done columns are left out unless IncludeDone is
+// set or Statuses are given. OrgIDs, when set, limits the organizations.
+type MyTasksFilter struct {
+	Session     OrgSession
+	OrgIDs      []uuid.UUID
+	Statuses    []TaskStatus
+	Due         string
+	IncludeDone bool
+	GroupBy     string
+	Sort        *TaskSort
+	Page        int
+	PerPage     int
+}
+
+// MyTaskResponse is an assigned task along with where it lives
+type MyTaskResponse struct {
+	TaskResponse
+	OrgID       uuid.UUID `json:"org_id"`
+	OrgName     string    `json:"org_name"`
+	ProjectName string    `json:"project_name"`
+}
+
+// MyTaskGroup is the assigned tasks of one organization, or of one project
+// when grouping by project
+type MyTaskGroup struct {
+	OrgID       uuid.UUID        `json:"org_id"`
+	OrgName     string           `json:"org_name"`
+	ProjectID   *uuid.UUID       `json:"project_id,omitempty"`
+	ProjectName string           `json:"project_name,omitempty"`
+	Tasks       []MyTaskResponse `json:"tasks"`
+}
+
+// ChecklistItemCreateRequest represents the request to add a checklist item
+type ChecklistItemCreateRequest struct {
+	Text       string     `json:"text" validate:"required,min=1,max=500"`
+	AssigneeID *uuid.UUID `json:"assignee_id,omitempty"`
+}
+
+// ChecklistItemUpdateRequest represents the request to update a checklist item.
+// Setting AssigneeID to the nil UUID clears the assignee.
+type ChecklistItemUpdateRequest struct {
+	Text       *string    `json:"text,omitempty" validate:"omitempty,min=1,max=500"`
+	Done       *bool      `json:"done,omitempty"`
+	AssigneeID *uuid.UUID `json:"assignee_id,omitempty"`
+}
+
+// ChecklistReorderRequest represents the request to reorder a task's checklist
+type ChecklistReorderRequest struct {
+	ItemIDs []uuid.UUID `json:"item_ids" validate:"required,min=1"`
+}
+
+// TaskProgress represents how many of a task's subtasks and checklist items are done
+type TaskProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// CardPosition places a card within a board column: after one card, before
+// another, or between both. An empty position means the top of the column.
+type CardPosition struct {
+	AfterID  *uuid.UUID `json:"after_id,omitempty"`
+	BeforeID *uuid.UUID `json:"before_id,omitempty"`
+}
+
+// IsSet reports whether the position names a neighbouring card
+func (p CardPosition) IsSet() bool {
+	return p.AfterID != nil || p.BeforeID != nil
+}
+
+// TaskMoveRequest represents the request to move a task (drag and drop).
+// OverrideWIPLimit lets admins move past the target column's WIP limits.
 type TaskMoveRequest struct {
-	Status TaskStatus `json:"status" validate:"required"`
+	Status           TaskStatus `json:"status" validate:"required"`
+	OverrideWIPLimit bool       `json:"override_wip_limit,omitempty"`
+	CardPosition
 }
 
-// TaskBulkMoveRequest represents the request to move multiple tasks
+// TaskBulkMoveRequest represents the request to move multiple tasks. The tasks
+// are placed together at the position in the order given.
 type TaskBulkMoveRequest struct {
-	TaskIDs []uuid.UUID `json:"task_ids" validate:"required,min=1"`
-	Status  TaskStatus  `json:"status" validate:"required"`
+	TaskIDs          []uuid.UUID `json:"task_ids" validate:"required,min=1"`
+	Status           TaskStatus  `json:"status" validate:"required"`
+	OverrideWIPLimit bool        `json:"override_wip_limit,omitempty"`
+	CardPosition
 }
 
 // TaskResponse represents the task data returned to the client
 type TaskResponse struct {
-	ID          uuid.UUID      `json:"id"`
-	ProjectID   uuid.UUID      `json:"project_id"`
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	Status      TaskStatus     `json:"status"`
-	CreatedBy   uuid.UUID      `json:"created_by"`
-	Deadline    *time.Time     `json:"deadline"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	Assignees   []UserResponse `json:"assignees"`
+	ID              uuid.UUID         `json:"id"`
+	ProjectID       uuid.UUID         `json:"project_id"`
+	ParentTaskID    *uuid.UUID        `json:"parent_task_id"`
+	Name            string            `json:"name"`
+	Description     string            `json:"description"`
+	Status          TaskStatus        `json:"status"`
+	Priority        TaskPriority      `json:"priority"`
+	StoryPoints     *int              `json:"story_points"`
+	EstimateMinutes *int              `json:"estimate_minutes"`
+	CreatedBy       uuid.UUID         `json:"created_by"`
+	Deadline        *time.Time        `json:"deadline"`
+	Rank            string            `json:"rank"`
+	CustomFields    CustomFieldValues `json:"custom_fields"`
+	RecurringTaskID *uuid.UUID        `json:"recurring_task_id"`
+	OccurrenceAt    *time.Time        `json:"occurrence_at"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+	Assignees       []UserResponse    `json:"assignees"`
+	Labels          []Label           `json:"labels"`
+	Checklist       []ChecklistItem   `json:"checklist"`
+	Progress        TaskProgress      `json:"progress"`
+	BlockedBy       []uuid.UUID       `json:"blocked_by"`
+	Blocked         bool              `json:"blocked"`
+	Overdue         bool              `json:"overdue"`
 }
 
 // TaskQueryResult represents the result from database query (without assignees)
 type TaskQueryResult struct {
-	ID          uuid.UUID  `json:"id"`
-	ProjectID   uuid.UUID  `json:"project_id"`
-	Name        string     `json:"name"`
-	Description string     `json:"description"`
-	Status      TaskStatus `json:"status"`
-	CreatedBy   uuid.UUID  `json:"created_by"`
-	Deadline    *time.Time `json:"deadline"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID              uuid.UUID         `json:"id"`
+	ProjectID       uuid.UUID         `json:"project_id"`
+	ParentTaskID    *uuid.UUID        `json:"parent_task_id"`
+	Name            string            `json:"name"`
+	Description     string            `json:"description"`
+	Status          TaskStatus        `json:"status"`
+	Priority        TaskPriority      `json:"priority"`
+	StoryPoints     *int              `json:"story_points"`
+	EstimateMinutes *int              `json:"estimate_minutes"`
+	CreatedBy       uuid.UUID         `json:"created_by"`
+	Deadline        *time.Time        `json:"deadline"`
+	Rank            string            `json:"rank"`
+	CustomFields    CustomFieldValues `json:"custom_fields"`
+	RecurringTaskID *uuid.UUID        `json:"recurring_task_id"`
+	OccurrenceAt    *time.Time        `json:"occurrence_at"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
 }
 
 // ToResponse converts a Task to TaskResponse
 func (t *Task) ToResponse() TaskResponse {
 	return TaskResponse{
-		ID:          t.ID,
-		ProjectID:   t.ProjectID,
-		Name:        t.Name,
-		Description: t.Description,
-		Status:      t.Status,
-		CreatedBy:   t.CreatedBy,
-		Deadline:    t.Deadline,
-		CreatedAt:   t.CreatedAt,
-		UpdatedAt:   t.UpdatedAt,
+		ID:              t.ID,
+		ProjectID:       t.ProjectID,
+		ParentTaskID:    t.ParentTaskID,
+		Name:            t.Name,
+		Description:     t.Description,
+		Status:          t.Status,
+		Priority:        t.Priority,
+		StoryPoints:     t.StoryPoints,
+		EstimateMinutes: t.EstimateMinutes,
+		CreatedBy:       t.CreatedBy,
+		Deadline:        t.Deadline,
+		Rank:            t.Rank,
+		CustomFields:    t.CustomFields,
+		RecurringTaskID: t.RecurringTaskID,
+		OccurrenceAt:    t.OccurrenceAt,
+		CreatedAt:       t.CreatedAt,
+		UpdatedAt:       t.UpdatedAt,
 	}
 }
+
+// IsOverdue reports whether a deadline has passed on work that is not done
+func IsOverdue(deadline *time.Time, done bool, now time.Time) bool {
+	return deadline != nil && !done && deadline.Before(now)
+}
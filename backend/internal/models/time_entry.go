@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TimeEntry represents time a user spent on a task. Entries without an end
+// time are running timers; a user has at most one of those.
+type TimeEntry struct {
+	ID              uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TaskID          uuid.UUID  `json:"task_id" gorm:"type:uuid;not null;index"`
+	UserID          uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	StartedAt       time.Time  `json:"started_at" gorm:"not null"`
+	EndedAt         *time.Time `json:"ended_at"`
+	Note            string     `json:"note"`
+	DurationSeconds int64      `json:"duration_seconds" gorm:"-"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// AfterFind fills in the duration, counting running timers up to now
+func (e *TimeEntry) AfterFind(tx *gorm.DB) error {
+	e.SetDuration()
+	return nil
+}
+
+// SetDuration fills in the duration from the start and end times
+func (e *TimeEntry) SetDuration() {
+	end := time.Now()
+	if e.EndedAt != nil {
+		end = *e.EndedAt
+	}
+	e.DurationSeconds = int64(end.Sub(e.StartedAt).Seconds())
+}
+
+// TimerStartRequest represents the request to start a timer on a task
+type TimerStartRequest struct {
+	Note string `json:"note" validate:"max=500"`
+}
+
+// TimeEntryCreateRequest represents the request to log time manually
+type TimeEntryCreateRequest struct {
+	StartedAt time.Time `json:"started_at" validate:"required"`
+	EndedAt   time.Time `json:"ended_at" validate:"required"`
+	Note      string    `json:"note" validate:"max=500"`
+}
+
+// TimeEntryUpdateRequest represents the request to update a time entry
+type TimeEntryUpdateRequest struct {
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	Note      *string    `json:"note,omitempty" validate:"omitempty,max=500"`
+}
+
+// TimesheetFilter represents the filters accepted by the timesheet report.
+// Entries are counted when they start in [From, To).
+type TimesheetFilter struct {
+	From      time.Time
+	To        time.Time
+	UserID    *uuid.UUID
+	ProjectID *uuid.UUID
+}
+
+// TimesheetRow represents the time one user logged on one project on one day
+type TimesheetRow struct {
+	UserID      uuid.UUID `json:"user_id"`
+	UserName    string    `json:"user_name"`
+	ProjectID   uuid.UUID `json:"project_id"`
+	ProjectName string    `json:"project_name"`
+	Day         string    `json:"day"`
+	Seconds     int64     `json:"seconds"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserTwoFactor holds a user's TOTP secret. The secret is pending until the
+// user proves their authenticator app works by entering a code.
+type UserTwoFactor struct {
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;primaryKey"`
+	Secret    string     `json:"-" gorm:"not null"`
+	Enabled   bool       `json:"enabled" gorm:"not null;default:false"`
+	EnabledAt *time.Time `json:"enabled_at"`
+
+	// LastUsedStep is the TOTP time step of the last accepted code, so that
+	// a code cannot be used twice
+	LastUsedStep int64 `json:"-" gorm:"not null;default:0"`
+
+	// Consecutive wrong codes lock verification until LockedUntil
+	FailedAttempts int        `json:"-" gorm:"not null;default:0"`
+	LockedUntil    *time.Time `json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RecoveryCode is a bcrypt hashed one-time code that stands in for a TOTP code when
+// the user has lost their authenticator
+type RecoveryCode struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	CodeHash  string     `json:"-" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TwoFactorStatus describes a user's two-factor authentication setup
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at"`
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorEnrollment is the secret a user adds to their authenticator app
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// TwoFactorCodeRequest carries a TOTP code or a recovery code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// TwoFactorVerifyRequest represents the second step of a login
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
@@ -25,6 +25,22 @@ type UserCreateRequest struct {
 	Password string `json:"password" binding:"required,min=8"`
 }
 
+// PendingRegistration is a sign-up waiting for the owner of its email to
+// confirm it. The user is only created once they do.
+type PendingRegistration struct {
+	TokenHash    string    `gorm:"primaryKey"`
+	Email        string    `gorm:"not null;index"`
+	FullName     string    `gorm:"not null"`
+	PasswordHash string    `gorm:"not null"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time
+}
+
+// RegistrationConfirmRequest carries the token from a registration email
+type RegistrationConfirmRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
 // UserLoginRequest represents the request to login a user
 type UserLoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
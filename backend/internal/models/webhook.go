@@ -0,0 +1,154 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook event types
+const (
+	WebhookEventTaskCreated    = "task.created"
+	WebhookEventTaskUpdated    = "task.updated"
+	WebhookEventTaskDeleted    = "task.deleted"
+	WebhookEventTaskMoved      = "task.moved"
+	WebhookEventProjectCreated = "project.created"
+	WebhookEventProjectUpdated = "project.updated"
+	WebhookEventProjectDeleted = "project.deleted"
+	WebhookEventProjectMoved   = "project.moved"
+	WebhookEventTest           = "webhook.test"
+)
+
+// WebhookEvents lists the events a webhook can subscribe to
+var WebhookEvents = []string{
+	WebhookEventTaskCreated,
+	WebhookEventTaskUpdated,
+	WebhookEventTaskDeleted,
+	WebhookEventTaskMoved,
+	WebhookEventProjectCreated,
+	WebhookEventProjectUpdated,
+	WebhookEventProjectDeleted,
+	WebhookEventProjectMoved,
+}
+
+// Webhook is an organization's subscription to events, delivered as signed
+// POST requests to URL. An empty event list subscribes to every event.
+type Webhook struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrgID     uuid.UUID  `json:"org_id" gorm:"type:uuid;not null;index"`
+	URL       string     `json:"url" gorm:"not null"`
+	Secret    string     `json:"-" gorm:"not null"`
+	Events    StringList `json:"events" gorm:"type:jsonb;not null;default:'[]'"`
+	Active    bool       `json:"active" gorm:"not null"`
+	CreatedBy uuid.UUID  `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Subscribes reports whether the webhook wants an event
+func (w *Webhook) Subscribes(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, subscribed := range w.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookCreateRequest represents the request to create a webhook. A secret
+// is generated when none is given.
+type WebhookCreateRequest struct {
+	URL    string   `json:"url" validate:"required,url"`
+	Secret string   `json:"secret,omitempty" validate:"omitempty,min=16,max=200"`
+	Events []string `json:"events,omitempty"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+// WebhookUpdateRequest represents the request to update a webhook
+type WebhookUpdateRequest struct {
+	URL    *string  `json:"url,omitempty" validate:"omitempty,url"`
+	Secret *string  `json:"secret,omitempty" validate:"omitempty,min=16,max=200"`
+	Events []string `json:"events,omitempty"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one event queued for one webhook, with the outcome of
+// its latest attempt. Pending deliveries are retried with exponential
+// backoff until they succeed or run out of attempts.
+type WebhookDelivery struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	WebhookID     uuid.UUID  `json:"webhook_id" gorm:"type:uuid;not null;index"`
+	Event         string     `json:"event" gorm:"not null"`
+	Payload       RawJSON    `json:"payload" gorm:"type:jsonb;not null"`
+	Status        string     `json:"status" gorm:"not null;default:'pending'"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	LastAttemptAt *time.Time `json:"last_attempt_at"`
+	ResponseCode  *int       `json:"response_code"`
+	ResponseBody  string     `json:"response_body,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// WebhookDeliveryFilter represents the filters accepted when listing deliveries
+type WebhookDeliveryFilter struct {
+	Status  string
+	Page    int
+	PerPage int
+}
+
+// WebhookPayload is the JSON body sent for every webhook event
+type WebhookPayload struct {
+	ID        uuid.UUID   `json:"id"`
+	Event     string      `json:"event"`
+	OrgID     uuid.UUID   `json:"org_id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// RawJSON is an already encoded JSON document stored as JSONB
+type RawJSON []byte
+
+// Value implements driver.Valuer
+func (j RawJSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return "null", nil
+	}
+	return string(j), nil
+}
+
+// Scan implements sql.Scanner
+func (j *RawJSON) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append(RawJSON(nil), data...)
+	case string:
+		*j = RawJSON(data)
+	default:
+		return fmt.Errorf("cannot scan %T into RawJSON", value)
+	}
+	return nil
+}
+
+// MarshalJSON returns the document as is
+func (j RawJSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
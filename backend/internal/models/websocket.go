@@ -27,6 +27,7 @@ const (
 	MessageTypeProjectDeleted = "project_deleted"
 	MessageTypeUserJoined     = "user_joined"
 	MessageTypeUserLeft       = "user_left"
+	MessageTypeNotification   = "notification"
 	MessageTypeError          = "error"
 )
 
@@ -36,7 +37,12 @@ type TaskMovedData struct {
 	ProjectID uuid.UUID `json:"project_id"`
 	OldStatus string    `json:"old_status"`
 	NewStatus string    `json:"new_status"`
+	Rank      string    `json:"rank,omitempty"`
 	UserID    uuid.UUID `json:"user_id"`
+	CardPosition
+
+	// OverrideWIPLimit lets an admin sending the move exceed WIP limits
+	OverrideWIPLimit bool `json:"override_wip_limit,omitempty"`
 }
 
 // ProjectMovedData represents the data for a project moved event
@@ -45,6 +51,7 @@ type ProjectMovedData struct {
 	OrgID     uuid.UUID `json:"org_id"`
 	OldStatus string    `json:"old_status"`
 	NewStatus string    `json:"new_status"`
+	Rank      string    `json:"rank,omitempty"`
 	UserID    uuid.UUID `json:"user_id"`
 }
 
@@ -98,6 +105,12 @@ type UserLeftData struct {
 	OrgID  uuid.UUID `json:"org_id"`
 }
 
+// NotificationData represents the data for a notification event, sent only
+// to the notification's user
+type NotificationData struct {
+	Notification Notification `json:"notification"`
+}
+
 // ErrorData represents the data for an error event
 type ErrorData struct {
 	Message string `json:"message"`
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WorkflowCategory groups workflow columns by how far along the work is
+type WorkflowCategory string
+
+const (
+	WorkflowCategoryTodo  WorkflowCategory = "todo"
+	WorkflowCategoryDoing WorkflowCategory = "doing"
+	WorkflowCategoryDone  WorkflowCategory = "done"
+)
+
+// IsValid reports whether the category is one of the known categories
+func (c WorkflowCategory) IsValid() bool {
+	switch c {
+	case WorkflowCategoryTodo, WorkflowCategoryDoing, WorkflowCategoryDone:
+		return true
+	}
+	return false
+}
+
+// WorkflowColumn represents a column of a project's task board. Tasks in the
+// project use the column key as their status. WIPLimit caps the tasks in the
+// column and AssigneeWIPLimit the tasks each assignee has in it.
+type WorkflowColumn struct {
+	ID               uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProjectID        uuid.UUID        `json:"project_id" gorm:"type:uuid;not null;uniqueIndex:idx_workflow_columns_project_key"`
+	Key              TaskStatus       `json:"key" gorm:"not null;uniqueIndex:idx_workflow_columns_project_key"`
+	Name             string           `json:"name" gorm:"not null"`
+	Category         WorkflowCategory `json:"category" gorm:"not null"`
+	Position         int              `json:"position" gorm:"not null;default:0"`
+	WIPLimit         *int             `json:"wip_limit"`
+	AssigneeWIPLimit *int             `json:"assignee_wip_limit"`
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
+}
+
+// WorkflowTransition represents an allowed move between two columns of a
+// project's workflow. A project without transitions allows every move.
+type WorkflowTransition struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProjectID uuid.UUID  `json:"project_id" gorm:"type:uuid;not null;uniqueIndex:idx_workflow_transitions_project_from_to"`
+	FromKey   TaskStatus `json:"from" gorm:"not null;uniqueIndex:idx_workflow_transitions_project_from_to"`
+	ToKey     TaskStatus `json:"to" gorm:"not null;uniqueIndex:idx_workflow_transitions_project_from_to"`
+	AdminOnly bool       `json:"admin_only" gorm:"not null;default:false"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// WorkflowColumnInput represents a column in a workflow update request
+type WorkflowColumnInput struct {
+	Key              TaskStatus       `json:"key" validate:"required,max=50"`
+	Name             string           `json:"name" validate:"required,max=100"`
+	Category         WorkflowCategory `json:"category" validate:"required,oneof=todo doing done"`
+	WIPLimit         *int             `json:"wip_limit,omitempty" validate:"omitempty,min=1"`
+	AssigneeWIPLimit *int             `json:"assignee_wip_limit,omitempty" validate:"omitempty,min=1"`
+}
+
+// WorkflowTransitionInput represents a transition in a workflow update request
+type WorkflowTransitionInput struct {
+	From      TaskStatus `json:"from" validate:"required"`
+	To        TaskStatus `json:"to" validate:"required"`
+	AdminOnly bool       `json:"admin_only"`
+}
+
+// WorkflowUpdateRequest represents the request to replace a project's workflow.
+// Remap moves tasks out of removed columns: removed key -> new key.
+// Transitions replaces the transition table when present, and an empty list
+// allows every move. It may only be omitted when the workflow allows every
+// move or the column keys stay the same; the existing transitions are kept.
+type WorkflowUpdateRequest struct {
+	Columns     []WorkflowColumnInput     `json:"columns" validate:"required,min=1"`
+	Remap       map[TaskStatus]TaskStatus `json:"remap,omitempty"`
+	Transitions []WorkflowTransitionInput `json:"transitions"`
+}
+
+// WIPLimitWarning describes a WIP limit a move exceeds: the limit of the whole
+// column, or of one assignee in it when AssigneeID is set
+type WIPLimitWarning struct {
+	Status     TaskStatus `json:"status"`
+	AssigneeID *uuid.UUID `json:"assignee_id,omitempty"`
+	Limit      int        `json:"limit"`
+	Count      int        `json:"count"`
+}
+
+// WorkflowResponse represents a project's workflow returned to the client
+type WorkflowResponse struct {
+	Columns     []WorkflowColumn     `json:"columns"`
+	Transitions []WorkflowTransition `json:"transitions"`
+}
+
+// DefaultWorkflowColumns returns the columns every project starts with
+func DefaultWorkflowColumns(projectID uuid.UUID) []WorkflowColumn {
+	return []WorkflowColumn{
+		{ProjectID: projectID, Key: TaskStatusNotStarted, Name: "Not Started", Category: WorkflowCategoryTodo, Position: 0},
+		{ProjectID: projectID, Key: TaskStatusInProgress, Name: "In Progress", Category: WorkflowCategoryDoing, Position: 1},
+		{ProjectID: projectID, Key: TaskStatusDone, Name: "Done", Category: WorkflowCategoryDone, Position: 2},
+	}
+}
+
+// DefaultWorkflowTransitions returns the transitions of the default workflow:
+// finished tasks cannot go back to not started, and only admins may reopen them
+func DefaultWorkflowTransitions(projectID uuid.UUID) []WorkflowTransition {
+	return []WorkflowTransition{
+		{ProjectID: projectID, FromKey: TaskStatusNotStarted, ToKey: TaskStatusInProgress},
+		{ProjectID: projectID, FromKey: TaskStatusNotStarted, ToKey: TaskStatusDone},
+		{ProjectID: projectID, FromKey: TaskStatusInProgress, ToKey: TaskStatusNotStarted},
+		{ProjectID: projectID, FromKey: TaskStatusInProgress, ToKey: TaskStatusDone},
+		{ProjectID: projectID, FromKey: TaskStatusDone, ToKey: TaskStatusInProgress, AdminOnly: true},
+	}
+}
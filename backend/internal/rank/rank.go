@@ -0,0 +1,86 @@
+package rank
+
+import "strings"
+
+// alphabet holds the rank digits in ascending order. Ranks compare as plain
+// strings, so the alphabet must sort the same way bytewise and in SQL.
+const alphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const base = len(alphabet)
+
+// MaxLength is the longest rank Between may hand out before the column it
+// belongs to should be rebalanced with Spread
+const MaxLength = 32
+
+// Between returns a rank that sorts strictly after prev and strictly before
+// next. An empty prev means the start of the column and an empty next means
+// its end. It reports false when prev does not sort before next.
+func Between(prev, next string) (string, bool) {
+	if next != "" && prev >= next {
+		return "", false
+	}
+
+	result := make([]byte, 0, len(prev)+1)
+	for i := 0; ; i++ {
+		lo := 0
+		if i < len(prev) {
+			lo = strings.IndexByte(alphabet, prev[i])
+		}
+		hi := base
+		if next != "" {
+			if i >= len(next) {
+				return "", false
+			}
+			hi = strings.IndexByte(alphabet, next[i])
+		}
+		if lo < 0 || hi < 0 {
+			return "", false
+		}
+
+		if lo == hi {
+			result = append(result, alphabet[lo])
+			continue
+		}
+
+		// The midpoint is never the zero digit, so ranks never end in "0" and
+		// there is always room before them
+		if mid := (lo + hi) / 2; mid > lo {
+			return string(append(result, alphabet[mid])), true
+		}
+
+		// Adjacent digits: keep prev's digit and look for room after the
+		// rest of prev, which is now unbounded above
+		result = append(result, alphabet[lo])
+		next = ""
+	}
+}
+
+// Spread returns n evenly spaced ranks in ascending order, leaving room for
+// inserts between and around them
+func Spread(n int) []string {
+	width := 2
+	capacity := base * base
+	for capacity < (n+1)*base {
+		width++
+		capacity *= base
+	}
+	step := capacity / (n + 1)
+
+	ranks := make([]string, n)
+	for i := range ranks {
+		ranks[i] = encode((i+1)*step, width)
+	}
+
+	return ranks
+}
+
+// encode writes value as a fixed-width rank, dropping trailing zero digits
+func encode(value, width int) string {
+	digits := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		digits[i] = alphabet[value%base]
+		value /= base
+	}
+
+	return strings.TrimRight(string(digits), "0")
+}
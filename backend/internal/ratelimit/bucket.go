@@ -0,0 +1,174 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a token bucket: it holds up to Requests tokens, refilled evenly
+// over Per. The zero Limit is unlimited.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// ParseLimit parses a limit written as "<requests>/<duration>", such as
+// "600/1m". "off" or "0" mean unlimited.
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(value)
+	if value == "off" || value == "0" {
+		return Limit{}, nil
+	}
+
+	requests, per, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected requests/duration", value)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || count < 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: bad request count", value)
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(per))
+	if err != nil || duration <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: bad duration", value)
+	}
+
+	return Limit{Requests: count, Per: duration}, nil
+}
+
+// Unlimited reports whether the limit lets everything through
+func (l Limit) Unlimited() bool {
+	return l.Requests <= 0 || l.Per <= 0
+}
+
+// String formats the limit the way ParseLimit reads it
+func (l Limit) String() string {
+	if l.Unlimited() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Per)
+}
+
+// rate returns the tokens added per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// BucketKey names a bucket and the limit it enforces
+type BucketKey struct {
+	Key   string
+	Limit Limit
+}
+
+// Decision is the outcome of taking a token from a bucket. Allowed reports
+// whether the bucket had a token; none is taken unless every bucket of the
+// request had one.
+type Decision struct {
+	Allowed   bool
+	Limit     Limit
+	Remaining int
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long until a token is available, when not allowed
+	RetryAfter time.Duration
+}
+
+// Bucket is the state of a token bucket
+type Bucket struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+// BucketStore keeps token buckets. Take must refill the buckets and take a
+// token from each of them atomically, and only when every one of them has a
+// token; a shared implementation (such as a Redis script) lets replicas
+// enforce one limit together.
+type BucketStore interface {
+	Take(keys []BucketKey, now time.Time) ([]Decision, error)
+}
+
+// Limiter takes requests from token buckets in a store
+type Limiter struct {
+	store BucketStore
+}
+
+// NewLimiter creates a limiter backed by a store
+func NewLimiter(store BucketStore) *Limiter {
+	return &Limiter{store: store}
+}
+
+// Take takes a token from each bucket a request counts against, or from none
+// of them when any is empty, so that a refused request is not charged. Keys
+// must not have unlimited limits. Like LoginGuard it fails open, so that an
+// unreachable store does not take the API down.
+func (l *Limiter) Take(keys []BucketKey) []Decision {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	decisions, err := l.store.Take(keys, time.Now())
+	if err != nil {
+		log.Printf("Failed to check rate limit: %v", err)
+		decisions = make([]Decision, len(keys))
+		for i, key := range keys {
+			decisions[i] = Decision{Allowed: true, Limit: key.Limit, Remaining: key.Limit.Requests}
+		}
+	}
+	return decisions
+}
+
+// take refills buckets for the time since they were last updated and takes a
+// token from each of them if every one has a token available. Nil buckets
+// start full.
+func take(buckets []*Bucket, limits []Limit, now time.Time) ([]Bucket, []Decision) {
+	next := make([]Bucket, len(buckets))
+	allowed := true
+	for i, bucket := range buckets {
+		next[i] = refill(bucket, limits[i], now)
+		if next[i].Tokens < 1 {
+			allowed = false
+		}
+	}
+
+	decisions := make([]Decision, len(buckets))
+	for i, limit := range limits {
+		rate := limit.rate()
+		decision := Decision{Limit: limit, Allowed: next[i].Tokens >= 1}
+		if allowed {
+			next[i].Tokens--
+		} else if !decision.Allowed {
+			decision.RetryAfter = seconds((1 - next[i].Tokens) / rate)
+		}
+		decision.Remaining = int(next[i].Tokens)
+		decision.Reset = seconds((float64(limit.Requests) - next[i].Tokens) / rate)
+		decisions[i] = decision
+	}
+
+	return next, decisions
+}
+
+// refill tops a bucket up for the time since it was last updated. A nil
+// bucket starts full.
+func refill(bucket *Bucket, limit Limit, now time.Time) Bucket {
+	capacity := float64(limit.Requests)
+
+	next := Bucket{Tokens: capacity, Updated: now}
+	if bucket != nil {
+		elapsed := now.Sub(bucket.Updated).Seconds()
+		if elapsed < 0 {
+			elapsed = 0
+		}
+		next.Tokens = math.Min(capacity, bucket.Tokens+elapsed*limit.rate())
+	}
+
+	return next
+}
+
+// seconds converts fractional seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+// LoginPolicy configures how failed sign-ins are throttled
+type LoginPolicy struct {
+	// MaxAccountFailures failed sign-ins to one account lock it for Lockout
+	MaxAccountFailures int
+	// MaxIPFailures failed sign-ins from one address lock it out for
+	// Lockout. It should allow for many users behind one NAT.
+	MaxIPFailures int
+	// Lockout is how long a lockout lasts, and how long failures are
+	// remembered for
+	Lockout time.Duration
+	// BaseDelay is the wait after an account's first failure, doubling with
+	// each further failure up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// DefaultLoginPolicy returns the policy used unless configured otherwise
+func DefaultLoginPolicy() LoginPolicy {
+	return LoginPolicy{
+		MaxAccountFailures: 5,
+		MaxIPFailures:      50,
+		Lockout:            15 * time.Minute,
+		BaseDelay:          time.Second,
+		MaxDelay:           30 * time.Second,
+	}
+}
+
+// LoginGuard throttles password guessing per account and per client address.
+// Each failure against an account makes the next attempt wait longer, and
+// too many failures lock the account or address out for a while.
+//
+// The guard fails open: if the store cannot be reached, sign-ins go ahead
+// unthrottled rather than locking everybody out.
+type LoginGuard struct {
+	store  Store
+	policy LoginPolicy
+}
+
+// NewLoginGuard creates a login guard backed by a store
+func NewLoginGuard(store Store, policy LoginPolicy) *LoginGuard {
+	return &LoginGuard{store: store, policy: policy}
+}
+
+// Check reports how long a sign-in to an account from an address must wait.
+// Zero means it may go ahead. An empty account only checks the address.
+func (g *LoginGuard) Check(ip, account string) time.Duration {
+	now := time.Now()
+	wait := g.waitFor(ipKey(ip), now, g.policy.MaxIPFailures, false)
+	if account != "" {
+		if accountWait := g.waitFor(accountKey(account), now, g.policy.MaxAccountFailures, true); accountWait > wait {
+			wait = accountWait
+		}
+	}
+	return wait
+}
+
+// Failure records a failed sign-in and reports whether it locked the account
+// or the address out
+func (g *LoginGuard) Failure(ip, account string) bool {
+	now := time.Now()
+	ttl := g.policy.Lockout
+	if g.policy.MaxDelay > ttl {
+		ttl = g.policy.MaxDelay
+	}
+
+	locked := false
+	if attempts, err := g.store.Increment(ipKey(ip), now, ttl); err != nil {
+		log.Printf("Failed to record login failure: %v", err)
+	} else if attempts.Count == g.policy.MaxIPFailures {
+		locked = true
+	}
+
+	if account != "" {
+		if attempts, err := g.store.Increment(accountKey(account), now, ttl); err != nil {
+			log.Printf("Failed to record login failure: %v", err)
+		} else if attempts.Count == g.policy.MaxAccountFailures {
+			locked = true
+		}
+	}
+
+	return locked
+}
+
+// Success clears an account's failures after a sign-in. The address keeps
+// its count, so that an attacker cannot reset it by signing in to an account
+// of their own between guesses.
+func (g *LoginGuard) Success(account string) {
+	if err := g.store.Reset(accountKey(account)); err != nil {
+		log.Printf("Failed to reset login failures: %v", err)
+	}
+}
+
+// waitFor works out how long a key must wait given its recent failures.
+// Progressive delays only apply to accounts; addresses are only locked out.
+func (g *LoginGuard) waitFor(key string, now time.Time, max int, progressive bool) time.Duration {
+	attempts, err := g.store.Get(key, now)
+	if err != nil {
+		log.Printf("Failed to check login failures: %v", err)
+		return 0
+	}
+	if attempts.Count == 0 {
+		return 0
+	}
+
+	var until time.Time
+	switch {
+	case max > 0 && attempts.Count >= max:
+		until = attempts.Last.Add(g.policy.Lockout)
+	case progressive:
+		until = attempts.Last.Add(g.delay(attempts.Count))
+	default:
+		return 0
+	}
+
+	if wait := until.Sub(now); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// delay returns the wait after a number of consecutive failures
+func (g *LoginGuard) delay(failures int) time.Duration {
+	delay := g.policy.BaseDelay
+	for i := 1; i < failures && delay < g.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > g.policy.MaxDelay {
+		delay = g.policy.MaxDelay
+	}
+	return delay
+}
+
+func ipKey(ip string) string {
+	return "login:ip:" + ip
+}
+
+// accountKey ignores case and surrounding spaces, so that variants of an
+// email share one counter
+func accountKey(account string) string {
+	return "login:account:" + strings.ToLower(strings.TrimSpace(account))
+}
@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Attempts counts recent failures under a key
+type Attempts struct {
+	Count int
+	Last  time.Time
+}
+
+// Store keeps failure counters. MemoryStore suits a single server; running
+// several replicas needs a shared implementation (such as Redis) so that an
+// attacker cannot spread attempts across them.
+type Store interface {
+	// Get returns the attempts under a key, or none when it has expired
+	Get(key string, now time.Time) (Attempts, error)
+	// Increment records a failure under a key and returns the new count. The
+	// key expires ttl after its last failure.
+	Increment(key string, now time.Time, ttl time.Duration) (Attempts, error)
+	// Reset forgets a key
+	Reset(key string) error
+}
+
+// memorySweepInterval is how often expired keys are dropped from memory
+const memorySweepInterval = time.Minute
+
+// MemoryStore is an in-process Store and BucketStore
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	buckets   map[string]memoryBucket
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	attempts  Attempts
+	expiresAt time.Time
+}
+
+// memoryBucket expires once it has refilled, as a missing bucket starts full
+type memoryBucket struct {
+	bucket    Bucket
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]memoryEntry),
+		buckets: make(map[string]memoryBucket),
+	}
+}
+
+// Get returns the attempts under a key
+func (s *MemoryStore) Get(key string, now time.Time) (Attempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		return Attempts{}, nil
+	}
+	return entry.attempts, nil
+}
+
+// Increment records a failure under a key
+func (s *MemoryStore) Increment(key string, now time.Time, ttl time.Duration) (Attempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	entry, ok := s.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		entry = memoryEntry{}
+	}
+	entry.attempts.Count++
+	entry.attempts.Last = now
+	entry.expiresAt = now.Add(ttl)
+	s.entries[key] = entry
+
+	return entry.attempts, nil
+}
+
+// Take takes a token from each of the buckets under keys, or from none of
+// them when any is empty
+func (s *MemoryStore) Take(keys []BucketKey, now time.Time) ([]Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	current := make([]*Bucket, len(keys))
+	limits := make([]Limit, len(keys))
+	for i, key := range keys {
+		if entry, ok := s.buckets[key.Key]; ok && now.Before(entry.expiresAt) {
+			bucket := entry.bucket
+			current[i] = &bucket
+		}
+		limits[i] = key.Limit
+	}
+
+	buckets, decisions := take(current, limits, now)
+	for i, key := range keys {
+		s.buckets[key.Key] = memoryBucket{bucket: buckets[i], expiresAt: now.Add(decisions[i].Reset)}
+	}
+
+	return decisions, nil
+}
+
+// Reset forgets a key
+func (s *MemoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// sweep drops expired keys, at most once per memorySweepInterval. The caller
+// must hold the lock.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memorySweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	for key, entry := range s.buckets {
+		if !now.Before(entry.expiresAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
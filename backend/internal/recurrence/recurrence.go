@@ -0,0 +1,243 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Frequencies supported by a Rule
+const (
+	Daily   = "daily"
+	Weekly  = "weekly"
+	Monthly = "monthly"
+)
+
+// weekdays maps RRULE weekday codes to time.Weekday
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// maxSteps bounds the search for the next occurrence, so that rules that
+// rarely match (say the 31st of every other month) still terminate
+const maxSteps = 1000
+
+// Rule is a subset of an iCalendar RRULE. Occurrences fall on Start's time
+// of day, in Start's location:
+//
+//   - daily: every Interval days from Start
+//   - weekly: every Interval weeks from Start's week, on each of ByWeekday
+//     (Start's weekday when empty)
+//   - monthly: every Interval months from Start, on Start's day of the
+//     month; months without that day are skipped
+//
+// Until, when set, is the last instant an occurrence may fall on. Count is
+// left to the caller, which knows how many occurrences it has used.
+type Rule struct {
+	Frequency string
+	Interval  int
+	ByWeekday []string
+	Start     time.Time
+	Until     *time.Time
+}
+
+// Validate checks the rule's frequency, interval, weekdays and end
+func (r Rule) Validate() error {
+	switch r.Frequency {
+	case Daily, Weekly, Monthly:
+	default:
+		return errors.New("frequency must be one of daily, weekly, monthly")
+	}
+
+	if r.Interval < 1 {
+		return errors.New("interval must be at least 1")
+	}
+
+	if len(r.ByWeekday) > 0 && r.Frequency != Weekly {
+		return errors.New("weekdays only apply to weekly rules")
+	}
+
+	for _, day := range r.ByWeekday {
+		if _, ok := weekdays[strings.ToUpper(day)]; !ok {
+			return fmt.Errorf("unknown weekday %q, expected one of MO, TU, WE, TH, FR, SA, SU", day)
+		}
+	}
+
+	if r.Start.IsZero() {
+		return errors.New("start is required")
+	}
+
+	if r.Until != nil && r.Until.Before(r.Start) {
+		return errors.New("until must not be before start")
+	}
+
+	return nil
+}
+
+// Next returns the first occurrence strictly after after, or false when the
+// rule has no more occurrences. The rule must be valid.
+func (r Rule) Next(after time.Time) (time.Time, bool) {
+	var next time.Time
+	var ok bool
+
+	switch r.Frequency {
+	case Daily:
+		next, ok = r.nextDaily(after)
+	case Weekly:
+		next, ok = r.nextWeekly(after)
+	case Monthly:
+		next, ok = r.nextMonthly(after)
+	}
+
+	if !ok || (r.Until != nil && next.After(*r.Until)) {
+		return time.Time{}, false
+	}
+
+	return next, true
+}
+
+// Latest returns the last occurrence at or before until, or false when the
+// rule has none by then
+func (r Rule) Latest(until time.Time) (time.Time, bool) {
+	current, ok := r.Next(r.Start.Add(-time.Nanosecond))
+	if !ok || current.After(until) {
+		return time.Time{}, false
+	}
+
+	// Jump close to until first so long-running rules stay cheap
+	if jump := until.Add(-r.span()); jump.After(current) {
+		if candidate, ok := r.Next(jump); ok && !candidate.After(until) {
+			current = candidate
+		}
+	}
+
+	for {
+		next, ok := r.Next(current)
+		if !ok || next.After(until) {
+			return current, true
+		}
+		current = next
+	}
+}
+
+// span is at least the longest gap between two consecutive occurrences
+func (r Rule) span() time.Duration {
+	switch r.Frequency {
+	case Weekly:
+		return time.Duration(r.Interval) * 7 * 24 * time.Hour
+	case Monthly:
+		// Skipped months can stretch a gap to a few intervals
+		return time.Duration(r.Interval) * 4 * 31 * 24 * time.Hour
+	default:
+		return time.Duration(r.Interval) * 24 * time.Hour
+	}
+}
+
+// nextDaily finds the next occurrence of a daily rule
+func (r Rule) nextDaily(after time.Time) (time.Time, bool) {
+	if after.Before(r.Start) {
+		return r.Start, true
+	}
+
+	// Start a step early; daylight saving changes can make the estimate
+	// overshoot
+	days := int(after.Sub(r.Start) / (24 * time.Hour))
+	step := days/r.Interval - 1
+	if step < 0 {
+		step = 0
+	}
+	for i := 0; i < maxSteps; i++ {
+		candidate := r.Start.AddDate(0, 0, (step+i)*r.Interval)
+		if candidate.After(after) {
+			return candidate, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// nextWeekly finds the next occurrence of a weekly rule
+func (r Rule) nextWeekly(after time.Time) (time.Time, bool) {
+	offsets := r.weekdayOffsets()
+
+	// Weeks start on Monday, at Start's time of day
+	weekStart := r.Start.AddDate(0, 0, -((int(r.Start.Weekday()) + 6) % 7))
+
+	step := 0
+	if after.After(weekStart) {
+		step = int(after.Sub(weekStart)/(7*24*time.Hour))/r.Interval - 1
+		if step < 0 {
+			step = 0
+		}
+	}
+
+	for i := 0; i < maxSteps; i++ {
+		week := weekStart.AddDate(0, 0, (step+i)*r.Interval*7)
+		for _, offset := range offsets {
+			candidate := week.AddDate(0, 0, offset)
+			if candidate.Before(r.Start) || !candidate.After(after) {
+				continue
+			}
+			return candidate, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// weekdayOffsets returns the rule's weekdays as sorted day offsets from Monday
+func (r Rule) weekdayOffsets() []int {
+	if len(r.ByWeekday) == 0 {
+		return []int{(int(r.Start.Weekday()) + 6) % 7}
+	}
+
+	seen := make(map[int]bool)
+	offsets := make([]int, 0, len(r.ByWeekday))
+	for _, day := range r.ByWeekday {
+		offset := (int(weekdays[strings.ToUpper(day)]) + 6) % 7
+		if !seen[offset] {
+			seen[offset] = true
+			offsets = append(offsets, offset)
+		}
+	}
+	sort.Ints(offsets)
+
+	return offsets
+}
+
+// nextMonthly finds the next occurrence of a monthly rule
+func (r Rule) nextMonthly(after time.Time) (time.Time, bool) {
+	step := 0
+	if after.After(r.Start) {
+		months := (after.Year()-r.Start.Year())*12 + int(after.Month()-r.Start.Month())
+		step = months / r.Interval
+		if step > 0 {
+			step--
+		}
+	}
+
+	hour, min, sec := r.Start.Clock()
+	for i := 0; i < maxSteps; i++ {
+		month := r.Start.Month() + time.Month((step+i)*r.Interval)
+		candidate := time.Date(r.Start.Year(), month, r.Start.Day(), hour, min, sec, r.Start.Nanosecond(), r.Start.Location())
+
+		// time.Date normalizes the 31st of a 30-day month into the next
+		// month; such months have no occurrence
+		if candidate.Day() != r.Start.Day() {
+			continue
+		}
+		if candidate.After(after) {
+			return candidate, true
+		}
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"taskman-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidAPIToken is returned when an API token request is rejected
+var ErrInvalidAPIToken = errors.New("invalid API token request")
+
+// ErrTokenAuthentication is returned when a presented API token is unknown,
+// expired or belongs to a user that no longer exists
+var ErrTokenAuthentication = errors.New("invalid API token")
+
+// API token tuning
+const (
+	defaultAPITokenLifetime = 90 * 24 * time.Hour
+	maxAPITokenLifetimeDays = 365
+	apiTokenDisplayLength   = 12
+
+	// lastUsedResolution limits how often last-used tracking writes to the
+	// database for a busy token
+	lastUsedResolution = time.Minute
+)
+
+// serviceAccountEmailDomain is a reserved domain for the users behind
+// service accounts, which never receive email
+const serviceAccountEmailDomain = "service-accounts.invalid"
+
+// APITokenService handles API tokens and service accounts
+type APITokenService struct {
+	db *gorm.DB
+}
+
+// NewAPITokenService creates a new API token service
+func NewAPITokenService(db *gorm.DB) *APITokenService {
+	return &APITokenService{db: db}
+}
+
+// CreatePersonalToken creates a token that acts as the user. The token
+// carries how the user signed in, and whether they passed two-factor
+// authentication, for organization policies. It returns the token itself,
+// which is not stored and cannot be retrieved again.
+func (s *APITokenService) CreatePersonalToken(userID uuid.UUID, authMethod string, twoFactor bool, req *models.APITokenCreateRequest) (string, *models.APIToken, error) {
+	orgIDs := uniqueUserIDs(req.OrgIDs)
+	if len(orgIDs) > 0 {
+		var count int64
+		err := s.db.Model(&models.OrgMember{}).
+			Where("user_id = ? AND org_id IN ?", userID, orgIDs).
+			Count(&count).Error
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to check memberships: %w", err)
+		}
+		if count != int64(len(orgIDs)) {
+			return "", nil, fmt.Errorf("%w: tokens can only be scoped to organizations you belong to", ErrInvalidAPIToken)
+		}
+	}
+
+	token := &models.APIToken{
+		UserID:     userID,
+		OrgIDs:     models.UUIDList(orgIDs),
+		AuthMethod: authMethod,
+		TwoFactor:  twoFactor,
+	}
+	return s.createToken(token, models.PersonalTokenPrefix, req)
+}
+
+// GetPersonalTokens retrieves a user's personal access tokens
+func (s *APITokenService) GetPersonalTokens(userID uuid.UUID) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := s.db.Where("user_id = ? AND service_account_id IS NULL", userID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// DeletePersonalToken revokes one of a user's personal access tokens
+func (s *APITokenService) DeletePersonalToken(userID, tokenID uuid.UUID) error {
+	result := s.db.Where("id = ? AND user_id = ? AND service_account_id IS NULL", tokenID, userID).Delete(&models.APIToken{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete API token: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("API token not found")
+	}
+
+	return nil
+}
+
+// AuthenticateToken resolves a presented API token to the identity it acts
+// as and records when and from where it was last used
+func (s *APITokenService) AuthenticateToken(raw, ipAddress string) (*models.TokenIdentity, error) {
+	var token models.APIToken
+	err := s.db.Where("token_hash = ?", hashAPIToken(raw)).First(&token).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrTokenAuthentication
+		}
+		return nil, fmt.Errorf("failed to get API token: %w", err)
+	}
+
+	now := time.Now()
+	if token.Expired(now) {
+		return nil, ErrTokenAuthentication
+	}
+
+	var user models.User
+	if err := s.db.Select("id, email").Where("id = ?", token.UserID).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrTokenAuthentication
+		}
+		return nil, fmt.Errorf("failed to get token user: %w", err)
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedResolution || token.LastUsedIP != ipAddress {
+		err := s.db.Model(&token).Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": ipAddress}).Error
+		if err != nil {
+			log.Printf("Failed to record use of API token %s: %v", token.ID, err)
+		}
+	}
+
+	return &models.TokenIdentity{
+		TokenID:    token.ID,
+		UserID:     user.ID,
+		Email:      user.Email,
+		OrgIDs:     token.OrgIDs,
+		Permission: token.Permission,
+		AuthMethod: token.AuthMethod,
+		TwoFactor:  token.TwoFactor,
+	}, nil
+}
+
+// GetServiceAccounts retrieves an organization's service accounts with their
+// member roles
+func (s *APITokenService) GetServiceAccounts(orgID uuid.UUID) ([]models.ServiceAccount, error) {
+	var accounts []models.ServiceAccount
+	if err := s.db.Where("org_id = ?", orgID).Order("created_at ASC").Find(&accounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get service accounts: %w", err)
+	}
+
+	var members []models.OrgMember
+	if err := s.db.Where("org_id = ?", orgID).Find(&members).Error; err != nil {
+		return nil, fmt.Errorf("failed to get organization members: %w", err)
+	}
+
+	roles := make(map[uuid.UUID]string, len(members))
+	for _, member := range members {
+		roles[member.UserID] = member.Role
+	}
+	for i := range accounts {
+		accounts[i].Role = roles[accounts[i].UserID]
+	}
+
+	return accounts, nil
+}
+
+// GetServiceAccountByID retrieves a service account by ID
+func (s *APITokenService) GetServiceAccountByID(id uuid.UUID) (*models.ServiceAccount, error) {
+	var account models.ServiceAccount
+	err := s.db.Where("id = ?", id).First(&account).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("service account not found")
+		}
+		return nil, fmt.Errorf("failed to get service account: %w", err)
+	}
+
+	return &account, nil
+}
+
+// CreateServiceAccount creates a service account and the user behind it, and
+// adds that user to the organization. The user has no usable password, so it
+// can only authenticate with the service account's tokens.
+func (s *APITokenService) CreateServiceAccount(orgID, createdBy uuid.UUID, req *models.ServiceAccountCreateRequest) (*models.ServiceAccount, error) {
+	role := req.Role
+	if role == "" {
+		role = models.RoleMember
+	}
+	if role != models.RoleMember && role != models.RoleAdmin {
+		return nil, fmt.Errorf("%w: role must be member or admin", ErrInvalidAPIToken)
+	}
+
+	account := &models.ServiceAccount{
+		ID:          uuid.New(),
+		OrgID:       orgID,
+		Name:        req.Name,
+		Description: req.Description,
+		Role:        role,
+		CreatedBy:   createdBy,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		user := &models.User{
+			Email:        fmt.Sprintf("%s@%s", account.ID, serviceAccountEmailDomain),
+			FullName:     req.Name,
+			PasswordHash: unusablePasswordHash,
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return fmt.Errorf("failed to create service account user: %w", err)
+		}
+
+		member := &models.OrgMember{
+			OrgID:    orgID,
+			UserID:   user.ID,
+			Role:     role,
+			JoinedAt: time.Now(),
+		}
+		if err := tx.Create(member).Error; err != nil {
+			return fmt.Errorf("failed to add service account to organization: %w", err)
+		}
+
+		account.UserID = user.ID
+		if err := tx.Create(account).Error; err != nil {
+			return fmt.Errorf("failed to create service account: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// DeleteServiceAccount deletes a service account, revoking its tokens and
+// removing its user from the organization
+func (s *APITokenService) DeleteServiceAccount(account *models.ServiceAccount) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("service_account_id = ?", account.ID).Delete(&models.APIToken{}).Error; err != nil {
+			return fmt.Errorf("failed to revoke service account tokens: %w", err)
+		}
+
+		if err := tx.Where("org_id = ? AND user_id = ?", account.OrgID, account.UserID).Delete(&models.OrgMember{}).Error; err != nil {
+			return fmt.Errorf("failed to remove service account from organization: %w", err)
+		}
+
+		if err := tx.Delete(account).Error; err != nil {
+			return fmt.Errorf("failed to delete service account: %w", err)
+		}
+
+		// Soft delete, so tasks and history that reference the user remain
+		if err := tx.Where("id = ?", account.UserID).Delete(&models.User{}).Error; err != nil {
+			return fmt.Errorf("failed to delete service account user: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// CreateServiceAccountToken creates a token that acts as the service account
+// within its organization. It returns the token itself, which is not stored
+// and cannot be retrieved again.
+func (s *APITokenService) CreateServiceAccountToken(account *models.ServiceAccount, req *models.APITokenCreateRequest) (string, *models.APIToken, error) {
+	accountID := account.ID
+	token := &models.APIToken{
+		UserID:           account.UserID,
+		ServiceAccountID: &accountID,
+		OrgIDs:           models.UUIDList{account.OrgID},
+		AuthMethod:       models.AuthMethodServiceAccount,
+	}
+	return s.createToken(token, models.ServiceAccountTokenPrefix, req)
+}
+
+// GetServiceAccountTokens retrieves a service account's tokens
+func (s *APITokenService) GetServiceAccountTokens(accountID uuid.UUID) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := s.db.Where("service_account_id = ?", accountID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service account tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// DeleteServiceAccountToken revokes one of a service account's tokens
+func (s *APITokenService) DeleteServiceAccountToken(accountID, tokenID uuid.UUID) error {
+	result := s.db.Where("id = ? AND service_account_id = ?", tokenID, accountID).Delete(&models.APIToken{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete service account token: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("API token not found")
+	}
+
+	return nil
+}
+
+// createToken fills in the generated token, its permission and expiry and
+// stores it
+func (s *APITokenService) createToken(token *models.APIToken, prefix string, req *models.APITokenCreateRequest) (string, *models.APIToken, error) {
+	if req.Name == "" || len(req.Name) > 100 {
+		return "", nil, fmt.Errorf("%w: name must be between 1 and 100 characters", ErrInvalidAPIToken)
+	}
+	if req.Permission != models.APITokenRead && req.Permission != models.APITokenWrite {
+		return "", nil, fmt.Errorf("%w: permission must be read or write", ErrInvalidAPIToken)
+	}
+
+	lifetime := defaultAPITokenLifetime
+	if req.ExpiresInDays != nil && *req.ExpiresInDays != 0 {
+		if *req.ExpiresInDays < 0 || *req.ExpiresInDays > maxAPITokenLifetimeDays {
+			return "", nil, fmt.Errorf("%w: expires_in_days must be between 1 and %d", ErrInvalidAPIToken, maxAPITokenLifetimeDays)
+		}
+		lifetime = time.Duration(*req.ExpiresInDays) * 24 * time.Hour
+	}
+	expiresAt := time.Now().Add(lifetime)
+	token.ExpiresAt = &expiresAt
+
+	raw, err := generateAPIToken(prefix)
+	if err != nil {
+		return "", nil, err
+	}
+
+	token.Name = req.Name
+	token.Permission = req.Permission
+	token.Prefix = raw[:apiTokenDisplayLength]
+	token.TokenHash = hashAPIToken(raw)
+
+	if err := s.db.Create(token).Error; err != nil {
+		return "", nil, fmt.Errorf("failed to create API token: %w", err)
+	}
+
+	return raw, token, nil
+}
+
+// generateAPIToken creates a random token with the given prefix
+func generateAPIToken(prefix string) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate API token: %w", err)
+	}
+	return prefix + hex.EncodeToString(secret), nil
+}
+
+// hashAPIToken returns the hex SHA-256 of a token. Tokens are long and random,
+// so a fast hash is enough to keep them from being usable if leaked.
+func hashAPIToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"taskman-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Checklist errors
+var (
+	// ErrInvalidChecklistOrder is returned when a reorder request does not
+	// list every item of the checklist exactly once
+	ErrInvalidChecklistOrder = errors.New("item list must contain every checklist item exactly once")
+
+	// ErrInvalidChecklistAssignee is returned when an item is assigned to
+	// someone outside the task's organization
+	ErrInvalidChecklistAssignee = errors.New("checklist items can only be assigned to members of the task's organization")
+)
+
+// ChecklistService handles checklist items inside tasks
+type ChecklistService struct {
+	db *gorm.DB
+}
+
+// NewChecklistService creates a new checklist service
+func NewChecklistService(db *gorm.DB) *ChecklistService {
+	return &ChecklistService{db: db}
+}
+
+// GetItems retrieves the checklist items of a task in order
+func (s *ChecklistService) GetItems(taskID uuid.UUID) ([]models.ChecklistItem, error) {
+	var items []models.ChecklistItem
+	err := s.db.Where("task_id = ?", taskID).Order("position ASC, created_at ASC").Find(&items).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checklist items: %w", err)
+	}
+
+	return items, nil
+}
+
+// GetItemByID retrieves a checklist item by ID
+func (s *ChecklistService) GetItemByID(id uuid.UUID) (*models.ChecklistItem, error) {
+	var item models.ChecklistItem
+	err := s.db.Where("id = ?", id).First(&item).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("checklist item not found")
+		}
+		return nil, fmt.Errorf("failed to get checklist item: %w", err)
+	}
+
+	return &item, nil
+}
+
+// AddItem appends a new item to the end of a task's checklist
+func (s *ChecklistService) AddItem(taskID uuid.UUID, req *models.ChecklistItemCreateRequest) (*models.ChecklistItem, error) {
+	if req.AssigneeID != nil && *req.AssigneeID != uuid.Nil {
+		if err := s.checkAssignee(taskID, *req.AssigneeID); err != nil {
+			return nil, err
+		}
+	} else {
+		req.AssigneeID = nil
+	}
+
+	var maxPosition *int
+	err := s.db.Model(&models.ChecklistItem{}).
+		Select("MAX(position)").
+		Where("task_id = ?", taskID).
+		Scan(&maxPosition).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checklist position: %w", err)
+	}
+
+	position := 0
+	if maxPosition != nil {
+		position = *maxPosition + 1
+	}
+
+	item := &models.ChecklistItem{
+		TaskID:     taskID,
+		Text:       req.Text,
+		AssigneeID: req.AssigneeID,
+		Position:   position,
+	}
+
+	if err := s.db.Create(item).Error; err != nil {
+		return nil, fmt.Errorf("failed to create checklist item: %w", err)
+	}
+
+	return item, nil
+}
+
+// UpdateItem updates a checklist item of a task
+func (s *ChecklistService) UpdateItem(taskID, id uuid.UUID, req *models.ChecklistItemUpdateRequest) (*models.ChecklistItem, error) {
+	// Build update map
+	updates := make(map[string]interface{})
+	if req.Text != nil {
+		updates["text"] = *req.Text
+	}
+	if req.Done != nil {
+		updates["done"] = *req.Done
+	}
+	if req.AssigneeID != nil {
+		if *req.AssigneeID == uuid.Nil {
+			updates["assignee_id"] = nil
+		} else {
+			if err := s.checkAssignee(taskID, *req.AssigneeID); err != nil {
+				return nil, err
+			}
+			updates["assignee_id"] = *req.AssigneeID
+		}
+	}
+
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("no fields to update")
+	}
+
+	result := s.db.Model(&models.ChecklistItem{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update checklist item: %w", result.Error)
+	}
+
+	return s.GetItemByID(id)
+}
+
+// checkAssignee checks that a user is a member of the organization owning a
+// task's project
+func (s *ChecklistService) checkAssignee(taskID, userID uuid.UUID) error {
+	var count int64
+	err := s.db.Table("org_members om").
+		Joins("JOIN projects p ON p.org_id = om.org_id").
+		Joins("JOIN tasks t ON t.project_id = p.id").
+		Where("t.id = ? AND om.user_id = ?", taskID, userID).
+		Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("failed to check checklist assignee: %w", err)
+	}
+	if count == 0 {
+		return ErrInvalidChecklistAssignee
+	}
+
+	return nil
+}
+
+// DeleteItem deletes a checklist item
+func (s *ChecklistService) DeleteItem(id uuid.UUID) error {
+	result := s.db.Where("id = ?", id).Delete(&models.ChecklistItem{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete checklist item: %w", result.Error)
+	}
+
+	return nil
+}
+
+// ReorderItems sets the checklist order of a task to the given item order.
+// Every item of the task must be listed exactly once.
+func (s *ChecklistService) ReorderItems(taskID uuid.UUID, itemIDs []uuid.UUID) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.ChecklistItem{}).Where("task_id = ?", taskID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to count checklist items: %w", err)
+		}
+
+		seen := make(map[uuid.UUID]bool)
+		for _, id := range itemIDs {
+			seen[id] = true
+		}
+		if int64(len(seen)) != count || len(itemIDs) != len(seen) {
+			return ErrInvalidChecklistOrder
+		}
+
+		for position, id := range itemIDs {
+			result := tx.Model(&models.ChecklistItem{}).
+				Where("id = ? AND task_id = ?", id, taskID).
+				Update("position", position)
+			if result.Error != nil {
+				return fmt.Errorf("failed to reorder checklist: %w", result.Error)
+			}
+			if result.RowsAffected == 0 {
+				return ErrInvalidChecklistOrder
+			}
+		}
+
+		return nil
+	})
+}
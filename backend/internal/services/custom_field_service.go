@@ -0,0 +1,318 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"taskman-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCustomField is returned when a custom field definition or value
+// is rejected
+var ErrInvalidCustomField = errors.New("invalid custom field")
+
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z0-9_]{1,50}$`)
+
+// customFieldDateLayout is the format of date custom field values
+const customFieldDateLayout = "2006-01-02"
+
+// maxCustomFieldTextLength is the longest text custom field value
+const maxCustomFieldTextLength = 1000
+
+// CustomFieldService handles organization custom field definitions
+type CustomFieldService struct {
+	db *gorm.DB
+}
+
+// NewCustomFieldService creates a new custom field service
+func NewCustomFieldService(db *gorm.DB) *CustomFieldService {
+	return &CustomFieldService{db: db}
+}
+
+// GetFields retrieves the custom fields of an organization by key. An empty
+// entity returns the fields of every entity.
+func (s *CustomFieldService) GetFields(orgID uuid.UUID, entity models.CustomFieldEntity) ([]models.CustomField, error) {
+	query := s.db.Where("org_id = ?", orgID)
+	if entity != "" {
+		query = query.Where("entity = ?", entity)
+	}
+
+	var fields []models.CustomField
+	if err := query.Order("entity ASC, key ASC").Find(&fields).Error; err != nil {
+		return nil, fmt.Errorf("failed to get custom fields: %w", err)
+	}
+
+	return fields, nil
+}
+
+// GetFieldByID retrieves a custom field by ID
+func (s *CustomFieldService) GetFieldByID(id uuid.UUID) (*models.CustomField, error) {
+	var field models.CustomField
+	err := s.db.Where("id = ?", id).First(&field).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("custom field not found")
+		}
+		return nil, fmt.Errorf("failed to get custom field: %w", err)
+	}
+
+	return &field, nil
+}
+
+// CreateField defines a new custom field in an organization
+func (s *CustomFieldService) CreateField(orgID uuid.UUID, req *models.CustomFieldCreateRequest) (*models.CustomField, error) {
+	field := &models.CustomField{
+		OrgID:    orgID,
+		Entity:   req.Entity,
+		Key:      strings.TrimSpace(req.Key),
+		Name:     strings.TrimSpace(req.Name),
+		Type:     req.Type,
+		Options:  models.StringList(req.Options),
+		Required: req.Required,
+	}
+
+	if !field.Entity.IsValid() {
+		return nil, fmt.Errorf("%w: entity must be one of task, project", ErrInvalidCustomField)
+	}
+	if !field.Type.IsValid() {
+		return nil, fmt.Errorf("%w: type must be one of text, number, date, select, user", ErrInvalidCustomField)
+	}
+	if !customFieldKeyPattern.MatchString(field.Key) {
+		return nil, fmt.Errorf("%w: key must be 1-50 lowercase letters, digits or underscores", ErrInvalidCustomField)
+	}
+
+	if err := validateCustomField(field); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	err := s.db.Model(&models.CustomField{}).
+		Where("org_id = ? AND entity = ? AND key = ?", orgID, field.Entity, field.Key).
+		Count(&count).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check custom field key: %w", err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("%w: a %s field with key %q already exists", ErrInvalidCustomField, field.Entity, field.Key)
+	}
+
+	if err := s.db.Create(field).Error; err != nil {
+		return nil, fmt.Errorf("failed to create custom field: %w", err)
+	}
+
+	return field, nil
+}
+
+// UpdateField updates the name, options or required flag of a custom field.
+// Values already stored are not revalidated.
+func (s *CustomFieldService) UpdateField(id uuid.UUID, req *models.CustomFieldUpdateRequest) (*models.CustomField, error) {
+	field, err := s.GetFieldByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name == nil && req.Options == nil && req.Required == nil {
+		return nil, fmt.Errorf("no fields to update")
+	}
+	if req.Name != nil {
+		field.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Options != nil {
+		field.Options = models.StringList(req.Options)
+	}
+	if req.Required != nil {
+		field.Required = *req.Required
+	}
+
+	if err := validateCustomField(field); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Model(&models.CustomField{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"name":     field.Name,
+		"options":  field.Options,
+		"required": field.Required,
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to update custom field: %w", err)
+	}
+
+	return s.GetFieldByID(id)
+}
+
+// DeleteField deletes a custom field and removes its values from every task
+// or project of the organization
+func (s *CustomFieldService) DeleteField(id uuid.UUID) error {
+	field, err := s.GetFieldByID(id)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var result *gorm.DB
+		if field.Entity == models.CustomFieldEntityTask {
+			result = tx.Exec("UPDATE tasks SET custom_fields = custom_fields - ? WHERE project_id IN (SELECT id FROM projects WHERE org_id = ?)", field.Key, field.OrgID)
+		} else {
+			result = tx.Exec("UPDATE projects SET custom_fields = custom_fields - ? WHERE org_id = ?", field.Key, field.OrgID)
+		}
+		if result.Error != nil {
+			return fmt.Errorf("failed to remove custom field values: %w", result.Error)
+		}
+
+		if err := tx.Where("id = ?", id).Delete(&models.CustomField{}).Error; err != nil {
+			return fmt.Errorf("failed to delete custom field: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// validateCustomField checks a custom field's name and options
+func validateCustomField(field *models.CustomField) error {
+	if field.Name == "" || len(field.Name) > 100 {
+		return fmt.Errorf("%w: name must be 1-100 characters", ErrInvalidCustomField)
+	}
+
+	if field.Type != models.CustomFieldTypeSelect {
+		if len(field.Options) > 0 {
+			return fmt.Errorf("%w: only select fields have options", ErrInvalidCustomField)
+		}
+		return nil
+	}
+
+	if len(field.Options) == 0 {
+		return fmt.Errorf("%w: select fields need at least one option", ErrInvalidCustomField)
+	}
+
+	seen := make(map[string]bool)
+	for _, option := range field.Options {
+		if option == "" || len(option) > 100 {
+			return fmt.Errorf("%w: options must be 1-100 characters", ErrInvalidCustomField)
+		}
+		if seen[option] {
+			return fmt.Errorf("%w: duplicate option %q", ErrInvalidCustomField, option)
+		}
+		seen[option] = true
+	}
+
+	return nil
+}
+
+// resolveCustomFields merges input into the current custom field values of a
+// task or project, checking each value against the organization's field
+// definitions. A null value clears a field. Required fields must have a
+// value once input is applied.
+func resolveCustomFields(tx *gorm.DB, orgID uuid.UUID, entity models.CustomFieldEntity, current models.CustomFieldValues, input map[string]interface{}) (models.CustomFieldValues, error) {
+	var fields []models.CustomField
+	if err := tx.Where("org_id = ? AND entity = ?", orgID, entity).Find(&fields).Error; err != nil {
+		return nil, fmt.Errorf("failed to get custom fields: %w", err)
+	}
+
+	byKey := make(map[string]*models.CustomField, len(fields))
+	for i := range fields {
+		byKey[fields[i].Key] = &fields[i]
+	}
+
+	values := make(models.CustomFieldValues, len(current)+len(input))
+	for key, value := range current {
+		values[key] = value
+	}
+
+	for key, value := range input {
+		field, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown %s field %q", ErrInvalidCustomField, entity, key)
+		}
+
+		if value == nil {
+			delete(values, key)
+			continue
+		}
+
+		normalized, err := checkCustomFieldValue(tx, orgID, field, value)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = normalized
+	}
+
+	for _, field := range fields {
+		if _, ok := values[field.Key]; field.Required && !ok {
+			return nil, fmt.Errorf("%w: field %q is required", ErrInvalidCustomField, field.Key)
+		}
+	}
+
+	return values, nil
+}
+
+// checkCustomFieldValue checks a value against the type of its field and
+// returns it in stored form
+func checkCustomFieldValue(tx *gorm.DB, orgID uuid.UUID, field *models.CustomField, value interface{}) (interface{}, error) {
+	switch field.Type {
+	case models.CustomFieldTypeNumber:
+		if number, ok := value.(float64); ok {
+			return number, nil
+		}
+		return nil, fmt.Errorf("%w: field %q must be a number", ErrInvalidCustomField, field.Key)
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: field %q must be a string", ErrInvalidCustomField, field.Key)
+	}
+
+	switch field.Type {
+	case models.CustomFieldTypeText:
+		if len(text) > maxCustomFieldTextLength {
+			return nil, fmt.Errorf("%w: field %q must be at most %d characters", ErrInvalidCustomField, field.Key, maxCustomFieldTextLength)
+		}
+		return text, nil
+
+	case models.CustomFieldTypeDate:
+		date, err := time.Parse(customFieldDateLayout, text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: field %q must be a date like 2024-01-31", ErrInvalidCustomField, field.Key)
+		}
+		return date.Format(customFieldDateLayout), nil
+
+	case models.CustomFieldTypeSelect:
+		for _, option := range field.Options {
+			if option == text {
+				return text, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: field %q must be one of %s", ErrInvalidCustomField, field.Key, strings.Join(field.Options, ", "))
+
+	case models.CustomFieldTypeUser:
+		userID, err := uuid.Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: field %q must be a user ID", ErrInvalidCustomField, field.Key)
+		}
+
+		var count int64
+		if err := tx.Model(&models.OrgMember{}).Where("org_id = ? AND user_id = ?", orgID, userID).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to check custom field user: %w", err)
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("%w: field %q must be a member of this organization", ErrInvalidCustomField, field.Key)
+		}
+		return userID.String(), nil
+	}
+
+	return nil, fmt.Errorf("%w: field %q has unknown type %q", ErrInvalidCustomField, field.Key, field.Type)
+}
+
+// applyCustomFieldFilter narrows a query to rows whose custom field values,
+// in the JSONB column named column, match filter by key
+func applyCustomFieldFilter(query *gorm.DB, column string, filter map[string]string) *gorm.DB {
+	for key, value := range filter {
+		query = query.Where(column+"->>? = ?", key, value)
+	}
+
+	return query
+}
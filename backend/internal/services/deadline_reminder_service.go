@@ -0,0 +1,213 @@
+package services
+
+import (
+	"fmt"
+	"taskman-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// overdueReminderWindow is how long after a deadline passes an overdue
+// reminder may still go out. Older deadlines are skipped, so the first run
+// after a deploy or an outage does not remind of everything long overdue.
+const overdueReminderWindow = 24 * time.Hour
+
+// DeadlineReminderService reminds assignees of task and project deadlines
+// that are coming up or have passed
+type DeadlineReminderService struct {
+	db            *gorm.DB
+	notifications *NotificationService
+	lead          time.Duration
+}
+
+// NewDeadlineReminderService creates a new deadline reminder service.
+// Reminders go out once a deadline is less than lead away.
+func NewDeadlineReminderService(db *gorm.DB, notificationService *NotificationService, lead time.Duration) *DeadlineReminderService {
+	return &DeadlineReminderService{
+		db:            db,
+		notifications: notificationService,
+		lead:          lead,
+	}
+}
+
+// deadlineRow is an assignee of a task or project with a deadline
+type deadlineRow struct {
+	TargetID  uuid.UUID
+	OrgID     uuid.UUID
+	ProjectID uuid.UUID
+	Name      string
+	Deadline  time.Time
+	UserID    uuid.UUID
+}
+
+// RunReminders notifies the assignees of unfinished tasks and projects whose
+// deadline is within the lead time or passed recently, and returns how many
+// reminders were sent. Each assignee gets at most one "approaching" and one
+// "overdue" reminder per deadline, even with several replicas running.
+func (s *DeadlineReminderService) RunReminders(now time.Time) (int, error) {
+	tasks, err := s.dueTasks(now)
+	if err != nil {
+		return 0, err
+	}
+
+	projects, err := s.dueProjects(now)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, row := range tasks {
+		ok, err := s.remind("task", row, now)
+		if err != nil {
+			return sent, err
+		}
+		if ok {
+			sent++
+		}
+	}
+
+	for _, row := range projects {
+		ok, err := s.remind("project", row, now)
+		if err != nil {
+			return sent, err
+		}
+		if ok {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// dueTasks finds the assignees of unfinished tasks due within the lead time
+// or overdue within the reminder window
+func (s *DeadlineReminderService) dueTasks(now time.Time) ([]deadlineRow, error) {
+	var rows []deadlineRow
+	err := s.db.Table("tasks t").
+		Select("t.id AS target_id, p.org_id, t.project_id, t.name, t.deadline, u.id AS user_id").
+		Joins("JOIN projects p ON p.id = t.project_id AND p.deleted_at IS NULL").
+		Joins("JOIN task_assignees ta ON ta.task_id = t.id").
+		Joins("JOIN users u ON u.id = ta.user_id").
+		Where("t.deleted_at IS NULL AND t.deadline IS NOT NULL AND t.deadline BETWEEN ? AND ?", now.Add(-overdueReminderWindow), now.Add(s.lead)).
+		Where(
+			"NOT EXISTS (SELECT 1 FROM workflow_columns wc WHERE wc.project_id = t.project_id AND wc.key = t.status AND wc.category = ?)",
+			models.WorkflowCategoryDone,
+		).
+		Where(
+			"NOT EXISTS (SELECT 1 FROM deadline_reminders r WHERE r.user_id = u.id AND r.target_type = 'task' AND r.target_id = t.id AND r.deadline = t.deadline AND r.kind = CASE WHEN t.deadline < ? THEN ? ELSE ? END)",
+			now, models.DeadlineReminderOverdue, models.DeadlineReminderApproaching,
+		).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due tasks: %w", err)
+	}
+
+	return rows, nil
+}
+
+// dueProjects finds the assignees of unfinished projects due within the lead
+// time or overdue within the reminder window
+func (s *DeadlineReminderService) dueProjects(now time.Time) ([]deadlineRow, error) {
+	var rows []deadlineRow
+	err := s.db.Table("projects p").
+		Select("p.id AS target_id, p.org_id, p.id AS project_id, p.name, p.deadline, u.id AS user_id").
+		Joins("JOIN project_assignees pa ON pa.project_id = p.id").
+		Joins("JOIN users u ON u.id = pa.user_id").
+		Where("p.deleted_at IS NULL AND p.deadline IS NOT NULL AND p.deadline BETWEEN ? AND ?", now.Add(-overdueReminderWindow), now.Add(s.lead)).
+		Where("p.status <> ?", models.ProjectStatusFinished).
+		Where(
+			"NOT EXISTS (SELECT 1 FROM deadline_reminders r WHERE r.user_id = u.id AND r.target_type = 'project' AND r.target_id = p.id AND r.deadline = p.deadline AND r.kind = CASE WHEN p.deadline < ? THEN ? ELSE ? END)",
+			now, models.DeadlineReminderOverdue, models.DeadlineReminderApproaching,
+		).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due projects: %w", err)
+	}
+
+	return rows, nil
+}
+
+// remind records a reminder and, if no other run recorded it first, notifies
+// the assignee on the channel they chose for deadline reminders. Both happen
+// in one transaction, so a failed notification is retried by the next run.
+func (s *DeadlineReminderService) remind(targetType string, row deadlineRow, now time.Time) (bool, error) {
+	kind := models.DeadlineReminderApproaching
+	if row.Deadline.Before(now) {
+		kind = models.DeadlineReminderOverdue
+	}
+
+	reminder := &models.DeadlineReminder{
+		UserID:     row.UserID,
+		TargetType: targetType,
+		TargetID:   row.TargetID,
+		Kind:       kind,
+		Deadline:   row.Deadline,
+		SentAt:     now,
+	}
+
+	notification := deadlineNotification(targetType, kind, row)
+	recorded, stored := false, false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(reminder)
+		if result.Error != nil {
+			return fmt.Errorf("failed to record deadline reminder: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		recorded = true
+
+		var err error
+		stored, err = s.notifications.store(tx, notification)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if stored {
+		s.notifications.publish(notification)
+	}
+
+	return recorded, nil
+}
+
+// deadlineNotification builds the notification for a deadline reminder
+func deadlineNotification(targetType, kind string, row deadlineRow) *models.Notification {
+	orgID := row.OrgID
+	projectID := row.ProjectID
+	notification := &models.Notification{
+		UserID:    row.UserID,
+		OrgID:     &orgID,
+		ProjectID: &projectID,
+	}
+
+	if targetType == "task" {
+		taskID := row.TargetID
+		notification.TaskID = &taskID
+	}
+
+	deadline := row.Deadline.UTC().Format("2006-01-02 15:04 MST")
+	if kind == models.DeadlineReminderOverdue {
+		notification.Type = models.NotificationDeadlineOverdue
+		notification.Title = fmt.Sprintf("%s %q is overdue", capitalize(targetType), row.Name)
+		notification.Body = fmt.Sprintf("The %s %q was due %s.", targetType, row.Name, deadline)
+	} else {
+		notification.Type = models.NotificationDeadlineApproaching
+		notification.Title = fmt.Sprintf("%s %q is due soon", capitalize(targetType), row.Name)
+		notification.Body = fmt.Sprintf("The %s %q is due %s.", targetType, row.Name, deadline)
+	}
+
+	return notification
+}
+
+// capitalize upper-cases the first letter of an ASCII word
+func capitalize(word string) string {
+	if word == "" || word[0] < 'a' || word[0] > 'z' {
+		return word
+	}
+	return string(word[0]-'a'+'A') + word[1:]
+}
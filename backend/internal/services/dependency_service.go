@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"taskman-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidDependency is returned when a dependency link is not allowed
+var ErrInvalidDependency = errors.New("invalid task dependency")
+
+// DependencyService handles blocked-by relationships between tasks
+type DependencyService struct {
+	db *gorm.DB
+}
+
+// NewDependencyService creates a new dependency service
+func NewDependencyService(db *gorm.DB) *DependencyService {
+	return &DependencyService{db: db}
+}
+
+// AddDependency marks taskID as blocked by blockedByID. Both tasks must be in
+// the same project and the link must not create a cycle.
+func (s *DependencyService) AddDependency(taskID, blockedByID, createdBy uuid.UUID) (*models.TaskDependency, error) {
+	if taskID == blockedByID {
+		return nil, fmt.Errorf("%w: a task cannot block itself", ErrInvalidDependency)
+	}
+
+	dependency := &models.TaskDependency{
+		TaskID:          taskID,
+		BlockedByTaskID: blockedByID,
+		CreatedBy:       createdBy,
+		CreatedAt:       time.Now(),
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var tasks []models.Task
+		if err := tx.Where("id IN ?", []uuid.UUID{taskID, blockedByID}).Find(&tasks).Error; err != nil {
+			return fmt.Errorf("failed to get tasks: %w", err)
+		}
+
+		if len(tasks) != 2 {
+			return fmt.Errorf("%w: blocking task not found", ErrInvalidDependency)
+		}
+
+		if tasks[0].ProjectID != tasks[1].ProjectID {
+			return fmt.Errorf("%w: tasks belong to different projects", ErrInvalidDependency)
+		}
+
+		// Walk the blockers of the blocking task; reaching taskID means a cycle
+		var count int64
+		err := tx.Raw(`
+			WITH RECURSIVE blockers AS (
+				SELECT blocked_by_task_id AS id FROM task_dependencies WHERE task_id = ?
+				UNION
+				SELECT d.blocked_by_task_id FROM task_dependencies d JOIN blockers b ON d.task_id = b.id
+			)
+			SELECT COUNT(*) FROM blockers WHERE id = ?`, blockedByID, taskID).Scan(&count).Error
+		if err != nil {
+			return fmt.Errorf("failed to check dependency cycle: %w", err)
+		}
+
+		if count > 0 {
+			return fmt.Errorf("%w: dependency would create a cycle", ErrInvalidDependency)
+		}
+
+		var existing int64
+		err = tx.Model(&models.TaskDependency{}).
+			Where("task_id = ? AND blocked_by_task_id = ?", taskID, blockedByID).
+			Count(&existing).Error
+		if err != nil {
+			return fmt.Errorf("failed to check existing dependency: %w", err)
+		}
+
+		if existing > 0 {
+			return fmt.Errorf("%w: dependency already exists", ErrInvalidDependency)
+		}
+
+		if err := tx.Create(dependency).Error; err != nil {
+			return fmt.Errorf("failed to create dependency: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dependency, nil
+}
+
+// RemoveDependency removes the link marking taskID as blocked by blockedByID
+func (s *DependencyService) RemoveDependency(taskID, blockedByID uuid.UUID) error {
+	result := s.db.Where("task_id = ? AND blocked_by_task_id = ?", taskID, blockedByID).Delete(&models.TaskDependency{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove dependency: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("dependency not found")
+	}
+
+	return nil
+}
+
+// GetBlockingTasks retrieves the tasks that block a task
+func (s *DependencyService) GetBlockingTasks(taskID uuid.UUID) ([]models.TaskResponse, error) {
+	return s.getLinkedTasks("JOIN task_dependencies d ON d.blocked_by_task_id = tasks.id", "d.task_id = ?", taskID)
+}
+
+// GetBlockedTasks retrieves the tasks blocked by a task
+func (s *DependencyService) GetBlockedTasks(taskID uuid.UUID) ([]models.TaskResponse, error) {
+	return s.getLinkedTasks("JOIN task_dependencies d ON d.task_id = tasks.id", "d.blocked_by_task_id = ?", taskID)
+}
+
+// getLinkedTasks retrieves tasks joined through task_dependencies
+func (s *DependencyService) getLinkedTasks(join, condition string, taskID uuid.UUID) ([]models.TaskResponse, error) {
+	var tasks []models.Task
+	err := s.db.Joins(join).Where(condition, taskID).Order("d.created_at ASC").Find(&tasks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get linked tasks: %w", err)
+	}
+
+	responses := make([]models.TaskResponse, len(tasks))
+	for i := range tasks {
+		responses[i] = tasks[i].ToResponse()
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"taskman-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidLabel is returned when a label is rejected or a label ID does not
+// belong to the organization
+var ErrInvalidLabel = errors.New("invalid label")
+
+var labelColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// LabelService handles organization labels
+type LabelService struct {
+	db *gorm.DB
+}
+
+// NewLabelService creates a new label service
+func NewLabelService(db *gorm.DB) *LabelService {
+	return &LabelService{db: db}
+}
+
+// GetLabels retrieves the labels of an organization by name
+func (s *LabelService) GetLabels(orgID uuid.UUID) ([]models.Label, error) {
+	var labels []models.Label
+	err := s.db.Where("org_id = ?", orgID).Order("name ASC").Find(&labels).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get labels: %w", err)
+	}
+
+	return labels, nil
+}
+
+// GetLabelByID retrieves a label by ID
+func (s *LabelService) GetLabelByID(id uuid.UUID) (*models.Label, error) {
+	var label models.Label
+	err := s.db.Where("id = ?", id).First(&label).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("label not found")
+		}
+		return nil, fmt.Errorf("failed to get label: %w", err)
+	}
+
+	return &label, nil
+}
+
+// CreateLabel creates a new label in an organization
+func (s *LabelService) CreateLabel(orgID uuid.UUID, req *models.LabelCreateRequest) (*models.Label, error) {
+	label := &models.Label{
+		OrgID: orgID,
+		Name:  strings.TrimSpace(req.Name),
+		Color: strings.ToLower(req.Color),
+	}
+
+	if err := s.validateLabel(label); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(label).Error; err != nil {
+		return nil, fmt.Errorf("failed to create label: %w", err)
+	}
+
+	return label, nil
+}
+
+// UpdateLabel updates a label
+func (s *LabelService) UpdateLabel(id uuid.UUID, req *models.LabelUpdateRequest) (*models.Label, error) {
+	label, err := s.GetLabelByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name == nil && req.Color == nil {
+		return nil, fmt.Errorf("no fields to update")
+	}
+	if req.Name != nil {
+		label.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Color != nil {
+		label.Color = strings.ToLower(*req.Color)
+	}
+
+	if err := s.validateLabel(label); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Model(&models.Label{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"name":  label.Name,
+		"color": label.Color,
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to update label: %w", err)
+	}
+
+	return s.GetLabelByID(id)
+}
+
+// DeleteLabel deletes a label and removes it from every task and project
+func (s *LabelService) DeleteLabel(id uuid.UUID) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("label_id = ?", id).Delete(&models.TaskLabel{}).Error; err != nil {
+			return fmt.Errorf("failed to remove label from tasks: %w", err)
+		}
+
+		if err := tx.Where("label_id = ?", id).Delete(&models.ProjectLabel{}).Error; err != nil {
+			return fmt.Errorf("failed to remove label from projects: %w", err)
+		}
+
+		if err := tx.Where("id = ?", id).Delete(&models.Label{}).Error; err != nil {
+			return fmt.Errorf("failed to delete label: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// validateLabel checks a label's name and color, and that no other label of
+// the organization has the same name
+func (s *LabelService) validateLabel(label *models.Label) error {
+	if label.Name == "" || len(label.Name) > 50 {
+		return fmt.Errorf("%w: name must be 1-50 characters", ErrInvalidLabel)
+	}
+
+	if !labelColorPattern.MatchString(label.Color) {
+		return fmt.Errorf("%w: color must be a hex color like #1f77b4", ErrInvalidLabel)
+	}
+
+	var count int64
+	err := s.db.Model(&models.Label{}).
+		Where("org_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", label.OrgID, label.Name, label.ID).
+		Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("failed to check label name: %w", err)
+	}
+
+	if count > 0 {
+		return fmt.Errorf("%w: a label named %q already exists", ErrInvalidLabel, label.Name)
+	}
+
+	return nil
+}
+
+// checkOrgLabels checks that every label in labelIDs belongs to the organization
+func checkOrgLabels(tx *gorm.DB, orgID uuid.UUID, labelIDs []uuid.UUID) error {
+	unique := make(map[uuid.UUID]bool)
+	for _, id := range labelIDs {
+		unique[id] = true
+	}
+
+	var count int64
+	if err := tx.Model(&models.Label{}).Where("org_id = ? AND id IN ?", orgID, labelIDs).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check labels: %w", err)
+	}
+
+	if int(count) != len(unique) {
+		return fmt.Errorf("%w: label does not belong to this organization", ErrInvalidLabel)
+	}
+
+	return nil
+}
+
+// setTaskLabels replaces the labels of a task
+func setTaskLabels(tx *gorm.DB, taskID, orgID uuid.UUID, labelIDs []uuid.UUID) error {
+	if err := tx.Where("task_id = ?", taskID).Delete(&models.TaskLabel{}).Error; err != nil {
+		return fmt.Errorf("failed to remove task labels: %w", err)
+	}
+
+	if len(labelIDs) == 0 {
+		return nil
+	}
+
+	if err := checkOrgLabels(tx, orgID, labelIDs); err != nil {
+		return err
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	for _, labelID := range labelIDs {
+		if seen[labelID] {
+			continue
+		}
+		seen[labelID] = true
+
+		if err := tx.Create(&models.TaskLabel{TaskID: taskID, LabelID: labelID}).Error; err != nil {
+			return fmt.Errorf("failed to add task label: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// setProjectLabels replaces the labels of a project
+func setProjectLabels(tx *gorm.DB, projectID, orgID uuid.UUID, labelIDs []uuid.UUID) error {
+	if err := tx.Where("project_id = ?", projectID).Delete(&models.ProjectLabel{}).Error; err != nil {
+		return fmt.Errorf("failed to remove project labels: %w", err)
+	}
+
+	if len(labelIDs) == 0 {
+		return nil
+	}
+
+	if err := checkOrgLabels(tx, orgID, labelIDs); err != nil {
+		return err
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	for _, labelID := range labelIDs {
+		if seen[labelID] {
+			continue
+		}
+		seen[labelID] = true
+
+		if err := tx.Create(&models.ProjectLabel{ProjectID: projectID, LabelID: labelID}).Error; err != nil {
+			return fmt.Errorf("failed to add project label: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// getLabelsByOwner loads the labels attached through a join table, keyed by
+// the owning task or project ID
+func getLabelsByOwner(db *gorm.DB, joinTable, ownerColumn string, ownerIDs []uuid.UUID) (map[uuid.UUID][]models.Label, error) {
+	type labelRow struct {
+		OwnerID uuid.UUID
+		models.Label
+	}
+
+	var rows []labelRow
+	err := db.Table(joinTable+" j").
+		Select("j."+ownerColumn+" AS owner_id, l.id, l.org_id, l.name, l.color, l.created_at, l.updated_at").
+		Joins("JOIN labels l ON l.id = j.label_id").
+		Where("j."+ownerColumn+" IN ?", ownerIDs).
+		Order("l.name ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get labels: %w", err)
+	}
+
+	labels := make(map[uuid.UUID][]models.Label)
+	for _, row := range rows {
+		labels[row.OwnerID] = append(labels[row.OwnerID], row.Label)
+	}
+
+	return labels, nil
+}
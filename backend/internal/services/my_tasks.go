@@ -0,0 +1,174 @@
+package services
+
+import (
+	"fmt"
+	"taskman-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// myTaskRow is an assigned task with its organization and project names
+type myTaskRow struct {
+	models.TaskQueryResult
+	OrgID       uuid.UUID
+	OrgName     string
+	ProjectName string
+}
+
+// GetMyTasks retrieves a page of the tasks assigned to a user across the
+// organizations they belong to, and how many match in total. "This week"
+// runs Monday to Sunday in the user's notification timezone.
+func (s *TaskService) GetMyTasks(userID uuid.UUID, filter *models.MyTasksFilter, now time.Time) ([]models.MyTaskResponse, int64, error) {
+	doneCondition := "EXISTS (SELECT 1 FROM workflow_columns wc WHERE wc.project_id = t.project_id AND wc.key = t.status AND wc.category = ?)"
+
+	query := s.db.Table("tasks t").
+		Joins("JOIN task_assignees ta ON ta.task_id = t.id AND ta.user_id = ?", userID).
+		Joins("JOIN projects p ON p.id = t.project_id AND p.deleted_at IS NULL").
+		Joins("JOIN organizations o ON o.id = p.org_id AND o.deleted_at IS NULL").
+		Joins("JOIN org_members om ON om.org_id = o.id AND om.user_id = ?", userID).
+		Where("t.deleted_at IS NULL")
+
+	if len(filter.OrgIDs) > 0 {
+		query = query.Where("o.id IN ?", filter.OrgIDs)
+	}
+
+	// Leave out organizations whose access policy the session does not meet
+	if failure, args := orgPolicyFailure(filter.Session); failure != "" {
+		query = query.Where("NOT ("+failure+")", args...)
+	}
+
+	if len(filter.Statuses) > 0 {
+		query = query.Where("t.status IN ?", filter.Statuses)
+	} else if !filter.IncludeDone {
+		query = query.Where("NOT "+doneCondition, models.WorkflowCategoryDone)
+	}
+
+	switch filter.Due {
+	case models.MyTasksDueOverdue:
+		query = query.Where("t.deadline < ? AND NOT "+doneCondition, now, models.WorkflowCategoryDone)
+	case models.MyTasksDueThisWeek:
+		weekStart, err := s.weekStart(userID, now)
+		if err != nil {
+			return nil, 0, err
+		}
+		query = query.Where("t.deadline >= ? AND t.deadline < ?", weekStart, weekStart.AddDate(0, 0, 7))
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count tasks: %w", err)
+	}
+
+	switch filter.GroupBy {
+	case models.MyTasksGroupByOrg:
+		query = query.Order("o.name ASC, o.id ASC")
+	case models.MyTasksGroupByProject:
+		query = query.Order("o.name ASC, o.id ASC, p.name ASC, p.id ASC")
+	}
+	if filter.Sort != nil {
+		query = query.Order(taskSortOrder(filter.Sort))
+	}
+
+	var rows []myTaskRow
+	err := query.
+		Select("t.id, t.project_id, t.parent_task_id, t.name, t.description, t.status, t.priority, t.story_points, t.estimate_minutes, t.created_by, t.deadline, t.rank, t.custom_fields, t.recurring_task_id, t.occurrence_at, t.created_at, t.updated_at, o.id AS org_id, o.name AS org_name, p.name AS project_name").
+		Order("t.deadline ASC NULLS LAST, t.created_at DESC, t.id ASC").
+		Limit(filter.PerPage).
+		Offset((filter.Page - 1) * filter.PerPage).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get tasks: %w", err)
+	}
+
+	responses := make([]models.TaskResponse, len(rows))
+	for i, row := range rows {
+		assignees, err := s.GetTaskAssignees(row.ID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get task assignees: %w", err)
+		}
+
+		responses[i] = models.TaskResponse{
+			ID:              row.ID,
+			ProjectID:       row.ProjectID,
+			ParentTaskID:    row.ParentTaskID,
+			Name:            row.Name,
+			Description:     row.Description,
+			Status:          row.Status,
+			Priority:        row.Priority,
+			StoryPoints:     row.StoryPoints,
+			EstimateMinutes: row.EstimateMinutes,
+			CreatedBy:       row.CreatedBy,
+			Deadline:        row.Deadline,
+			Rank:            row.Rank,
+			CustomFields:    row.CustomFields,
+			RecurringTaskID: row.RecurringTaskID,
+			OccurrenceAt:    row.OccurrenceAt,
+			CreatedAt:       row.CreatedAt,
+			UpdatedAt:       row.UpdatedAt,
+			Assignees:       assignees,
+		}
+	}
+
+	if err := s.enrichTaskResponses(responses); err != nil {
+		return nil, 0, err
+	}
+
+	tasks := make([]models.MyTaskResponse, len(rows))
+	for i, row := range rows {
+		tasks[i] = models.MyTaskResponse{
+			TaskResponse: responses[i],
+			OrgID:        row.OrgID,
+			OrgName:      row.OrgName,
+			ProjectName:  row.ProjectName,
+		}
+	}
+
+	return tasks, total, nil
+}
+
+// GroupMyTasks groups tasks ordered by GetMyTasks by organization, or by
+// project
+func GroupMyTasks(tasks []models.MyTaskResponse, groupBy string) []models.MyTaskGroup {
+	groups := []models.MyTaskGroup{}
+	for _, task := range tasks {
+		last := len(groups) - 1
+		sameGroup := last >= 0 && groups[last].OrgID == task.OrgID
+		if groupBy == models.MyTasksGroupByProject {
+			sameGroup = sameGroup && *groups[last].ProjectID == task.ProjectID
+		}
+
+		if !sameGroup {
+			group := models.MyTaskGroup{OrgID: task.OrgID, OrgName: task.OrgName}
+			if groupBy == models.MyTasksGroupByProject {
+				projectID := task.ProjectID
+				group.ProjectID = &projectID
+				group.ProjectName = task.ProjectName
+			}
+			groups = append(groups, group)
+			last++
+		}
+		groups[last].Tasks = append(groups[last].Tasks, task)
+	}
+
+	return groups
+}
+
+// weekStart returns the start of Monday of the current week in the user's
+// timezone
+func (s *TaskService) weekStart(userID uuid.UUID, now time.Time) (time.Time, error) {
+	var settings []models.NotificationSettings
+	if err := s.db.Where("user_id = ?", userID).Limit(1).Find(&settings).Error; err != nil {
+		return time.Time{}, fmt.Errorf("failed to get timezone: %w", err)
+	}
+
+	loc := time.UTC
+	if len(settings) == 1 {
+		loc = settingsLocation(&settings[0])
+	}
+
+	local := now.In(loc)
+	daysSinceMonday := (int(local.Weekday()) + 6) % 7
+	return time.Date(local.Year(), local.Month(), local.Day()-daysSinceMonday, 0, 0, 0, 0, loc), nil
+}
@@ -0,0 +1,430 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"taskman-backend/internal/mailer"
+	"taskman-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidPreferences is returned when notification preferences are rejected
+var ErrInvalidPreferences = errors.New("invalid notification preferences")
+
+// digestLookback bounds how far back a user's first digest reaches
+const digestLookback = 24 * time.Hour
+
+// GetPreferences retrieves a user's notification preferences, filling in the
+// default channel for types they have not set
+func (s *NotificationService) GetPreferences(userID uuid.UUID) (*models.NotificationPreferencesResponse, error) {
+	var preferences []models.NotificationPreference
+	if err := s.db.Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	settings, err := s.getSettings(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.NotificationPreferencesResponse{
+		Channels:             make(map[models.NotificationType]models.NotificationChannel, len(models.NotificationTypes)),
+		NotificationSettings: *settings,
+	}
+	for _, t := range models.NotificationTypes {
+		response.Channels[t] = models.DefaultNotificationChannel(t)
+	}
+	for _, preference := range preferences {
+		response.Channels[preference.Type] = preference.Channel
+	}
+
+	return response, nil
+}
+
+// UpdatePreferences updates a user's channels, quiet hours and digest settings
+func (s *NotificationService) UpdatePreferences(userID uuid.UUID, req *models.NotificationPreferencesUpdateRequest) (*models.NotificationPreferencesResponse, error) {
+	for t, channel := range req.Channels {
+		if !t.IsValid() {
+			return nil, fmt.Errorf("%w: unknown notification type %q", ErrInvalidPreferences, t)
+		}
+		if !channel.IsValid() {
+			return nil, fmt.Errorf("%w: channel must be in_app, email or none", ErrInvalidPreferences)
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for t, channel := range req.Channels {
+			preference := &models.NotificationPreference{UserID: userID, Type: t, Channel: channel}
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+				DoUpdates: clause.AssignmentColumns([]string{"channel", "updated_at"}),
+			}).Create(preference).Error
+			if err != nil {
+				return fmt.Errorf("failed to save notification preference: %w", err)
+			}
+		}
+
+		settings, err := s.getSettings(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if req.Timezone != nil {
+			settings.Timezone = *req.Timezone
+		}
+		if req.QuietHoursStart != nil {
+			settings.QuietHoursStart = *req.QuietHoursStart
+		}
+		if req.QuietHoursEnd != nil {
+			settings.QuietHoursEnd = *req.QuietHoursEnd
+		}
+		if req.DigestEnabled != nil {
+			settings.DigestEnabled = *req.DigestEnabled
+		}
+		if req.DigestHour != nil {
+			settings.DigestHour = *req.DigestHour
+		}
+
+		if err := validateNotificationSettings(settings); err != nil {
+			return err
+		}
+
+		if err := tx.Save(settings).Error; err != nil {
+			return fmt.Errorf("failed to save notification settings: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPreferences(userID)
+}
+
+// getSettings retrieves a user's notification settings, or the defaults when
+// they have none saved
+func (s *NotificationService) getSettings(db *gorm.DB, userID uuid.UUID) (*models.NotificationSettings, error) {
+	var settings models.NotificationSettings
+	err := db.Where("user_id = ?", userID).First(&settings).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &models.NotificationSettings{UserID: userID, Timezone: "UTC", DigestHour: 8}, nil
+		}
+		return nil, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// channelFor returns the channel a user receives a notification type on
+func (s *NotificationService) channelFor(userID uuid.UUID, t models.NotificationType) (models.NotificationChannel, error) {
+	var preferences []models.NotificationPreference
+	err := s.db.Where("user_id = ? AND type = ?", userID, t).Limit(1).Find(&preferences).Error
+	if err != nil {
+		return "", fmt.Errorf("failed to get notification preference: %w", err)
+	}
+	if len(preferences) == 0 {
+		return models.DefaultNotificationChannel(t), nil
+	}
+
+	return preferences[0].Channel, nil
+}
+
+// validateNotificationSettings checks the timezone, quiet hours and digest hour
+func validateNotificationSettings(settings *models.NotificationSettings) error {
+	if settings.Timezone == "" {
+		settings.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(settings.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidPreferences, settings.Timezone)
+	}
+
+	if (settings.QuietHoursStart == "") != (settings.QuietHoursEnd == "") {
+		return fmt.Errorf("%w: quiet hours need both a start and an end", ErrInvalidPreferences)
+	}
+	for _, clock := range []string{settings.QuietHoursStart, settings.QuietHoursEnd} {
+		if _, ok := parseClock(clock); clock != "" && !ok {
+			return fmt.Errorf("%w: quiet hours must be HH:MM", ErrInvalidPreferences)
+		}
+	}
+
+	if settings.DigestHour < 0 || settings.DigestHour > 23 {
+		return fmt.Errorf("%w: digest hour must be between 0 and 23", ErrInvalidPreferences)
+	}
+
+	return nil
+}
+
+// RunEmails sends notification emails that are no longer held back by quiet
+// hours and the daily digests that are due, and returns how many emails were
+// sent. Notifications are claimed before they are sent, so running on
+// several replicas does not send them twice; claims on emails that fail to
+// send are released so the next run retries them.
+func (s *NotificationService) RunEmails(now time.Time) (int, error) {
+	digests, err := s.sendDigests(now)
+	if err != nil {
+		return digests, err
+	}
+
+	pending, err := s.sendPending(now)
+	return digests + pending, err
+}
+
+// sendDigests emails each user whose digest is due their unread
+// notifications since the last digest
+func (s *NotificationService) sendDigests(now time.Time) (int, error) {
+	var candidates []models.NotificationSettings
+	if err := s.db.Where("digest_enabled = ?", true).Find(&candidates).Error; err != nil {
+		return 0, fmt.Errorf("failed to get digest settings: %w", err)
+	}
+
+	sent := 0
+	for i := range candidates {
+		settings := &candidates[i]
+		dayStart, due := digestDue(settings, now)
+		if !due {
+			continue
+		}
+
+		// Claim today's digest; another replica may have sent it already
+		result := s.db.Model(&models.NotificationSettings{}).
+			Where("user_id = ? AND (last_digest_at IS NULL OR last_digest_at < ?)", settings.UserID, dayStart).
+			Update("last_digest_at", now)
+		if result.Error != nil {
+			return sent, fmt.Errorf("failed to claim digest: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		since := now.Add(-digestLookback)
+		if settings.LastDigestAt != nil && settings.LastDigestAt.After(since) {
+			since = *settings.LastDigestAt
+		}
+
+		notifications, err := s.claimEmails(settings.UserID, now, "read_at IS NULL AND emailed_at IS NULL AND created_at > ?", since)
+		if err != nil {
+			return sent, err
+		}
+		if len(notifications) == 0 {
+			continue
+		}
+
+		subject := fmt.Sprintf("Your daily digest: %d unread notification(s)", len(notifications))
+		ok, err := s.email(settings.UserID, subject, notifications)
+		if err != nil {
+			log.Printf("Failed to send digest to user %s: %v", settings.UserID, err)
+			if err := s.releaseDigest(settings, now, notifications); err != nil {
+				return sent, err
+			}
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// sendPending emails notifications on the email channel to users who are
+// outside their quiet hours, batching each user's into one email
+func (s *NotificationService) sendPending(now time.Time) (int, error) {
+	var userIDs []uuid.UUID
+	err := s.db.Model(&models.Notification{}).
+		Where("email_pending = ? AND read_at IS NULL", true).
+		Distinct("user_id").
+		Pluck("user_id", &userIDs).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending notification emails: %w", err)
+	}
+
+	sent := 0
+	for _, userID := range userIDs {
+		settings, err := s.getSettings(s.db, userID)
+		if err != nil {
+			return sent, err
+		}
+		if inQuietHours(settings, now) {
+			continue
+		}
+
+		notifications, err := s.claimEmails(userID, now, "email_pending = ? AND read_at IS NULL", true)
+		if err != nil {
+			return sent, err
+		}
+		if len(notifications) == 0 {
+			continue
+		}
+
+		subject := notifications[0].Title
+		if len(notifications) > 1 {
+			subject = fmt.Sprintf("You have %d new notifications", len(notifications))
+		}
+		ok, err := s.email(userID, subject, notifications)
+		if err != nil {
+			log.Printf("Failed to email notifications to user %s: %v", userID, err)
+			if err := s.releaseEmails(now, notifications, true); err != nil {
+				return sent, err
+			}
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// claimEmails marks a user's notifications matching the condition as emailed
+// and returns them, oldest first
+func (s *NotificationService) claimEmails(userID uuid.UUID, now time.Time, condition string, args ...interface{}) ([]models.Notification, error) {
+	var notifications []models.Notification
+	err := s.db.Model(&notifications).
+		Clauses(clause.Returning{}).
+		Where("user_id = ?", userID).
+		Where(condition, args...).
+		Updates(map[string]interface{}{"email_pending": false, "emailed_at": now}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim notification emails: %w", err)
+	}
+
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].CreatedAt.Before(notifications[j].CreatedAt)
+	})
+	return notifications, nil
+}
+
+// releaseEmails undoes the claim on notifications that could not be emailed.
+// Pending notifications go back in the queue; the others become eligible
+// for the next digest again.
+func (s *NotificationService) releaseEmails(claimedAt time.Time, notifications []models.Notification, pending bool) error {
+	ids := make([]uuid.UUID, len(notifications))
+	for i, notification := range notifications {
+		ids[i] = notification.ID
+	}
+
+	updates := map[string]interface{}{"emailed_at": nil}
+	if pending {
+		updates["email_pending"] = true
+	}
+
+	err := s.db.Model(&models.Notification{}).
+		Where("id IN ? AND emailed_at = ?", ids, claimedAt).
+		Updates(updates).Error
+	if err != nil {
+		return fmt.Errorf("failed to release notification emails: %w", err)
+	}
+
+	return nil
+}
+
+// releaseDigest undoes the claim on a digest that could not be sent, so the
+// next run sends it
+func (s *NotificationService) releaseDigest(settings *models.NotificationSettings, claimedAt time.Time, notifications []models.Notification) error {
+	if err := s.releaseEmails(claimedAt, notifications, false); err != nil {
+		return err
+	}
+
+	err := s.db.Model(&models.NotificationSettings{}).
+		Where("user_id = ? AND last_digest_at = ?", settings.UserID, claimedAt).
+		Update("last_digest_at", settings.LastDigestAt).Error
+	if err != nil {
+		return fmt.Errorf("failed to release digest: %w", err)
+	}
+
+	return nil
+}
+
+// email sends notifications to a user in one message and reports whether it
+// went out. Service accounts have no mailbox and are skipped without an
+// error; the notifications stay in the inbox either way.
+func (s *NotificationService) email(userID uuid.UUID, subject string, notifications []models.Notification) (bool, error) {
+	var user models.User
+	if err := s.db.Select("email").Where("id = ?", userID).First(&user).Error; err != nil {
+		return false, fmt.Errorf("failed to get email address: %w", err)
+	}
+	if strings.HasSuffix(user.Email, "@"+serviceAccountEmailDomain) {
+		return false, nil
+	}
+
+	var body strings.Builder
+	if len(notifications) == 1 {
+		body.WriteString(notifications[0].Body)
+		body.WriteString("\n")
+	} else {
+		for _, notification := range notifications {
+			body.WriteString("- " + notification.Title + "\n")
+			if notification.Body != "" {
+				body.WriteString("  " + notification.Body + "\n")
+			}
+		}
+	}
+
+	err := s.mailer.Send(mailer.Message{To: user.Email, Subject: subject, Body: body.String()})
+	if err != nil {
+		return false, fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return true, nil
+}
+
+// inQuietHours reports whether now falls in the user's quiet hours
+func inQuietHours(settings *models.NotificationSettings, now time.Time) bool {
+	start, okStart := parseClock(settings.QuietHoursStart)
+	end, okEnd := parseClock(settings.QuietHoursEnd)
+	if !okStart || !okEnd || start == end {
+		return false
+	}
+
+	local := now.In(settingsLocation(settings))
+	minute := local.Hour()*60 + local.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	// Quiet hours wrap past midnight, e.g. 22:00 to 07:00
+	return minute >= start || minute < end
+}
+
+// digestDue reports whether the user's digest for today is due, along with
+// the start of their local day
+func digestDue(settings *models.NotificationSettings, now time.Time) (time.Time, bool) {
+	local := now.In(settingsLocation(settings))
+	dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+
+	if local.Hour() < settings.DigestHour || inQuietHours(settings, now) {
+		return dayStart, false
+	}
+	if settings.LastDigestAt != nil && !settings.LastDigestAt.Before(dayStart) {
+		return dayStart, false
+	}
+
+	return dayStart, true
+}
+
+// settingsLocation loads the user's timezone, falling back to UTC
+func settingsLocation(settings *models.NotificationSettings) *time.Location {
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// parseClock parses an "HH:MM" time into minutes after midnight
+func parseClock(clock string) (int, bool) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
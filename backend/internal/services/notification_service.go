@@ -0,0 +1,336 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"taskman-backend/internal/mailer"
+	"taskman-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrNotificationNotFound is returned when a notification does not exist or
+// belongs to another user
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// NotificationService handles notifications and how they reach each user
+type NotificationService struct {
+	db          *gorm.DB
+	mailer      mailer.Mailer
+	mu          sync.RWMutex
+	subscribers []func(*models.Notification)
+}
+
+// NewNotificationService creates a new notification service that emails
+// through m
+func NewNotificationService(db *gorm.DB, m mailer.Mailer) *NotificationService {
+	return &NotificationService{db: db, mailer: m}
+}
+
+// Subscribe registers fn to be called with every notification once it is
+// stored, for example to push it to the user's open connections
+func (s *NotificationService) Subscribe(fn func(*models.Notification)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// Notify delivers a notification on the channel its user chose for its type.
+// Unless the user turned the type off, it is stored and passed to
+// subscribers; on the email channel it is also queued for the email job.
+func (s *NotificationService) Notify(notification *models.Notification) error {
+	stored, err := s.store(s.db, notification)
+	if err != nil {
+		return err
+	}
+	if stored {
+		s.publish(notification)
+	}
+
+	return nil
+}
+
+// store saves a notification in tx unless its user turned the type off, and
+// reports whether it did. Callers publish stored notifications once tx is
+// committed.
+func (s *NotificationService) store(tx *gorm.DB, notification *models.Notification) (bool, error) {
+	channel, err := s.channelFor(notification.UserID, notification.Type)
+	if err != nil {
+		return false, err
+	}
+	if channel == models.NotificationChannelNone {
+		return false, nil
+	}
+	notification.EmailPending = channel == models.NotificationChannelEmail
+
+	if err := tx.Create(notification).Error; err != nil {
+		return false, fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	return true, nil
+}
+
+// publish passes a stored notification to subscribers
+func (s *NotificationService) publish(notification *models.Notification) {
+	s.mu.RLock()
+	subscribers := s.subscribers
+	s.mu.RUnlock()
+
+	for _, fn := range subscribers {
+		fn(notification)
+	}
+}
+
+// GetNotifications retrieves a page of a user's notifications, newest first,
+// along with the total matching the filter
+func (s *NotificationService) GetNotifications(userID uuid.UUID, filter *models.NotificationFilter) ([]models.Notification, int64, error) {
+	query := s.db.Model(&models.Notification{}).Where("user_id = ?", userID)
+	query = withOrgPolicy(query, filter.Session)
+	if filter.UnreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	var notifications []models.Notification
+	err := query.
+		Order("created_at DESC").
+		Limit(filter.PerPage).
+		Offset((filter.Page - 1) * filter.PerPage).
+		Find(&notifications).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get notifications: %w", err)
+	}
+
+	return notifications, total, nil
+}
+
+// GetUnreadCount counts a user's unread notifications
+func (s *NotificationService) GetUnreadCount(userID uuid.UUID, session models.OrgSession) (int64, error) {
+	var count int64
+	err := withOrgPolicy(s.db.Model(&models.Notification{}), session).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+
+	return count, nil
+}
+
+// withOrgPolicy leaves out the notifications of organizations whose access
+// policy the session does not meet
+func withOrgPolicy(query *gorm.DB, session models.OrgSession) *gorm.DB {
+	failure, args := orgPolicyFailure(session)
+	if failure == "" {
+		return query
+	}
+
+	return query.Where(`notifications.org_id IS NULL OR NOT EXISTS (
+		SELECT 1 FROM organizations o
+		JOIN org_members om ON om.org_id = o.id AND om.user_id = notifications.user_id
+		WHERE o.id = notifications.org_id AND (`+failure+`))`, args...)
+}
+
+// MarkRead marks one of a user's notifications as read. Marking a
+// notification that is already read is not an error.
+func (s *NotificationService) MarkRead(userID, notificationID uuid.UUID) (*models.Notification, error) {
+	var notification models.Notification
+	err := s.db.Where("id = ? AND user_id = ?", notificationID, userID).First(&notification).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotificationNotFound
+		}
+		return nil, fmt.Errorf("failed to get notification: %w", err)
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		if err := s.db.Model(&notification).Update("read_at", now).Error; err != nil {
+			return nil, fmt.Errorf("failed to mark notification as read: %w", err)
+		}
+		notification.ReadAt = &now
+	}
+
+	return &notification, nil
+}
+
+// MarkAllRead marks all of a user's unread notifications as read and returns
+// how many were changed
+func (s *NotificationService) MarkAllRead(userID uuid.UUID) (int64, error) {
+	result := s.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// TaskAssigneesChanged notifies users added to and removed from a task.
+// The user who made the change is not notified. Failures are logged rather
+// than returned so that notifications never break the change itself.
+func (s *NotificationService) TaskAssigneesChanged(task *models.Task, before, after []uuid.UUID, actorID uuid.UUID) {
+	added, removed := diffUserIDs(before, after)
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+
+	actor := s.actorName(actorID)
+	for _, userID := range added {
+		s.notifyTask(task, userID, actorID, models.NotificationTaskAssigned,
+			fmt.Sprintf("You were assigned to %q", task.Name),
+			fmt.Sprintf("%s assigned you to the task %q.", actor, task.Name))
+	}
+	for _, userID := range removed {
+		s.notifyTask(task, userID, actorID, models.NotificationTaskUnassigned,
+			fmt.Sprintf("You were unassigned from %q", task.Name),
+			fmt.Sprintf("%s removed you from the task %q.", actor, task.Name))
+	}
+}
+
+// TaskStatusChanged notifies a task's assignees and creator that it moved to
+// another status. The user who moved it is not notified.
+func (s *NotificationService) TaskStatusChanged(task *models.Task, oldStatus, newStatus models.TaskStatus, actorID uuid.UUID) {
+	if oldStatus == newStatus {
+		return
+	}
+
+	var userIDs []uuid.UUID
+	err := s.db.Model(&models.TaskAssignee{}).Where("task_id = ?", task.ID).Pluck("user_id", &userIDs).Error
+	if err != nil {
+		log.Printf("Failed to get assignees of task %s for notifications: %v", task.ID, err)
+		return
+	}
+
+	recipients := uniqueUserIDs(append(userIDs, task.CreatedBy))
+	if len(recipients) == 0 {
+		return
+	}
+
+	actor := s.actorName(actorID)
+	for _, userID := range recipients {
+		s.notifyTask(task, userID, actorID, models.NotificationTaskStatusChanged,
+			fmt.Sprintf("%q moved to %s", task.Name, newStatus),
+			fmt.Sprintf("%s moved the task %q from %s to %s.", actor, task.Name, oldStatus, newStatus))
+	}
+}
+
+// ProjectAssigneesChanged notifies users added to a project. The user who
+// made the change is not notified.
+func (s *NotificationService) ProjectAssigneesChanged(project *models.Project, before, after []uuid.UUID, actorID uuid.UUID) {
+	added, _ := diffUserIDs(before, after)
+	if len(added) == 0 {
+		return
+	}
+
+	actor := s.actorName(actorID)
+	orgID := project.OrgID
+	projectID := project.ID
+	for _, userID := range added {
+		if userID == actorID {
+			continue
+		}
+		s.notify(&models.Notification{
+			UserID:    userID,
+			OrgID:     &orgID,
+			Type:      models.NotificationProjectAssigned,
+			Title:     fmt.Sprintf("You were added to %q", project.Name),
+			Body:      fmt.Sprintf("%s added you to the project %q.", actor, project.Name),
+			ProjectID: &projectID,
+			ActorID:   &actorID,
+		})
+	}
+}
+
+// notifyTask sends a notification about a task unless the recipient is the
+// user who caused it
+func (s *NotificationService) notifyTask(task *models.Task, userID, actorID uuid.UUID, notificationType models.NotificationType, title, body string) {
+	if userID == actorID {
+		return
+	}
+
+	var orgID uuid.UUID
+	err := s.db.Model(&models.Project{}).Where("id = ?", task.ProjectID).Pluck("org_id", &orgID).Error
+	if err != nil {
+		log.Printf("Failed to get organization of task %s for notifications: %v", task.ID, err)
+		return
+	}
+
+	projectID := task.ProjectID
+	taskID := task.ID
+	s.notify(&models.Notification{
+		UserID:    userID,
+		OrgID:     &orgID,
+		Type:      notificationType,
+		Title:     title,
+		Body:      body,
+		ProjectID: &projectID,
+		TaskID:    &taskID,
+		ActorID:   &actorID,
+	})
+}
+
+// notify stores a notification, logging failures
+func (s *NotificationService) notify(notification *models.Notification) {
+	if err := s.Notify(notification); err != nil {
+		log.Printf("Failed to notify user %s of %s: %v", notification.UserID, notification.Type, err)
+	}
+}
+
+// actorName returns the display name of the user behind an event
+func (s *NotificationService) actorName(actorID uuid.UUID) string {
+	var user models.User
+	if err := s.db.Select("full_name").Where("id = ?", actorID).First(&user).Error; err != nil || user.FullName == "" {
+		return "Someone"
+	}
+	return user.FullName
+}
+
+// diffUserIDs returns the IDs in after that are not in before, and the IDs in
+// before that are not in after
+func diffUserIDs(before, after []uuid.UUID) ([]uuid.UUID, []uuid.UUID) {
+	beforeSet := make(map[uuid.UUID]bool, len(before))
+	for _, id := range before {
+		beforeSet[id] = true
+	}
+	afterSet := make(map[uuid.UUID]bool, len(after))
+	for _, id := range after {
+		afterSet[id] = true
+	}
+
+	var added, removed []uuid.UUID
+	for _, id := range uniqueUserIDs(after) {
+		if !beforeSet[id] {
+			added = append(added, id)
+		}
+	}
+	for _, id := range uniqueUserIDs(before) {
+		if !afterSet[id] {
+			removed = append(removed, id)
+		}
+	}
+
+	return added, removed
+}
+
+// uniqueUserIDs removes duplicate IDs, keeping the first occurrence of each
+func uniqueUserIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
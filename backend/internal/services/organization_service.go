@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
 	"taskman-backend/internal/models"
@@ -8,8 +9,13 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrLastAdmin is returned when a change would leave an organization without
+// an admin
+var ErrLastAdmin = errors.New("an organization must keep at least one admin")
+
 // OrganizationService handles organization-related operations
 type OrganizationService struct {
 	db *gorm.DB
@@ -30,21 +36,28 @@ func generateInviteCode() string {
 	return string(code)
 }
 
-// CreateOrganization creates a new organization
-func (s *OrganizationService) CreateOrganization(req *models.OrganizationCreateRequest, createdBy uuid.UUID) (*models.Organization, error) {
-	// Generate unique invite code
-	var inviteCode string
+// uniqueInviteCode generates an invite code not used by any organization
+func (s *OrganizationService) uniqueInviteCode() (string, error) {
 	for {
-		inviteCode = generateInviteCode()
+		inviteCode := generateInviteCode()
 		var exists bool
 		err := s.db.Model(&models.Organization{}).Select("1").Where("invite_code = ?", inviteCode).First(&exists).Error
 		if err != nil && err != gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("failed to check invite code: %w", err)
+			return "", fmt.Errorf("failed to check invite code: %w", err)
 		}
 		if err == gorm.ErrRecordNotFound {
-			break
+			return inviteCode, nil
 		}
 	}
+}
+
+// CreateOrganization creates a new organization
+func (s *OrganizationService) CreateOrganization(req *models.OrganizationCreateRequest, createdBy uuid.UUID) (*models.Organization, error) {
+	// Generate unique invite code
+	inviteCode, err := s.uniqueInviteCode()
+	if err != nil {
+		return nil, err
+	}
 
 	org := &models.Organization{
 		Name:          req.Name,
@@ -144,11 +157,38 @@ func (s *OrganizationService) AddMember(orgID, userID uuid.UUID, role string) er
 	return nil
 }
 
-// RemoveMember removes a user from an organization
+// RemoveMember removes a user from an organization. The last admin cannot
+// be removed.
 func (s *OrganizationService) RemoveMember(orgID, userID uuid.UUID) error {
-	result := s.db.Where("org_id = ? AND user_id = ?", orgID, userID).Delete(&models.OrgMember{})
-	if result.Error != nil {
-		return fmt.Errorf("failed to remove member: %w", result.Error)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkNotLastAdmin(tx, orgID, userID); err != nil {
+			return err
+		}
+
+		result := tx.Where("org_id = ? AND user_id = ?", orgID, userID).Delete(&models.OrgMember{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to remove member: %w", result.Error)
+		}
+
+		return nil
+	})
+}
+
+// checkNotLastAdmin refuses with ErrLastAdmin when userID is the only admin
+// of the organization. The admins stay locked until tx ends, so that two
+// admins cannot demote or remove each other at the same time.
+func checkNotLastAdmin(tx *gorm.DB, orgID, userID uuid.UUID) error {
+	var admins []models.OrgMember
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("org_id = ? AND role = ?", orgID, models.RoleAdmin).
+		Order("user_id").
+		Find(&admins).Error
+	if err != nil {
+		return fmt.Errorf("failed to check organization admins: %w", err)
+	}
+
+	if len(admins) == 1 && admins[0].UserID == userID {
+		return ErrLastAdmin
 	}
 
 	return nil
@@ -159,10 +199,10 @@ func (s *OrganizationService) GetUserOrganizations(userID uuid.UUID) ([]models.O
 	var orgs []models.OrganizationResponse
 
 	err := s.db.Table("organizations o").
-		Select("o.id, o.name, o.created_by, o.invite_code, o.code_expires_at, o.created_at, COUNT(om.user_id) as member_count, om.role").
+		Select("o.id, o.name, o.created_by, o.invite_code, o.code_expires_at, o.sso_only, o.require_admin_2fa, o.created_at, COUNT(om.user_id) as member_count, om.role").
 		Joins("LEFT JOIN org_members om ON o.id = om.org_id").
 		Where("om.user_id = ?", userID).
-		Group("o.id, o.name, o.created_by, o.invite_code, o.code_expires_at, o.created_at, om.role").
+		Group("o.id, o.name, o.created_by, o.invite_code, o.code_expires_at, o.sso_only, o.require_admin_2fa, o.created_at, om.role").
 		Order("o.created_at DESC").
 		Scan(&orgs).Error
 
@@ -187,6 +227,48 @@ func (s *OrganizationService) IsMember(orgID, userID uuid.UUID) (bool, string, e
 	return true, member.Role, nil
 }
 
+// AccessPolicy returns what an organization requires of a member's session:
+// single sign-on for everyone when it is SSO-only, and two-factor
+// authentication when the member is an admin and admins must use it
+func (s *OrganizationService) AccessPolicy(orgID, userID uuid.UUID) (*models.OrgAccessPolicy, error) {
+	var rows []struct {
+		SSOOnly         bool
+		RequireAdmin2FA bool `gorm:"column:require_admin_2fa"`
+		Role            string
+	}
+	err := s.db.Table("organizations o").
+		Select("o.sso_only, o.require_admin_2fa, om.role").
+		Joins("LEFT JOIN org_members om ON om.org_id = o.id AND om.user_id = ?", userID).
+		Where("o.id = ? AND o.deleted_at IS NULL", orgID).
+		Limit(1).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization policy: %w", err)
+	}
+
+	policy := &models.OrgAccessPolicy{}
+	if len(rows) > 0 {
+		policy.SSOOnly = rows[0].SSOOnly
+		policy.TwoFactor = rows[0].RequireAdmin2FA && rows[0].Role == models.RoleAdmin
+	}
+
+	return policy, nil
+}
+
+// orgPolicyFailure returns the condition, over organizations o and the
+// user's membership om, under which a session fails an organization's access
+// policy, or "" when it meets every policy. It mirrors AccessPolicy for
+// queries spanning organizations.
+func orgPolicyFailure(session models.OrgSession) (string, []interface{}) {
+	if session.Exempt || session.SSO {
+		return "", nil
+	}
+	if session.TwoFactor {
+		return "o.sso_only", nil
+	}
+	return "o.sso_only OR (o.require_admin_2fa AND om.role = ?)", []interface{}{models.RoleAdmin}
+}
+
 // GetOrganizationMembers retrieves all members of an organization
 func (s *OrganizationService) GetOrganizationMembers(orgID uuid.UUID) ([]models.UserResponse, error) {
 	var members []models.UserResponse
@@ -218,3 +300,46 @@ func (s *OrganizationService) UpdateOrganization(id uuid.UUID, updates map[strin
 
 	return nil
 }
+
+// UpdateMemberRole changes the role of an existing organization member. The
+// last admin cannot be demoted.
+func (s *OrganizationService) UpdateMemberRole(orgID, userID uuid.UUID, role string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if role != models.RoleAdmin {
+			if err := checkNotLastAdmin(tx, orgID, userID); err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&models.OrgMember{}).
+			Where("org_id = ? AND user_id = ?", orgID, userID).
+			Update("role", role)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update member role: %w", result.Error)
+		}
+
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("member not found")
+		}
+
+		return nil
+	})
+}
+
+// RegenerateInviteCode replaces an organization's invite code and resets its expiry
+func (s *OrganizationService) RegenerateInviteCode(id uuid.UUID) (*models.Organization, error) {
+	inviteCode, err := s.uniqueInviteCode()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.UpdateOrganization(id, map[string]interface{}{
+		"invite_code":     inviteCode,
+		"code_expires_at": time.Now().Add(7 * 24 * time.Hour), // 7 days
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetOrganizationByID(id)
+}
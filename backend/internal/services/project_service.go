@@ -51,12 +51,45 @@ func (s *ProjectService) CreateProject(req *models.ProjectCreateRequest, orgID,
 		}
 	}()
 
+	// Validate custom fields
+	values, err := resolveCustomFields(tx, orgID, models.CustomFieldEntityProject, nil, req.CustomFields)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	project.CustomFields = values
+
 	// Insert project
 	if err := tx.Create(project).Error; err != nil {
 		tx.Rollback()
 		return nil, fmt.Errorf("failed to create project: %w", err)
 	}
 
+	// New projects go to the top of their column
+	ranks, err := placeCards(tx, projectColumn(orgID, status), []uuid.UUID{project.ID}, models.CardPosition{})
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	project.Rank = ranks[project.ID]
+	if err := tx.Model(project).Update("rank", project.Rank).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to rank project: %w", err)
+	}
+
+	// Create default workflow
+	columns := models.DefaultWorkflowColumns(project.ID)
+	if err := tx.Create(&columns).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to create project workflow: %w", err)
+	}
+
+	transitions := models.DefaultWorkflowTransitions(project.ID)
+	if err := tx.Create(&transitions).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to create project workflow: %w", err)
+	}
+
 	// Add assignees (including creator)
 	assigneeIDs := append(req.AssigneeIDs, createdBy)
 
@@ -78,6 +111,12 @@ func (s *ProjectService) CreateProject(req *models.ProjectCreateRequest, orgID,
 		}
 	}
 
+	// Add labels
+	if err := setProjectLabels(tx, project.ID, orgID, req.LabelIDs); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
@@ -101,17 +140,21 @@ func (s *ProjectService) GetProjectByID(id uuid.UUID) (*models.Project, error) {
 }
 
 // GetProjectsByOrg retrieves all projects for an organization
-func (s *ProjectService) GetProjectsByOrg(orgID uuid.UUID, userID *uuid.UUID) ([]models.ProjectResponse, error) {
+func (s *ProjectService) GetProjectsByOrg(orgID uuid.UUID, userID *uuid.UUID, filter *models.ProjectFilter) ([]models.ProjectResponse, error) {
 	var queryResults []models.ProjectQueryResult
 
 	query := s.db.Table("projects p").
-		Select("p.id, p.org_id, p.name, p.description, p.status, p.created_by, p.deadline, p.created_at, p.updated_at, COUNT(t.id) as task_count").
+		Select("p.id, p.org_id, p.name, p.description, p.status, p.created_by, p.deadline, p.rank, p.custom_fields, p.created_at, p.updated_at, COUNT(t.id) as task_count").
 		Joins("LEFT JOIN tasks t ON p.id = t.project_id").
 		Where("p.org_id = ?", orgID).
-		Group("p.id, p.org_id, p.name, p.description, p.status, p.created_by, p.deadline, p.created_at, p.updated_at")
+		Group("p.id, p.org_id, p.name, p.description, p.status, p.created_by, p.deadline, p.rank, p.custom_fields, p.created_at, p.updated_at")
+
+	if filter != nil && len(filter.CustomFields) > 0 {
+		query = applyCustomFieldFilter(query, "p.custom_fields", filter.CustomFields)
+	}
 
-	// Order by creation date
-	query = query.Order("p.created_at DESC")
+	// Order by board position, newest first among unranked projects
+	query = query.Order("p.rank ASC, p.created_at DESC")
 
 	err := query.Scan(&queryResults).Error
 	if err != nil {
@@ -127,17 +170,39 @@ func (s *ProjectService) GetProjectsByOrg(orgID uuid.UUID, userID *uuid.UUID) ([
 		}
 
 		projects[i] = models.ProjectResponse{
-			ID:          result.ID,
-			OrgID:       result.OrgID,
-			Name:        result.Name,
-			Description: result.Description,
-			Status:      result.Status,
-			CreatedBy:   result.CreatedBy,
-			Deadline:    result.Deadline,
-			CreatedAt:   result.CreatedAt,
-			UpdatedAt:   result.UpdatedAt,
-			Assignees:   assignees,
-			TaskCount:   result.TaskCount,
+			ID:           result.ID,
+			OrgID:        result.OrgID,
+			Name:         result.Name,
+			Description:  result.Description,
+			Status:       result.Status,
+			CreatedBy:    result.CreatedBy,
+			Deadline:     result.Deadline,
+			Rank:         result.Rank,
+			CustomFields: result.CustomFields,
+			CreatedAt:    result.CreatedAt,
+			UpdatedAt:    result.UpdatedAt,
+			Assignees:    assignees,
+			TaskCount:    result.TaskCount,
+			Overdue:      models.IsOverdue(result.Deadline, result.Status == models.ProjectStatusFinished, time.Now()),
+		}
+	}
+
+	if len(projects) > 0 {
+		projectIDs := make([]uuid.UUID, len(projects))
+		for i := range projects {
+			projectIDs[i] = projects[i].ID
+		}
+
+		labels, err := getLabelsByOwner(s.db, "project_labels", "project_id", projectIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range projects {
+			projects[i].Labels = labels[projects[i].ID]
+			if projects[i].Labels == nil {
+				projects[i].Labels = []models.Label{}
+			}
 		}
 	}
 
@@ -169,15 +234,62 @@ func (s *ProjectService) UpdateProject(id uuid.UUID, req *models.ProjectUpdateRe
 		updates["deadline"] = *req.Deadline
 	}
 
-	if len(updates) == 0 {
+	if req.CustomFields != nil {
+		var current models.Project
+		if err := tx.Select("id, org_id, custom_fields").Where("id = ?", id).First(&current).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to get project: %w", err)
+		}
+		values, err := resolveCustomFields(tx, current.OrgID, models.CustomFieldEntityProject, current.CustomFields, req.CustomFields)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		updates["custom_fields"] = values
+	}
+
+	if len(updates) == 0 && req.LabelIDs == nil {
+		tx.Rollback()
 		return nil, fmt.Errorf("no fields to update")
 	}
 
+	// Projects changing column go to the top of the new one
+	if req.Status != nil {
+		var current models.Project
+		if err := tx.Where("id = ?", id).First(&current).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to get project: %w", err)
+		}
+		if current.Status != *req.Status {
+			ranks, err := placeCards(tx, projectColumn(current.OrgID, *req.Status), []uuid.UUID{id}, models.CardPosition{})
+			if err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			updates["rank"] = ranks[id]
+		}
+	}
+
 	// Update project
 	var project models.Project
-	if err := tx.Model(&project).Where("id = ?", id).Updates(updates).Error; err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("failed to update project: %w", err)
+	if len(updates) > 0 {
+		if err := tx.Model(&project).Where("id = ?", id).Updates(updates).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to update project: %w", err)
+		}
+	}
+
+	// Replace labels if provided
+	if req.LabelIDs != nil {
+		var current models.Project
+		if err := tx.Select("id, org_id").Where("id = ?", id).First(&current).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to get project: %w", err)
+		}
+		if err := setProjectLabels(tx, id, current.OrgID, req.LabelIDs); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
 	}
 
 	// Update assignees if provided
@@ -244,6 +356,20 @@ func (s *ProjectService) GetProjectAssignees(projectID uuid.UUID) ([]models.User
 	return assignees, nil
 }
 
+// GetProjectLabels retrieves the labels of a project
+func (s *ProjectService) GetProjectLabels(projectID uuid.UUID) ([]models.Label, error) {
+	labels, err := getLabelsByOwner(s.db, "project_labels", "project_id", []uuid.UUID{projectID})
+	if err != nil {
+		return nil, err
+	}
+
+	if labels[projectID] == nil {
+		return []models.Label{}, nil
+	}
+
+	return labels[projectID], nil
+}
+
 // IsProjectAssignee checks if a user is assigned to a project
 func (s *ProjectService) IsProjectAssignee(projectID, userID uuid.UUID) (bool, error) {
 	var count int64
@@ -258,22 +384,57 @@ func (s *ProjectService) IsProjectAssignee(projectID, userID uuid.UUID) (bool, e
 	return count > 0, nil
 }
 
-// MoveProject moves a project to a different status
-func (s *ProjectService) MoveProject(id uuid.UUID, status models.ProjectStatus) error {
-	result := s.db.Model(&models.Project{}).Where("id = ?", id).Update("status", status)
-	if result.Error != nil {
-		return fmt.Errorf("failed to move project: %w", result.Error)
+// MoveProject moves a project to a position in a different status and
+// returns its new rank. Without a position the project goes to the top of a
+// new column or keeps its place in the same one.
+func (s *ProjectService) MoveProject(id uuid.UUID, status models.ProjectStatus, position models.CardPosition) (string, error) {
+	project, err := s.GetProjectByID(id)
+	if err != nil {
+		return "", err
 	}
 
-	return nil
-}
+	if project.Status == status && !position.IsSet() {
+		return project.Rank, nil
+	}
 
-// BulkMoveProjects moves multiple projects to a different status
-func (s *ProjectService) BulkMoveProjects(projectIDs []uuid.UUID, status models.ProjectStatus) error {
-	result := s.db.Model(&models.Project{}).Where("id IN ?", projectIDs).Update("status", status)
-	if result.Error != nil {
-		return fmt.Errorf("failed to bulk move projects: %w", result.Error)
+	var newRank string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		ranks, err := placeCards(tx, projectColumn(project.OrgID, status), []uuid.UUID{id}, position)
+		if err != nil {
+			return err
+		}
+		newRank = ranks[id]
+
+		result := tx.Model(&models.Project{}).Where("id = ?", id).Updates(map[string]interface{}{"status": status, "rank": newRank})
+		if result.Error != nil {
+			return fmt.Errorf("failed to move project: %w", result.Error)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	return newRank, nil
+}
+
+// BulkMoveProjects moves multiple projects of an organization to a different
+// status, placing them together at the position in the order given
+func (s *ProjectService) BulkMoveProjects(orgID uuid.UUID, projectIDs []uuid.UUID, status models.ProjectStatus, position models.CardPosition) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		ranks, err := placeCards(tx, projectColumn(orgID, status), projectIDs, position)
+		if err != nil {
+			return err
+		}
+
+		for id, r := range ranks {
+			result := tx.Model(&models.Project{}).Where("id = ?", id).Updates(map[string]interface{}{"status": status, "rank": r})
+			if result.Error != nil {
+				return fmt.Errorf("failed to bulk move projects: %w", result.Error)
+			}
+		}
+
+		return nil
+	})
 }
@@ -0,0 +1,578 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/rank"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidProjectCopy is returned when a clone or template request is
+// rejected
+var ErrInvalidProjectCopy = errors.New("invalid project copy")
+
+// CloneProject creates a project from another one in the same organization,
+// copying its setup and, as requested, its tasks, assignees and labels
+func (s *ProjectService) CloneProject(sourceID uuid.UUID, req *models.ProjectCloneRequest, createdBy uuid.UUID) (*models.Project, error) {
+	var project *models.Project
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var source models.Project
+		if err := tx.Where("id = ?", sourceID).First(&source).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("project not found")
+			}
+			return fmt.Errorf("failed to get project: %w", err)
+		}
+
+		snapshot, err := snapshotProject(tx, &source)
+		if err != nil {
+			return err
+		}
+
+		// Without a new start date the copy keeps the original deadlines
+		start := projectStart(&source)
+		if req.StartDate != nil {
+			start = *req.StartDate
+		}
+
+		project, err = createFromSnapshot(tx, snapshot, source.OrgID, createdBy, req, start)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// GetTemplates retrieves the project templates of an organization by name
+func (s *ProjectService) GetTemplates(orgID uuid.UUID) ([]models.ProjectTemplate, error) {
+	var templates []models.ProjectTemplate
+	err := s.db.Where("org_id = ?", orgID).Order("name ASC").Find(&templates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+// GetTemplateByID retrieves a project template by ID
+func (s *ProjectService) GetTemplateByID(id uuid.UUID) (*models.ProjectTemplate, error) {
+	var template models.ProjectTemplate
+	err := s.db.Where("id = ?", id).First(&template).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("template not found")
+		}
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	return &template, nil
+}
+
+// CreateTemplate saves a snapshot of a project as a template of its
+// organization
+func (s *ProjectService) CreateTemplate(projectID uuid.UUID, req *models.ProjectTemplateCreateRequest, createdBy uuid.UUID) (*models.ProjectTemplate, error) {
+	name := strings.TrimSpace(req.Name)
+	if len(name) < 2 || len(name) > 100 {
+		return nil, fmt.Errorf("%w: name must be 2-100 characters", ErrInvalidProjectCopy)
+	}
+	if len(req.Description) > 500 {
+		return nil, fmt.Errorf("%w: description must be at most 500 characters", ErrInvalidProjectCopy)
+	}
+
+	var template *models.ProjectTemplate
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var project models.Project
+		if err := tx.Where("id = ?", projectID).First(&project).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("project not found")
+			}
+			return fmt.Errorf("failed to get project: %w", err)
+		}
+
+		snapshot, err := snapshotProject(tx, &project)
+		if err != nil {
+			return err
+		}
+
+		template = &models.ProjectTemplate{
+			OrgID:       project.OrgID,
+			Name:        name,
+			Description: req.Description,
+			CreatedBy:   createdBy,
+			Snapshot:    *snapshot,
+		}
+		if err := tx.Create(template).Error; err != nil {
+			return fmt.Errorf("failed to create template: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// InstantiateTemplate creates a project from a template. Labels, assignees
+// and custom fields that no longer exist in the organization are skipped.
+func (s *ProjectService) InstantiateTemplate(templateID uuid.UUID, req *models.ProjectCloneRequest, createdBy uuid.UUID) (*models.Project, error) {
+	template, err := s.GetTemplateByID(templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if req.StartDate != nil {
+		start = *req.StartDate
+	}
+
+	var project *models.Project
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		project, err = createFromSnapshot(tx, &template.Snapshot, template.OrgID, createdBy, req, start)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// DeleteTemplate deletes a project template
+func (s *ProjectService) DeleteTemplate(id uuid.UUID) error {
+	if err := s.db.Where("id = ?", id).Delete(&models.ProjectTemplate{}).Error; err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+
+	return nil
+}
+
+// projectStart returns the start of the day (UTC) a project was created,
+// which snapshot deadlines are relative to
+func projectStart(project *models.Project) time.Time {
+	created := project.CreatedAt.UTC()
+	return time.Date(created.Year(), created.Month(), created.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// deadlineOffset returns how many minutes after start a deadline falls
+func deadlineOffset(deadline *time.Time, start time.Time) *int {
+	if deadline == nil {
+		return nil
+	}
+	minutes := int(deadline.Sub(start) / time.Minute)
+	return &minutes
+}
+
+// shiftDeadline returns the deadline offset minutes after start
+func shiftDeadline(offset *int, start time.Time) *time.Time {
+	if offset == nil {
+		return nil
+	}
+	deadline := start.Add(time.Duration(*offset) * time.Minute)
+	return &deadline
+}
+
+// snapshotProject captures a project's setup and its live tasks in board
+// order
+func snapshotProject(tx *gorm.DB, project *models.Project) (*models.ProjectSnapshot, error) {
+	start := projectStart(project)
+	snapshot := &models.ProjectSnapshot{
+		Description:           project.Description,
+		DeadlineOffsetMinutes: deadlineOffset(project.Deadline, start),
+		CustomFields:          project.CustomFields,
+		Tasks:                 []models.TaskSnapshot{},
+	}
+
+	if err := tx.Model(&models.ProjectLabel{}).Where("project_id = ?", project.ID).Pluck("label_id", &snapshot.LabelIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get project labels: %w", err)
+	}
+	if err := tx.Model(&models.ProjectAssignee{}).Where("project_id = ?", project.ID).Pluck("user_id", &snapshot.AssigneeIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get project assignees: %w", err)
+	}
+
+	var columns []models.WorkflowColumn
+	if err := tx.Where("project_id = ?", project.ID).Order("position ASC").Find(&columns).Error; err != nil {
+		return nil, fmt.Errorf("failed to get workflow columns: %w", err)
+	}
+	for _, column := range columns {
+		snapshot.Columns = append(snapshot.Columns, models.WorkflowColumnInput{
+			Key:              column.Key,
+			Name:             column.Name,
+			Category:         column.Category,
+			WIPLimit:         column.WIPLimit,
+			AssigneeWIPLimit: column.AssigneeWIPLimit,
+		})
+	}
+
+	var transitions []models.WorkflowTransition
+	if err := tx.Where("project_id = ?", project.ID).Find(&transitions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get workflow transitions: %w", err)
+	}
+	for _, transition := range transitions {
+		snapshot.Transitions = append(snapshot.Transitions, models.WorkflowTransitionInput{
+			From:      transition.FromKey,
+			To:        transition.ToKey,
+			AdminOnly: transition.AdminOnly,
+		})
+	}
+
+	var tasks []models.Task
+	err := tx.Table("tasks t").
+		Select("t.*").
+		Joins("LEFT JOIN workflow_columns wc ON wc.project_id = t.project_id AND wc.key = t.status").
+		Where("t.project_id = ? AND t.deleted_at IS NULL", project.ID).
+		Order("wc.position ASC NULLS LAST, t.rank ASC, t.created_at DESC").
+		Scan(&tasks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+	if len(tasks) == 0 {
+		return snapshot, nil
+	}
+
+	refs := make(map[uuid.UUID]int, len(tasks))
+	taskIDs := make([]uuid.UUID, len(tasks))
+	for i, task := range tasks {
+		refs[task.ID] = i
+		taskIDs[i] = task.ID
+	}
+
+	var labels []models.TaskLabel
+	if err := tx.Where("task_id IN ?", taskIDs).Find(&labels).Error; err != nil {
+		return nil, fmt.Errorf("failed to get task labels: %w", err)
+	}
+	var assignees []models.TaskAssignee
+	if err := tx.Where("task_id IN ?", taskIDs).Find(&assignees).Error; err != nil {
+		return nil, fmt.Errorf("failed to get task assignees: %w", err)
+	}
+	var items []models.ChecklistItem
+	if err := tx.Where("task_id IN ?", taskIDs).Order("position ASC").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to get checklist items: %w", err)
+	}
+	var dependencies []models.TaskDependency
+	if err := tx.Where("task_id IN ? AND blocked_by_task_id IN ?", taskIDs, taskIDs).Find(&dependencies).Error; err != nil {
+		return nil, fmt.Errorf("failed to get task dependencies: %w", err)
+	}
+
+	for i, task := range tasks {
+		taskSnapshot := models.TaskSnapshot{
+			Ref:                   i,
+			Name:                  task.Name,
+			Description:           task.Description,
+			Priority:              task.Priority,
+			StoryPoints:           task.StoryPoints,
+			EstimateMinutes:       task.EstimateMinutes,
+			DeadlineOffsetMinutes: deadlineOffset(task.Deadline, start),
+			CustomFields:          task.CustomFields,
+		}
+		// Subtasks of deleted tasks become top-level tasks
+		if task.ParentTaskID != nil {
+			if parentRef, ok := refs[*task.ParentTaskID]; ok {
+				taskSnapshot.ParentRef = &parentRef
+			}
+		}
+		snapshot.Tasks = append(snapshot.Tasks, taskSnapshot)
+	}
+
+	for _, label := range labels {
+		task := &snapshot.Tasks[refs[label.TaskID]]
+		task.LabelIDs = append(task.LabelIDs, label.LabelID)
+	}
+	for _, assignee := range assignees {
+		task := &snapshot.Tasks[refs[assignee.TaskID]]
+		task.AssigneeIDs = append(task.AssigneeIDs, assignee.UserID)
+	}
+	for _, item := range items {
+		task := &snapshot.Tasks[refs[item.TaskID]]
+		task.Checklist = append(task.Checklist, item.Text)
+	}
+	for _, dependency := range dependencies {
+		task := &snapshot.Tasks[refs[dependency.TaskID]]
+		task.BlockedByRefs = append(task.BlockedByRefs, refs[dependency.BlockedByTaskID])
+	}
+
+	return snapshot, nil
+}
+
+// createFromSnapshot creates a project in an organization from a snapshot,
+// with deadlines placed relative to start. The project starts as an idea
+// with the creator as an assignee; other assignees must still be members of
+// the organization, and labels and custom fields must still exist. Custom
+// field values are checked against the fields as they are now.
+func createFromSnapshot(tx *gorm.DB, snapshot *models.ProjectSnapshot, orgID, createdBy uuid.UUID, req *models.ProjectCloneRequest, start time.Time) (*models.Project, error) {
+	name := strings.TrimSpace(req.Name)
+	if len(name) < 2 || len(name) > 100 {
+		return nil, fmt.Errorf("%w: name must be 2-100 characters", ErrInvalidProjectCopy)
+	}
+
+	projectFields, err := customFieldKeys(tx, orgID, models.CustomFieldEntityProject)
+	if err != nil {
+		return nil, err
+	}
+	taskFields, err := customFieldKeys(tx, orgID, models.CustomFieldEntityTask)
+	if err != nil {
+		return nil, err
+	}
+
+	var memberIDs []uuid.UUID
+	if err := tx.Model(&models.OrgMember{}).Where("org_id = ?", orgID).Pluck("user_id", &memberIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get members: %w", err)
+	}
+	members := uuidSet(memberIDs)
+
+	var labelIDs []uuid.UUID
+	if err := tx.Model(&models.Label{}).Where("org_id = ?", orgID).Pluck("id", &labelIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get labels: %w", err)
+	}
+	labels := uuidSet(labelIDs)
+
+	projectValues, err := copyCustomFields(tx, orgID, models.CustomFieldEntityProject, snapshot.CustomFields, projectFields)
+	if err != nil {
+		return nil, err
+	}
+
+	project := &models.Project{
+		OrgID:        orgID,
+		Name:         name,
+		Description:  snapshot.Description,
+		Status:       models.ProjectStatusIdea,
+		CreatedBy:    createdBy,
+		Deadline:     shiftDeadline(snapshot.DeadlineOffsetMinutes, start),
+		CustomFields: projectValues,
+	}
+	if err := tx.Create(project).Error; err != nil {
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	// New projects go to the top of their column
+	ranks, err := placeCards(tx, projectColumn(orgID, project.Status), []uuid.UUID{project.ID}, models.CardPosition{})
+	if err != nil {
+		return nil, err
+	}
+	project.Rank = ranks[project.ID]
+	if err := tx.Model(project).Update("rank", project.Rank).Error; err != nil {
+		return nil, fmt.Errorf("failed to rank project: %w", err)
+	}
+
+	// Copy the workflow
+	columns := models.DefaultWorkflowColumns(project.ID)
+	if len(snapshot.Columns) > 0 {
+		columns = make([]models.WorkflowColumn, len(snapshot.Columns))
+		for i, column := range snapshot.Columns {
+			columns[i] = models.WorkflowColumn{
+				ProjectID:        project.ID,
+				Key:              column.Key,
+				Name:             column.Name,
+				Category:         column.Category,
+				Position:         i,
+				WIPLimit:         column.WIPLimit,
+				AssigneeWIPLimit: column.AssigneeWIPLimit,
+			}
+		}
+	}
+	if err := tx.Create(&columns).Error; err != nil {
+		return nil, fmt.Errorf("failed to create project workflow: %w", err)
+	}
+
+	if len(snapshot.Transitions) > 0 {
+		transitions := make([]models.WorkflowTransition, len(snapshot.Transitions))
+		for i, transition := range snapshot.Transitions {
+			transitions[i] = models.WorkflowTransition{
+				ProjectID: project.ID,
+				FromKey:   transition.From,
+				ToKey:     transition.To,
+				AdminOnly: transition.AdminOnly,
+			}
+		}
+		if err := tx.Create(&transitions).Error; err != nil {
+			return nil, fmt.Errorf("failed to create project workflow: %w", err)
+		}
+	}
+
+	// Add assignees (including creator)
+	assigneeIDs := []uuid.UUID{createdBy}
+	if req.IncludeAssignees {
+		assigneeIDs = append(assigneeIDs, snapshot.AssigneeIDs...)
+	}
+	seen := make(map[uuid.UUID]bool)
+	for _, assigneeID := range assigneeIDs {
+		if seen[assigneeID] || (assigneeID != createdBy && !members[assigneeID]) {
+			continue
+		}
+		seen[assigneeID] = true
+
+		assignee := &models.ProjectAssignee{
+			ProjectID:  project.ID,
+			UserID:     assigneeID,
+			AssignedAt: time.Now(),
+		}
+		if err := tx.Create(assignee).Error; err != nil {
+			return nil, fmt.Errorf("failed to add project assignee: %w", err)
+		}
+	}
+
+	if req.IncludeLabels {
+		if err := setProjectLabels(tx, project.ID, orgID, filterUUIDs(snapshot.LabelIDs, labels)); err != nil {
+			return nil, err
+		}
+	}
+
+	if !req.IncludeTasks || len(snapshot.Tasks) == 0 {
+		return project, nil
+	}
+
+	// Tasks start in the first to-do column, keeping their board order
+	status := columns[0].Key
+	for _, column := range columns {
+		if column.Category == models.WorkflowCategoryTodo {
+			status = column.Key
+			break
+		}
+	}
+	taskRanks := rank.Spread(len(snapshot.Tasks))
+
+	tasks := make([]*models.Task, len(snapshot.Tasks))
+	for i, taskSnapshot := range snapshot.Tasks {
+		priority := taskSnapshot.Priority
+		if !priority.IsValid() {
+			priority = models.TaskPriorityMedium
+		}
+
+		taskValues, err := copyCustomFields(tx, orgID, models.CustomFieldEntityTask, taskSnapshot.CustomFields, taskFields)
+		if err != nil {
+			return nil, fmt.Errorf("task %q: %w", taskSnapshot.Name, err)
+		}
+
+		task := &models.Task{
+			ProjectID:       project.ID,
+			Name:            taskSnapshot.Name,
+			Description:     taskSnapshot.Description,
+			Status:          status,
+			Priority:        priority,
+			StoryPoints:     taskSnapshot.StoryPoints,
+			EstimateMinutes: taskSnapshot.EstimateMinutes,
+			CreatedBy:       createdBy,
+			Deadline:        shiftDeadline(taskSnapshot.DeadlineOffsetMinutes, start),
+			Rank:            taskRanks[i],
+			CustomFields:    taskValues,
+		}
+		if err := tx.Create(task).Error; err != nil {
+			return nil, fmt.Errorf("failed to create task: %w", err)
+		}
+		tasks[i] = task
+
+		if req.IncludeLabels {
+			if err := setTaskLabels(tx, task.ID, orgID, filterUUIDs(taskSnapshot.LabelIDs, labels)); err != nil {
+				return nil, err
+			}
+		}
+
+		if req.IncludeAssignees {
+			for _, assigneeID := range filterUUIDs(taskSnapshot.AssigneeIDs, members) {
+				assignee := &models.TaskAssignee{
+					TaskID:     task.ID,
+					UserID:     assigneeID,
+					AssignedAt: time.Now(),
+				}
+				if err := tx.Create(assignee).Error; err != nil {
+					return nil, fmt.Errorf("failed to add task assignee: %w", err)
+				}
+			}
+		}
+
+		for position, text := range taskSnapshot.Checklist {
+			item := &models.ChecklistItem{
+				TaskID:   task.ID,
+				Text:     text,
+				Position: position,
+			}
+			if err := tx.Create(item).Error; err != nil {
+				return nil, fmt.Errorf("failed to create checklist item: %w", err)
+			}
+		}
+	}
+
+	// Link subtasks and blockers once every task exists
+	for i, taskSnapshot := range snapshot.Tasks {
+		if ref := taskSnapshot.ParentRef; ref != nil && *ref >= 0 && *ref < len(tasks) && *ref != i {
+			if err := tx.Model(tasks[i]).Update("parent_task_id", tasks[*ref].ID).Error; err != nil {
+				return nil, fmt.Errorf("failed to set parent task: %w", err)
+			}
+		}
+
+		for _, ref := range taskSnapshot.BlockedByRefs {
+			if ref < 0 || ref >= len(tasks) || ref == i {
+				continue
+			}
+			dependency := &models.TaskDependency{
+				TaskID:          tasks[i].ID,
+				BlockedByTaskID: tasks[ref].ID,
+				CreatedBy:       createdBy,
+			}
+			if err := tx.Create(dependency).Error; err != nil {
+				return nil, fmt.Errorf("failed to create task dependency: %w", err)
+			}
+		}
+	}
+
+	return project, nil
+}
+
+// customFieldKeys retrieves the keys of an organization's custom fields for
+// tasks or projects
+func customFieldKeys(tx *gorm.DB, orgID uuid.UUID, entity models.CustomFieldEntity) (map[string]bool, error) {
+	var keys []string
+	err := tx.Model(&models.CustomField{}).Where("org_id = ? AND entity = ?", orgID, entity).Pluck("key", &keys).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom fields: %w", err)
+	}
+
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+	return set, nil
+}
+
+// copyCustomFields checks copied custom field values the same way as values
+// set directly, after dropping those of fields that no longer exist
+func copyCustomFields(tx *gorm.DB, orgID uuid.UUID, entity models.CustomFieldEntity, values models.CustomFieldValues, keys map[string]bool) (models.CustomFieldValues, error) {
+	input := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if keys[key] {
+			input[key] = value
+		}
+	}
+	return resolveCustomFields(tx, orgID, entity, nil, input)
+}
+
+// uuidSet builds a set from a list of IDs
+func uuidSet(ids []uuid.UUID) map[uuid.UUID]bool {
+	set := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// filterUUIDs keeps the IDs in allowed, dropping duplicates
+func filterUUIDs(ids []uuid.UUID, allowed map[uuid.UUID]bool) []uuid.UUID {
+	var filtered []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range ids {
+		if allowed[id] && !seen[id] {
+			seen[id] = true
+			filtered = append(filtered, id)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/rank"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidPosition is returned when a card position refers to a card that
+// is not in the target column, or to cards in the wrong order
+var ErrInvalidPosition = errors.New("invalid card position")
+
+// rankColumn identifies the cards of one board column: rows of table that
+// match where and are not soft-deleted. Cards are placed while holding a lock
+// on the row of lockTable matching lockWhere.
+type rankColumn struct {
+	table string
+	where string
+	args  []interface{}
+
+	lockTable string
+	lockWhere string
+	lockArgs  []interface{}
+}
+
+// taskColumn returns the board column holding a project's tasks in a status,
+// locked through its workflow column
+func taskColumn(projectID uuid.UUID, status models.TaskStatus) rankColumn {
+	return rankColumn{
+		table: "tasks", where: "project_id = ? AND status = ?", args: []interface{}{projectID, status},
+		lockTable: "workflow_columns", lockWhere: "project_id = ? AND key = ?", lockArgs: []interface{}{projectID, status},
+	}
+}
+
+// projectColumn returns the board column holding an organization's projects
+// in a status. Project statuses have no rows of their own, so the column is
+// locked through the organization.
+func projectColumn(orgID uuid.UUID, status models.ProjectStatus) rankColumn {
+	return rankColumn{
+		table: "projects", where: "org_id = ? AND status = ?", args: []interface{}{orgID, status},
+		lockTable: "organizations", lockWhere: "id = ?", lockArgs: []interface{}{orgID},
+	}
+}
+
+// lock locks the row standing for the column until tx ends, so that
+// concurrent placements read the neighbours one after another. It uses
+// NO KEY UPDATE, which unlike UPDATE does not hold up inserts referencing the
+// row.
+func (col rankColumn) lock(tx *gorm.DB) error {
+	var ids []uuid.UUID
+	err := tx.Table(col.lockTable).
+		Clauses(clause.Locking{Strength: "NO KEY UPDATE"}).
+		Where(col.lockWhere, col.lockArgs...).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return fmt.Errorf("failed to lock board column: %w", err)
+	}
+	return nil
+}
+
+// query starts a query over the cards of the column, leaving out ids
+func (col rankColumn) query(tx *gorm.DB, ids []uuid.UUID) *gorm.DB {
+	query := tx.Table(col.table).Where(col.where, col.args...).Where("deleted_at IS NULL")
+	if len(ids) > 0 {
+		query = query.Where("id NOT IN ?", ids)
+	}
+	return query
+}
+
+// placeCards computes ranks that put ids, in order, at position in the
+// column. Cards being placed are ignored when looking up neighbours, so they
+// may already be in the column. When the ranks around position are too dense
+// the rest of the column is rebalanced first. The column stays locked until
+// tx ends.
+func placeCards(tx *gorm.DB, col rankColumn, ids []uuid.UUID, position models.CardPosition) (map[uuid.UUID]string, error) {
+	if err := col.lock(tx); err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		prev, next, err := col.neighbours(tx, ids, position)
+		if err != nil {
+			return nil, err
+		}
+
+		ranks := make(map[uuid.UUID]string, len(ids))
+		current := prev
+		for _, id := range ids {
+			r, ok := rank.Between(current, next)
+			if !ok || len(r) > rank.MaxLength {
+				break
+			}
+			ranks[id] = r
+			current = r
+		}
+
+		if len(ranks) == len(ids) {
+			return ranks, nil
+		}
+
+		if err := col.rebalance(tx, ids); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("failed to rank cards: column has no room after rebalancing")
+}
+
+// neighbours resolves a position to the ranks of the cards just before and
+// just after it; empty strings stand for the start and end of the column
+func (col rankColumn) neighbours(tx *gorm.DB, ids []uuid.UUID, position models.CardPosition) (string, string, error) {
+	var prev, next string
+
+	if position.AfterID != nil {
+		r, err := col.rankOf(tx, ids, *position.AfterID)
+		if err != nil {
+			return "", "", err
+		}
+		prev = r
+	}
+
+	if position.BeforeID != nil {
+		r, err := col.rankOf(tx, ids, *position.BeforeID)
+		if err != nil {
+			return "", "", err
+		}
+		next = r
+	}
+
+	switch {
+	case position.AfterID != nil && position.BeforeID != nil:
+		if prev >= next {
+			return "", "", fmt.Errorf("%w: after card must come before the before card", ErrInvalidPosition)
+		}
+	case position.AfterID != nil:
+		r, err := col.firstRank(col.query(tx, ids).Where("rank > ?", prev).Order("rank ASC"))
+		if err != nil {
+			return "", "", err
+		}
+		next = r
+	case position.BeforeID != nil:
+		r, err := col.firstRank(col.query(tx, ids).Where("rank < ?", next).Order("rank DESC"))
+		if err != nil {
+			return "", "", err
+		}
+		prev = r
+	default:
+		// No position: the top of the column
+		r, err := col.firstRank(col.query(tx, ids).Order("rank ASC"))
+		if err != nil {
+			return "", "", err
+		}
+		next = r
+	}
+
+	return prev, next, nil
+}
+
+// rankOf retrieves the rank of an anchor card, which must be in the column
+func (col rankColumn) rankOf(tx *gorm.DB, ids []uuid.UUID, id uuid.UUID) (string, error) {
+	var ranks []string
+	if err := col.query(tx, ids).Where("id = ?", id).Pluck("rank", &ranks).Error; err != nil {
+		return "", fmt.Errorf("failed to get card rank: %w", err)
+	}
+
+	if len(ranks) == 0 {
+		return "", fmt.Errorf("%w: card %s is not in the target column", ErrInvalidPosition, id)
+	}
+
+	return ranks[0], nil
+}
+
+// firstRank retrieves the rank of the first card matched by query, or an
+// empty string when there is none
+func (col rankColumn) firstRank(query *gorm.DB) (string, error) {
+	var ranks []string
+	if err := query.Limit(1).Pluck("rank", &ranks).Error; err != nil {
+		return "", fmt.Errorf("failed to get card rank: %w", err)
+	}
+
+	if len(ranks) == 0 {
+		return "", nil
+	}
+
+	return ranks[0], nil
+}
+
+// rebalance spreads the ranks of the column's cards evenly, keeping their
+// order. Cards in ids are left alone.
+func (col rankColumn) rebalance(tx *gorm.DB, ids []uuid.UUID) error {
+	var cardIDs []uuid.UUID
+	if err := col.query(tx, ids).Order("rank ASC, created_at DESC").Pluck("id", &cardIDs).Error; err != nil {
+		return fmt.Errorf("failed to get column cards: %w", err)
+	}
+
+	for i, r := range rank.Spread(len(cardIDs)) {
+		if err := tx.Table(col.table).Where("id = ?", cardIDs[i]).Update("rank", r).Error; err != nil {
+			return fmt.Errorf("failed to rebalance column: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,413 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/recurrence"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidRecurrence is returned when a recurring task's rule or template
+// is rejected
+var ErrInvalidRecurrence = errors.New("invalid recurrence")
+
+// RecurringTaskService handles recurring task templates and creates their
+// tasks when they come due
+type RecurringTaskService struct {
+	db            *gorm.DB
+	tasks         *TaskService
+	notifications *NotificationService
+}
+
+// NewRecurringTaskService creates a new recurring task service. Tasks are
+// created through taskService so they get the same defaults and checks as
+// tasks created by hand.
+func NewRecurringTaskService(db *gorm.DB, taskService *TaskService, notificationService *NotificationService) *RecurringTaskService {
+	return &RecurringTaskService{db: db, tasks: taskService, notifications: notificationService}
+}
+
+// GetRecurringTasks retrieves the recurring tasks of a project
+func (s *RecurringTaskService) GetRecurringTasks(projectID uuid.UUID) ([]models.RecurringTask, error) {
+	var recurring []models.RecurringTask
+	if err := s.db.Where("project_id = ?", projectID).Order("created_at ASC").Find(&recurring).Error; err != nil {
+		return nil, fmt.Errorf("failed to get recurring tasks: %w", err)
+	}
+
+	return recurring, nil
+}
+
+// GetRecurringTaskByID retrieves a recurring task by ID
+func (s *RecurringTaskService) GetRecurringTaskByID(id uuid.UUID) (*models.RecurringTask, error) {
+	var recurring models.RecurringTask
+	err := s.db.Where("id = ?", id).First(&recurring).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("recurring task not found")
+		}
+		return nil, fmt.Errorf("failed to get recurring task: %w", err)
+	}
+
+	return &recurring, nil
+}
+
+// CreateRecurringTask creates a recurring task and schedules its first occurrence
+func (s *RecurringTaskService) CreateRecurringTask(req *models.RecurringTaskCreateRequest, projectID, createdBy uuid.UUID) (*models.RecurringTask, error) {
+	priority := models.TaskPriorityMedium
+	if req.Priority != "" {
+		priority = req.Priority
+	}
+
+	interval := req.Interval
+	if interval == 0 {
+		interval = 1
+	}
+
+	recurring := &models.RecurringTask{
+		ProjectID:       projectID,
+		CreatedBy:       createdBy,
+		Name:            strings.TrimSpace(req.Name),
+		Description:     req.Description,
+		Priority:        priority,
+		StoryPoints:     req.StoryPoints,
+		EstimateMinutes: req.EstimateMinutes,
+		AssigneeIDs:     models.UUIDList(req.AssigneeIDs),
+		LabelIDs:        models.UUIDList(req.LabelIDs),
+		Frequency:       req.Frequency,
+		Interval:        interval,
+		ByWeekday:       normalizeWeekdays(req.ByWeekday),
+		StartsAt:        req.StartsAt.UTC(),
+		Until:           req.Until,
+		Count:           req.Count,
+		DueAfterMinutes: req.DueAfterMinutes,
+	}
+
+	if err := s.validateRecurringTask(recurring); err != nil {
+		return nil, err
+	}
+
+	recurring.NextRunAt = s.nextRun(recurring, time.Now())
+
+	if err := s.db.Create(recurring).Error; err != nil {
+		return nil, fmt.Errorf("failed to create recurring task: %w", err)
+	}
+
+	return recurring, nil
+}
+
+// UpdateRecurringTask updates a recurring task. Changing the rule
+// reschedules the next occurrence from now; tasks already created are kept.
+func (s *RecurringTaskService) UpdateRecurringTask(id uuid.UUID, req *models.RecurringTaskUpdateRequest) (*models.RecurringTask, error) {
+	recurring, err := s.GetRecurringTaskByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		recurring.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		recurring.Description = *req.Description
+	}
+	if req.Priority != nil {
+		recurring.Priority = *req.Priority
+	}
+	if req.StoryPoints != nil {
+		recurring.StoryPoints = req.StoryPoints
+	}
+	if req.EstimateMinutes != nil {
+		recurring.EstimateMinutes = req.EstimateMinutes
+	}
+	if req.AssigneeIDs != nil {
+		recurring.AssigneeIDs = models.UUIDList(req.AssigneeIDs)
+	}
+	if req.LabelIDs != nil {
+		recurring.LabelIDs = models.UUIDList(req.LabelIDs)
+	}
+	if req.DueAfterMinutes != nil {
+		recurring.DueAfterMinutes = req.DueAfterMinutes
+	}
+
+	ruleChanged := req.Frequency != nil || req.Interval != nil || req.ByWeekday != nil ||
+		req.StartsAt != nil || req.Until != nil || req.Count != nil
+	if req.Frequency != nil {
+		recurring.Frequency = *req.Frequency
+		if recurring.Frequency != recurrence.Weekly && req.ByWeekday == nil {
+			recurring.ByWeekday = models.StringList{}
+		}
+	}
+	if req.Interval != nil {
+		recurring.Interval = *req.Interval
+	}
+	if req.ByWeekday != nil {
+		recurring.ByWeekday = normalizeWeekdays(req.ByWeekday)
+	}
+	if req.StartsAt != nil {
+		recurring.StartsAt = req.StartsAt.UTC()
+	}
+	if req.Until != nil {
+		recurring.Until = req.Until
+	}
+	if req.Count != nil {
+		recurring.Count = req.Count
+	}
+
+	if err := s.validateRecurringTask(recurring); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{
+		"name":              recurring.Name,
+		"description":       recurring.Description,
+		"priority":          recurring.Priority,
+		"story_points":      recurring.StoryPoints,
+		"estimate_minutes":  recurring.EstimateMinutes,
+		"assignee_ids":      recurring.AssigneeIDs,
+		"label_ids":         recurring.LabelIDs,
+		"due_after_minutes": recurring.DueAfterMinutes,
+	}
+	if ruleChanged {
+		updates["frequency"] = recurring.Frequency
+		updates["interval"] = recurring.Interval
+		updates["by_weekday"] = recurring.ByWeekday
+		updates["starts_at"] = recurring.StartsAt
+		updates["until"] = recurring.Until
+		updates["count"] = recurring.Count
+
+		// Never reschedule an occurrence that already produced a task
+		after := time.Now()
+		if recurring.LastRunAt != nil && recurring.LastRunAt.After(after) {
+			after = *recurring.LastRunAt
+		}
+		updates["next_run_at"] = s.nextRun(recurring, after)
+	}
+
+	if err := s.db.Model(&models.RecurringTask{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update recurring task: %w", err)
+	}
+
+	return s.GetRecurringTaskByID(id)
+}
+
+// DeleteRecurringTask deletes a recurring task. Tasks it already created are kept.
+func (s *RecurringTaskService) DeleteRecurringTask(id uuid.UUID) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Task{}).Where("recurring_task_id = ?", id).Update("recurring_task_id", nil).Error; err != nil {
+			return fmt.Errorf("failed to detach recurring task instances: %w", err)
+		}
+
+		if err := tx.Where("id = ?", id).Delete(&models.RecurringTask{}).Error; err != nil {
+			return fmt.Errorf("failed to delete recurring task: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// RunDue creates the tasks of every recurring task due at now and schedules
+// their next occurrences. It returns how many tasks were created.
+//
+// Each recurring task is claimed with a row lock that other replicas skip
+// (a no-key lock, so the new task may still reference it), and a task is
+// only created if none exists yet for the occurrence, so running this
+// concurrently or again after a crash never creates duplicates.
+// Occurrences missed while the scheduler was down are skipped; only the
+// latest one is created.
+func (s *RecurringTaskService) RunDue(now time.Time) (int, error) {
+	created := 0
+	for {
+		processed, ok, err := s.runNext(now)
+		if err != nil {
+			return created, err
+		}
+		if !ok {
+			return created, nil
+		}
+		if processed {
+			created++
+		}
+	}
+}
+
+// runNext claims one due recurring task and creates its task. It reports
+// whether a task was created and whether a due recurring task was found.
+func (s *RecurringTaskService) runNext(now time.Time) (bool, bool, error) {
+	created := false
+	found := false
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var recurring models.RecurringTask
+		result := tx.Clauses(clause.Locking{Strength: "NO KEY UPDATE", Options: "SKIP LOCKED"}).
+			Where("next_run_at IS NOT NULL AND next_run_at <= ?", now).
+			Order("next_run_at ASC").
+			Limit(1).
+			Find(&recurring)
+		if result.Error != nil {
+			return fmt.Errorf("failed to claim recurring task: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		found = true
+
+		rule := recurrenceRule(&recurring)
+		occurrence := *recurring.NextRunAt
+		if latest, ok := rule.Latest(now); ok && latest.After(occurrence) {
+			occurrence = latest
+		}
+
+		var lastError string
+		exists, err := s.occurrenceExists(tx, recurring.ID, occurrence)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			req := s.taskRequest(&recurring, rule, occurrence)
+			task, err := s.tasks.CreateTask(req, recurring.ProjectID, recurring.CreatedBy)
+			if err != nil {
+				// A template that no longer fits the project (say a removed
+				// label) must not stall the schedule; record it and move on
+				log.Printf("Failed to create task for recurring task %s: %v", recurring.ID, err)
+				lastError = err.Error()
+			} else {
+				created = true
+				s.notifications.TaskAssigneesChanged(task, nil, req.AssigneeIDs, recurring.CreatedBy)
+			}
+		}
+
+		occurrences := recurring.OccurrenceCount
+		if lastError == "" {
+			occurrences++
+		}
+		recurring.OccurrenceCount = occurrences
+
+		err = tx.Model(&models.RecurringTask{}).Where("id = ?", recurring.ID).Updates(map[string]interface{}{
+			"next_run_at":      s.nextRun(&recurring, occurrence),
+			"last_run_at":      occurrence,
+			"occurrence_count": occurrences,
+			"last_error":       lastError,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to schedule recurring task: %w", err)
+		}
+
+		return nil
+	})
+
+	return created, found, err
+}
+
+// occurrenceExists reports whether a task was already created for an
+// occurrence, including tasks deleted since
+func (s *RecurringTaskService) occurrenceExists(tx *gorm.DB, recurringID uuid.UUID, occurrence time.Time) (bool, error) {
+	var count int64
+	err := tx.Unscoped().Model(&models.Task{}).
+		Where("recurring_task_id = ? AND occurrence_at = ?", recurringID, occurrence).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check recurring task occurrence: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// taskRequest builds the request that creates a recurring task's task for
+// an occurrence
+func (s *RecurringTaskService) taskRequest(recurring *models.RecurringTask, rule recurrence.Rule, occurrence time.Time) *models.TaskCreateRequest {
+	var deadline *time.Time
+	if recurring.DueAfterMinutes != nil {
+		due := occurrence.Add(time.Duration(*recurring.DueAfterMinutes) * time.Minute)
+		deadline = &due
+	} else if next, ok := rule.Next(occurrence); ok {
+		deadline = &next
+	}
+
+	return &models.TaskCreateRequest{
+		Name:            recurring.Name,
+		Description:     recurring.Description,
+		Deadline:        deadline,
+		AssigneeIDs:     []uuid.UUID(recurring.AssigneeIDs),
+		Priority:        recurring.Priority,
+		StoryPoints:     recurring.StoryPoints,
+		EstimateMinutes: recurring.EstimateMinutes,
+		LabelIDs:        []uuid.UUID(recurring.LabelIDs),
+		RecurringTaskID: &recurring.ID,
+		OccurrenceAt:    &occurrence,
+	}
+}
+
+// nextRun returns the first occurrence after after, or nil when the rule
+// has ended or its count is used up
+func (s *RecurringTaskService) nextRun(recurring *models.RecurringTask, after time.Time) *time.Time {
+	if recurring.Count != nil && recurring.OccurrenceCount >= *recurring.Count {
+		return nil
+	}
+
+	next, ok := recurrenceRule(recurring).Next(after)
+	if !ok {
+		return nil
+	}
+
+	return &next
+}
+
+// validateRecurringTask checks a recurring task's template and rule
+func (s *RecurringTaskService) validateRecurringTask(recurring *models.RecurringTask) error {
+	if recurring.Name == "" || len(recurring.Name) > 200 {
+		return fmt.Errorf("%w: name must be 1-200 characters", ErrInvalidRecurrence)
+	}
+
+	if err := validateTaskSizing(&recurring.Priority, recurring.StoryPoints, recurring.EstimateMinutes); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRecurrence, err)
+	}
+
+	if err := recurrenceRule(recurring).Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRecurrence, err)
+	}
+
+	if recurring.Count != nil && *recurring.Count < 1 {
+		return fmt.Errorf("%w: count must be at least 1", ErrInvalidRecurrence)
+	}
+
+	if recurring.DueAfterMinutes != nil && *recurring.DueAfterMinutes < 0 {
+		return fmt.Errorf("%w: due after cannot be negative", ErrInvalidRecurrence)
+	}
+
+	if len(recurring.LabelIDs) > 0 {
+		orgID, err := s.tasks.getProjectOrg(s.db, recurring.ProjectID)
+		if err != nil {
+			return err
+		}
+		if err := checkOrgLabels(s.db, orgID, recurring.LabelIDs); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidRecurrence, err)
+		}
+	}
+
+	return nil
+}
+
+// recurrenceRule returns the recurrence rule of a recurring task
+func recurrenceRule(recurring *models.RecurringTask) recurrence.Rule {
+	return recurrence.Rule{
+		Frequency: recurring.Frequency,
+		Interval:  recurring.Interval,
+		ByWeekday: recurring.ByWeekday,
+		Start:     recurring.StartsAt,
+		Until:     recurring.Until,
+	}
+}
+
+// normalizeWeekdays upper-cases RRULE weekday codes
+func normalizeWeekdays(days []string) models.StringList {
+	normalized := make(models.StringList, len(days))
+	for i, day := range days {
+		normalized[i] = strings.ToUpper(strings.TrimSpace(day))
+	}
+	return normalized
+}
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"taskman-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidSavedView is returned when a view does not match the query schema
+// of its list
+var ErrInvalidSavedView = errors.New("invalid saved view")
+
+// maxSavedViewColumns bounds the visible columns of a view
+const maxSavedViewColumns = 30
+
+// viewSchema describes what a task list endpoint parses: its filter query
+// parameters with a check for each value, the fields it can sort on and how
+// it can group
+type viewSchema struct {
+	filters map[string]func(string) bool
+	sorts   []string
+	groups  []string
+}
+
+// projectViewSchema mirrors the query of a project's task list, which has no
+// grouping. Custom field filters (cf.<key>) are checked against the
+// organization's fields.
+var projectViewSchema = viewSchema{
+	filters: map[string]func(string) bool{
+		"priority":     isPriorityList,
+		"labels":       isUUIDList,
+		"min_points":   isNonNegativeInt,
+		"max_points":   isNonNegativeInt,
+		"min_estimate": isNonNegativeInt,
+		"max_estimate": isNonNegativeInt,
+	},
+	sorts: models.ProjectTaskSorts,
+}
+
+// myTasksViewSchema mirrors the query of /me/tasks
+var myTasksViewSchema = viewSchema{
+	filters: map[string]func(string) bool{
+		"status":       isNonEmptyList,
+		"due":          isOneOf(models.MyTasksDueOverdue, models.MyTasksDueThisWeek),
+		"include_done": isBool,
+		"org_id":       isUUID,
+	},
+	sorts:  models.MyTaskSorts,
+	groups: []string{models.MyTasksGroupByOrg, models.MyTasksGroupByProject},
+}
+
+// savedViewColumns lists the columns a view can show, besides custom fields
+var savedViewColumns = []string{
+	"name", "status", "priority", "assignees", "labels", "deadline", "story_points",
+	"estimate_minutes", "progress", "project", "org", "created_at", "updated_at",
+}
+
+// SavedViewService handles saved task list views
+type SavedViewService struct {
+	db *gorm.DB
+}
+
+// NewSavedViewService creates a new saved view service
+func NewSavedViewService(db *gorm.DB) *SavedViewService {
+	return &SavedViewService{db: db}
+}
+
+// GetMyTaskViews retrieves a user's views of their /me/tasks list by name
+func (s *SavedViewService) GetMyTaskViews(userID uuid.UUID) ([]models.SavedView, error) {
+	var views []models.SavedView
+	err := s.db.Where("user_id = ? AND project_id IS NULL", userID).
+		Order("name ASC").
+		Find(&views).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get views: %w", err)
+	}
+
+	return views, nil
+}
+
+// GetProjectViews retrieves the views of a project's task list a user can
+// open: their own and those shared with the organization
+func (s *SavedViewService) GetProjectViews(projectID, userID uuid.UUID) ([]models.SavedView, error) {
+	var views []models.SavedView
+	err := s.db.Where("project_id = ? AND (user_id = ? OR shared)", projectID, userID).
+		Order("name ASC").
+		Find(&views).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get views: %w", err)
+	}
+
+	return views, nil
+}
+
+// GetViewByID retrieves a view by ID
+func (s *SavedViewService) GetViewByID(id uuid.UUID) (*models.SavedView, error) {
+	var view models.SavedView
+	err := s.db.Where("id = ?", id).First(&view).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("view not found")
+		}
+		return nil, fmt.Errorf("failed to get view: %w", err)
+	}
+
+	return &view, nil
+}
+
+// CreateView saves a view for a user. A nil project saves a view of the
+// user's /me/tasks list; otherwise orgID is the project's organization.
+func (s *SavedViewService) CreateView(userID uuid.UUID, orgID uuid.UUID, projectID *uuid.UUID, req *models.SavedViewCreateRequest) (*models.SavedView, error) {
+	view := &models.SavedView{
+		UserID:    userID,
+		ProjectID: projectID,
+		Name:      strings.TrimSpace(req.Name),
+		Shared:    req.Shared,
+		Filters:   models.StringMap(req.Filters),
+		Sort:      req.Sort,
+		GroupBy:   req.GroupBy,
+		Columns:   models.StringList(req.Columns),
+	}
+
+	if err := s.validateView(orgID, view); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(view).Error; err != nil {
+		return nil, fmt.Errorf("failed to create view: %w", err)
+	}
+
+	return view, nil
+}
+
+// UpdateView updates a view. orgID is the organization of the view's
+// project, if any.
+func (s *SavedViewService) UpdateView(id uuid.UUID, orgID uuid.UUID, req *models.SavedViewUpdateRequest) (*models.SavedView, error) {
+	view, err := s.GetViewByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name == nil && req.Shared == nil && req.Filters == nil && req.Sort == nil && req.GroupBy == nil && req.Columns == nil {
+		return nil, fmt.Errorf("no fields to update")
+	}
+	if req.Name != nil {
+		view.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Shared != nil {
+		view.Shared = *req.Shared
+	}
+	if req.Filters != nil {
+		view.Filters = models.StringMap(req.Filters)
+	}
+	if req.Sort != nil {
+		view.Sort = *req.Sort
+	}
+	if req.GroupBy != nil {
+		view.GroupBy = *req.GroupBy
+	}
+	if req.Columns != nil {
+		view.Columns = models.StringList(req.Columns)
+	}
+
+	if err := s.validateView(orgID, view); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Model(&models.SavedView{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"name":     view.Name,
+		"shared":   view.Shared,
+		"filters":  view.Filters,
+		"sort":     view.Sort,
+		"group_by": view.GroupBy,
+		"columns":  view.Columns,
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to update view: %w", err)
+	}
+
+	return s.GetViewByID(id)
+}
+
+// DeleteView deletes a view
+func (s *SavedViewService) DeleteView(id uuid.UUID) error {
+	if err := s.db.Where("id = ?", id).Delete(&models.SavedView{}).Error; err != nil {
+		return fmt.Errorf("failed to delete view: %w", err)
+	}
+
+	return nil
+}
+
+// validateView checks a view against the query schema of its list
+func (s *SavedViewService) validateView(orgID uuid.UUID, view *models.SavedView) error {
+	if view.Name == "" || len(view.Name) > 100 {
+		return fmt.Errorf("%w: name must be 1-100 characters", ErrInvalidSavedView)
+	}
+
+	schema := myTasksViewSchema
+	var customFields map[string]bool
+	if view.ProjectID != nil {
+		schema = projectViewSchema
+
+		var keys []string
+		err := s.db.Model(&models.CustomField{}).
+			Where("org_id = ? AND entity = ?", orgID, models.CustomFieldEntityTask).
+			Pluck("key", &keys).Error
+		if err != nil {
+			return fmt.Errorf("failed to get custom fields: %w", err)
+		}
+		customFields = make(map[string]bool, len(keys))
+		for _, key := range keys {
+			customFields[key] = true
+		}
+	} else if view.Shared {
+		return fmt.Errorf("%w: only project views can be shared", ErrInvalidSavedView)
+	}
+
+	for param, value := range view.Filters {
+		if key := strings.TrimPrefix(param, "cf."); key != param {
+			if !customFields[key] {
+				return fmt.Errorf("%w: unknown custom field %q", ErrInvalidSavedView, key)
+			}
+			continue
+		}
+		check, ok := schema.filters[param]
+		if !ok {
+			return fmt.Errorf("%w: unknown filter %q", ErrInvalidSavedView, param)
+		}
+		if !check(value) {
+			return fmt.Errorf("%w: invalid value for filter %q", ErrInvalidSavedView, param)
+		}
+	}
+
+	if _, ok := models.ParseTaskSort(view.Sort, schema.sorts); view.Sort != "" && !ok {
+		return fmt.Errorf("%w: cannot sort by %q", ErrInvalidSavedView, view.Sort)
+	}
+
+	if view.GroupBy != "" && !containsString(schema.groups, view.GroupBy) {
+		return fmt.Errorf("%w: cannot group by %q", ErrInvalidSavedView, view.GroupBy)
+	}
+
+	if len(view.Columns) > maxSavedViewColumns {
+		return fmt.Errorf("%w: at most %d columns", ErrInvalidSavedView, maxSavedViewColumns)
+	}
+	seen := make(map[string]bool)
+	for _, column := range view.Columns {
+		if key := strings.TrimPrefix(column, "cf."); key != column {
+			if !customFields[key] {
+				return fmt.Errorf("%w: unknown custom field %q", ErrInvalidSavedView, key)
+			}
+		} else if !containsString(savedViewColumns, column) {
+			return fmt.Errorf("%w: unknown column %q", ErrInvalidSavedView, column)
+		}
+		if seen[column] {
+			return fmt.Errorf("%w: duplicate column %q", ErrInvalidSavedView, column)
+		}
+		seen[column] = true
+	}
+
+	return nil
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// isOneOf returns a check accepting only the given values
+func isOneOf(allowed ...string) func(string) bool {
+	return func(value string) bool {
+		return containsString(allowed, value)
+	}
+}
+
+// isNonEmptyList checks a comma separated list with at least one value
+func isNonEmptyList(value string) bool {
+	return strings.Trim(value, ", ") != ""
+}
+
+// isPriorityList checks a comma separated list of task priorities
+func isPriorityList(value string) bool {
+	for _, part := range strings.Split(value, ",") {
+		if !models.TaskPriority(strings.TrimSpace(part)).IsValid() {
+			return false
+		}
+	}
+	return true
+}
+
+// isUUIDList checks a comma separated list of IDs
+func isUUIDList(value string) bool {
+	for _, part := range strings.Split(value, ",") {
+		if !isUUID(strings.TrimSpace(part)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isUUID checks an ID
+func isUUID(value string) bool {
+	_, err := uuid.Parse(value)
+	return err == nil
+}
+
+// isNonNegativeInt checks a whole number of zero or more
+func isNonNegativeInt(value string) bool {
+	number, err := strconv.Atoi(value)
+	return err == nil && number >= 0
+}
+
+// isBool checks a boolean
+func isBool(value string) bool {
+	_, err := strconv.ParseBool(value)
+	return err == nil
+}
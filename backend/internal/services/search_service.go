@@ -0,0 +1,135 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"taskman-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Highlight markers. ts_headline wraps matches in these, and they become
+// <mark> tags once the rest of the text is escaped.
+const (
+	searchMarkStart = "{{mark}}"
+	searchMarkStop  = "{{/mark}}"
+)
+
+// ts_headline options for names, shown whole with every match marked, and
+// descriptions, cut down to a few short fragments
+var (
+	searchNameOptions    = fmt.Sprintf(`StartSel="%s", StopSel="%s", HighlightAll=true`, searchMarkStart, searchMarkStop)
+	searchSnippetOptions = fmt.Sprintf(`StartSel="%s", StopSel="%s", MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=" … "`, searchMarkStart, searchMarkStop)
+)
+
+// SearchService handles full-text search over an organization's tasks and
+// projects
+type SearchService struct {
+	db *gorm.DB
+}
+
+// NewSearchService creates a new search service
+func NewSearchService(db *gorm.DB) *SearchService {
+	return &SearchService{db: db}
+}
+
+// Search finds the tasks and projects of an organization matching a web
+// search style query ("quoted phrases", or, -exclusions), best matches
+// first. Members can see every project of their organization, so results
+// are limited to the organization's live projects and their tasks.
+func (s *SearchService) Search(orgID uuid.UUID, query string, filter *models.SearchFilter) ([]models.SearchResult, error) {
+	var parts []string
+	var args []interface{}
+
+	if wantsSearchType(filter, models.SearchTypeTask) {
+		part := `SELECT 'task' AS type, t.id, t.project_id, p.name AS project_name, t.name, t.status,
+				ts_rank(t.search_vector, q.query) AS rank,
+				ts_headline('english', t.name, q.query, ?) AS name_highlight,
+				ts_headline('english', t.description, q.query, ?) AS snippet
+			FROM tasks t
+			JOIN projects p ON p.id = t.project_id AND p.deleted_at IS NULL
+			CROSS JOIN (SELECT websearch_to_tsquery('english', ?) AS query) q
+			WHERE p.org_id = ? AND t.deleted_at IS NULL AND t.search_vector @@ q.query`
+		args = append(args, searchNameOptions, searchSnippetOptions, query, orgID)
+
+		if filter.ProjectID != nil {
+			part += " AND t.project_id = ?"
+			args = append(args, *filter.ProjectID)
+		}
+		if len(filter.Statuses) > 0 {
+			part += " AND t.status IN ?"
+			args = append(args, filter.Statuses)
+		}
+		if filter.AssigneeID != nil {
+			part += " AND EXISTS (SELECT 1 FROM task_assignees ta WHERE ta.task_id = t.id AND ta.user_id = ?)"
+			args = append(args, *filter.AssigneeID)
+		}
+		parts = append(parts, part)
+	}
+
+	if wantsSearchType(filter, models.SearchTypeProject) {
+		part := `SELECT 'project' AS type, p.id, p.id AS project_id, p.name AS project_name, p.name, p.status,
+				ts_rank(p.search_vector, q.query) AS rank,
+				ts_headline('english', p.name, q.query, ?) AS name_highlight,
+				ts_headline('english', p.description, q.query, ?) AS snippet
+			FROM projects p
+			CROSS JOIN (SELECT websearch_to_tsquery('english', ?) AS query) q
+			WHERE p.org_id = ? AND p.deleted_at IS NULL AND p.search_vector @@ q.query`
+		args = append(args, searchNameOptions, searchSnippetOptions, query, orgID)
+
+		if filter.ProjectID != nil {
+			part += " AND p.id = ?"
+			args = append(args, *filter.ProjectID)
+		}
+		if len(filter.Statuses) > 0 {
+			part += " AND p.status IN ?"
+			args = append(args, filter.Statuses)
+		}
+		if filter.AssigneeID != nil {
+			part += " AND EXISTS (SELECT 1 FROM project_assignees pa WHERE pa.project_id = p.id AND pa.user_id = ?)"
+			args = append(args, *filter.AssigneeID)
+		}
+		parts = append(parts, part)
+	}
+
+	results := []models.SearchResult{}
+	if len(parts) == 0 {
+		return results, nil
+	}
+
+	sql := strings.Join(parts, " UNION ALL ") + " ORDER BY rank DESC, name ASC, id ASC LIMIT ? OFFSET ?"
+	args = append(args, filter.PerPage, (filter.Page-1)*filter.PerPage)
+
+	if err := s.db.Raw(sql, args...).Scan(&results).Error; err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	for i := range results {
+		results[i].NameHighlight = renderHighlight(results[i].NameHighlight)
+		results[i].Snippet = renderHighlight(results[i].Snippet)
+	}
+
+	return results, nil
+}
+
+// wantsSearchType reports whether a filter includes results of a type
+func wantsSearchType(filter *models.SearchFilter, searchType string) bool {
+	if len(filter.Types) == 0 {
+		return true
+	}
+	for _, t := range filter.Types {
+		if t == searchType {
+			return true
+		}
+	}
+	return false
+}
+
+// renderHighlight escapes a ts_headline result for HTML and turns its
+// markers into <mark> tags
+func renderHighlight(headline string) string {
+	escaped := html.EscapeString(headline)
+	return strings.NewReplacer(searchMarkStart, "<mark>", searchMarkStop, "</mark>").Replace(escaped)
+}
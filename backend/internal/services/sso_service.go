@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"taskman-backend/internal/models"
+	"taskman-backend/internal/oidc"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSSOLogin is returned when a single sign-on login cannot be completed
+var ErrSSOLogin = errors.New("single sign-on failed")
+
+// ssoLoginTimeout is how long a user has to finish signing in at the provider
+const ssoLoginTimeout = 10 * time.Minute
+
+// SSOService handles single sign-on logins and the identities they link
+type SSOService struct {
+	db *gorm.DB
+}
+
+// NewSSOService creates a new SSO service
+func NewSSOService(db *gorm.DB) *SSOService {
+	return &SSOService{db: db}
+}
+
+// StartLogin records a new login at a provider with a fresh state, nonce and
+// PKCE verifier. Logins that were never finished are cleaned up here.
+func (s *SSOService) StartLogin(provider string) (*models.SSOLoginState, error) {
+	now := time.Now()
+	if err := s.db.Where("expires_at < ?", now).Delete(&models.SSOLoginState{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to clean up SSO logins: %w", err)
+	}
+
+	login := &models.SSOLoginState{
+		Provider:  provider,
+		ExpiresAt: now.Add(ssoLoginTimeout),
+	}
+	for _, field := range []*string{&login.State, &login.Nonce, &login.CodeVerifier} {
+		value, err := oidc.RandomString()
+		if err != nil {
+			return nil, err
+		}
+		*field = value
+	}
+
+	if err := s.db.Create(login).Error; err != nil {
+		return nil, fmt.Errorf("failed to start SSO login: %w", err)
+	}
+
+	return login, nil
+}
+
+// ConsumeLoginState removes and returns an unexpired login at a provider, so
+// that each state can complete at most one login
+func (s *SSOService) ConsumeLoginState(provider, state string) (*models.SSOLoginState, error) {
+	var logins []models.SSOLoginState
+	err := s.db.Clauses(clause.Returning{}).
+		Where("state = ? AND provider = ? AND expires_at > ?", state, provider, time.Now()).
+		Delete(&logins).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume SSO login: %w", err)
+	}
+
+	if len(logins) == 0 {
+		return nil, fmt.Errorf("%w: unknown or expired login", ErrSSOLogin)
+	}
+
+	return &logins[0], nil
+}
+
+// SignIn finds the user behind a provider account, linking it to an
+// existing user with the same verified email or creating a new user. It
+// reports whether the user was created.
+func (s *SSOService) SignIn(provider string, claims *oidc.Claims) (*models.User, bool, error) {
+	var user models.User
+	created := false
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var identity models.UserIdentity
+		err := tx.Where("provider = ? AND subject = ?", provider, claims.Subject).First(&identity).Error
+		if err == nil {
+			if err := tx.Where("id = ?", identity.UserID).First(&user).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return fmt.Errorf("%w: the linked account no longer exists", ErrSSOLogin)
+				}
+				return fmt.Errorf("failed to get user: %w", err)
+			}
+
+			if claims.Email != "" && claims.Email != identity.Email {
+				if err := tx.Model(&identity).Update("email", claims.Email).Error; err != nil {
+					return fmt.Errorf("failed to update identity: %w", err)
+				}
+			}
+			return nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return fmt.Errorf("failed to get identity: %w", err)
+		}
+
+		// Only an email the provider vouches for may claim an account
+		if !claims.Verified() {
+			return fmt.Errorf("%w: the identity provider has not verified your email address", ErrSSOLogin)
+		}
+
+		err = tx.Where("LOWER(email) = LOWER(?)", claims.Email).First(&user).Error
+		if err == gorm.ErrRecordNotFound {
+			user = models.User{
+				Email:        claims.Email,
+				FullName:     ssoFullName(claims),
+				PasswordHash: unusablePasswordHash,
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return fmt.Errorf("failed to create user: %w", err)
+			}
+			created = true
+		} else if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		identity = models.UserIdentity{
+			UserID:   user.ID,
+			Provider: provider,
+			Subject:  claims.Subject,
+			Email:    claims.Email,
+		}
+		if err := tx.Create(&identity).Error; err != nil {
+			return fmt.Errorf("failed to link identity: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &user, created, nil
+}
+
+// ssoFullName picks a display name for a new user
+func ssoFullName(claims *oidc.Claims) string {
+	if name := strings.TrimSpace(claims.Name); name != "" {
+		return name
+	}
+	return strings.SplitN(claims.Email, "@", 2)[0]
+}
@@ -1,33 +1,133 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"taskman-backend/internal/models"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrInvalidParentTask is returned when a parent task is missing, belongs to
+// another project or would create a cycle
+var ErrInvalidParentTask = errors.New("invalid parent task")
+
+// ErrInvalidTask is returned when a task's priority or estimates are rejected
+var ErrInvalidTask = errors.New("invalid task")
+
+// ErrTaskBlocked is returned when a task cannot move to a status because it
+// still has blockers that are not done
+var ErrTaskBlocked = errors.New("task is blocked")
+
+// BlockedTaskError describes a move refused because of open blockers
+type BlockedTaskError struct {
+	TaskID    uuid.UUID
+	BlockedBy []uuid.UUID
+}
+
+func (e *BlockedTaskError) Error() string {
+	return fmt.Sprintf("task %s is blocked by %d unfinished task(s)", e.TaskID, len(e.BlockedBy))
+}
+
+func (e *BlockedTaskError) Unwrap() error {
+	return ErrTaskBlocked
+}
+
+// ErrWIPLimitExceeded is returned when a move would put more tasks in a
+// workflow column than its WIP limits allow
+var ErrWIPLimitExceeded = errors.New("WIP limit exceeded")
+
+// WIPLimitError describes the WIP limits a refused move would exceed
+type WIPLimitError struct {
+	Exceeded []models.WIPLimitWarning
+}
+
+func (e *WIPLimitError) Error() string {
+	return fmt.Sprintf("move would exceed %d WIP limit(s) of column %q", len(e.Exceeded), e.Exceeded[0].Status)
+}
+
+func (e *WIPLimitError) Unwrap() error {
+	return ErrWIPLimitExceeded
+}
+
+// MoveOptions controls how a task status change is checked and placed
+type MoveOptions struct {
+	// IsAdmin unlocks admin-only transitions and WIP limit overrides
+	IsAdmin bool
+	// OverrideWIPLimit lets an admin exceed WIP limits; the move then
+	// reports the exceeded limits as warnings
+	OverrideWIPLimit bool
+	// Position places the task within the target column
+	Position models.CardPosition
+}
+
+// MoveResult describes the outcome of a successful move
+type MoveResult struct {
+	Rank     string
+	Warnings []models.WIPLimitWarning
+}
+
+// BulkMoveResult describes the outcome of a successful bulk move: the new
+// rank of each task
+type BulkMoveResult struct {
+	Ranks    map[uuid.UUID]string
+	Warnings []models.WIPLimitWarning
+}
+
 // TaskService handles task-related operations
 type TaskService struct {
-	db *gorm.DB
+	db              *gorm.DB
+	workflows       *WorkflowService
+	blockedStatuses map[string]bool
 }
 
-// NewTaskService creates a new task service
-func NewTaskService(db *gorm.DB) *TaskService {
-	return &TaskService{db: db}
+// NewTaskService creates a new task service. Tasks with unfinished blockers
+// may not be moved into any of blockedStatuses, which may name workflow
+// column keys or categories.
+func NewTaskService(db *gorm.DB, blockedStatuses []string) *TaskService {
+	statuses := make(map[string]bool)
+	for _, status := range blockedStatuses {
+		if status = strings.TrimSpace(status); status != "" {
+			statuses[status] = true
+		}
+	}
+
+	return &TaskService{db: db, workflows: NewWorkflowService(db), blockedStatuses: statuses}
 }
 
 // CreateTask creates a new task
 func (s *TaskService) CreateTask(req *models.TaskCreateRequest, projectID, createdBy uuid.UUID) (*models.Task, error) {
+	status, err := s.workflows.InitialStatus(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	priority := models.TaskPriorityMedium
+	if req.Priority != "" {
+		priority = req.Priority
+	}
+
+	if err := validateTaskSizing(&priority, req.StoryPoints, req.EstimateMinutes); err != nil {
+		return nil, err
+	}
+
 	task := &models.Task{
-		ProjectID:   projectID,
-		Name:        req.Name,
-		Description: req.Description,
-		Status:      models.TaskStatusNotStarted,
-		CreatedBy:   createdBy,
-		Deadline:    req.Deadline,
+		ProjectID:       projectID,
+		Name:            req.Name,
+		Description:     req.Description,
+		Status:          status,
+		Priority:        priority,
+		StoryPoints:     req.StoryPoints,
+		EstimateMinutes: req.EstimateMinutes,
+		CreatedBy:       createdBy,
+		Deadline:        req.Deadline,
+		RecurringTaskID: req.RecurringTaskID,
+		OccurrenceAt:    req.OccurrenceAt,
 	}
 
 	// Start transaction
@@ -38,12 +138,46 @@ func (s *TaskService) CreateTask(req *models.TaskCreateRequest, projectID, creat
 		}
 	}()
 
+	// Validate parent task
+	if req.ParentTaskID != nil {
+		if err := s.validateParentTask(tx, nil, projectID, *req.ParentTaskID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		task.ParentTaskID = req.ParentTaskID
+	}
+
+	orgID, err := s.getProjectOrg(tx, projectID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Validate custom fields
+	task.CustomFields, err = resolveCustomFields(tx, orgID, models.CustomFieldEntityTask, nil, req.CustomFields)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	// Insert task
 	if err := tx.Create(task).Error; err != nil {
 		tx.Rollback()
 		return nil, fmt.Errorf("failed to create task: %w", err)
 	}
 
+	// New tasks go to the top of their column
+	ranks, err := placeCards(tx, taskColumn(projectID, status), []uuid.UUID{task.ID}, models.CardPosition{})
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	task.Rank = ranks[task.ID]
+	if err := tx.Model(task).Update("rank", task.Rank).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to rank task: %w", err)
+	}
+
 	// Add assignees
 	for _, assigneeID := range req.AssigneeIDs {
 		assignee := &models.TaskAssignee{
@@ -57,6 +191,14 @@ func (s *TaskService) CreateTask(req *models.TaskCreateRequest, projectID, creat
 		}
 	}
 
+	// Add labels
+	if len(req.LabelIDs) > 0 {
+		if err := setTaskLabels(tx, task.ID, orgID, req.LabelIDs); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
@@ -79,16 +221,24 @@ func (s *TaskService) GetTaskByID(id uuid.UUID) (*models.Task, error) {
 	return &task, nil
 }
 
-// GetTasksByProject retrieves all tasks for a project
-func (s *TaskService) GetTasksByProject(projectID uuid.UUID, userID *uuid.UUID) ([]models.TaskResponse, error) {
+// GetTasksByProject retrieves the tasks of a project matching the filter
+func (s *TaskService) GetTasksByProject(projectID uuid.UUID, userID *uuid.UUID, filter *models.TaskFilter) ([]models.TaskResponse, error) {
 	var queryResults []models.TaskQueryResult
 
 	query := s.db.Table("tasks t").
-		Select("t.id, t.project_id, t.name, t.description, t.status, t.created_by, t.deadline, t.created_at, t.updated_at").
+		Select("t.id, t.project_id, t.parent_task_id, t.name, t.description, t.status, t.priority, t.story_points, t.estimate_minutes, t.created_by, t.deadline, t.rank, t.custom_fields, t.recurring_task_id, t.occurrence_at, t.created_at, t.updated_at").
 		Where("t.project_id = ?", projectID)
 
-	// Order by creation date
-	query = query.Order("t.created_at DESC")
+	if filter != nil {
+		query = applyTaskFilter(query, filter)
+	}
+
+	// Order by board position, newest first among unranked tasks, unless
+	// the filter asks for another order first
+	if filter != nil && filter.Sort != nil {
+		query = query.Order(taskSortOrder(filter.Sort))
+	}
+	query = query.Order("t.rank ASC, t.created_at DESC")
 
 	err := query.Scan(&queryResults).Error
 	if err != nil {
@@ -104,24 +254,466 @@ func (s *TaskService) GetTasksByProject(projectID uuid.UUID, userID *uuid.UUID)
 		}
 
 		tasks[i] = models.TaskResponse{
-			ID:          result.ID,
-			ProjectID:   result.ProjectID,
-			Name:        result.Name,
-			Description: result.Description,
-			Status:      result.Status,
-			CreatedBy:   result.CreatedBy,
-			Deadline:    result.Deadline,
-			CreatedAt:   result.CreatedAt,
-			UpdatedAt:   result.UpdatedAt,
-			Assignees:   assignees,
+			ID:              result.ID,
+			ProjectID:       result.ProjectID,
+			ParentTaskID:    result.ParentTaskID,
+			Name:            result.Name,
+			Description:     result.Description,
+			Status:          result.Status,
+			Priority:        result.Priority,
+			StoryPoints:     result.StoryPoints,
+			EstimateMinutes: result.EstimateMinutes,
+			CreatedBy:       result.CreatedBy,
+			Deadline:        result.Deadline,
+			Rank:            result.Rank,
+			CustomFields:    result.CustomFields,
+			RecurringTaskID: result.RecurringTaskID,
+			OccurrenceAt:    result.OccurrenceAt,
+			CreatedAt:       result.CreatedAt,
+			UpdatedAt:       result.UpdatedAt,
+			Assignees:       assignees,
 		}
 	}
 
+	if err := s.enrichTaskResponses(tasks); err != nil {
+		return nil, err
+	}
+
 	return tasks, nil
 }
 
-// UpdateTask updates a task
-func (s *TaskService) UpdateTask(id uuid.UUID, req *models.TaskUpdateRequest) (*models.Task, error) {
+// GetSubtasks retrieves the direct subtasks of a task
+func (s *TaskService) GetSubtasks(parentTaskID uuid.UUID) ([]models.TaskResponse, error) {
+	var subtasks []models.Task
+	err := s.db.Where("parent_task_id = ?", parentTaskID).Order("created_at ASC").Find(&subtasks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subtasks: %w", err)
+	}
+
+	responses := make([]models.TaskResponse, len(subtasks))
+	for i := range subtasks {
+		assignees, err := s.GetTaskAssignees(subtasks[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get task assignees: %w", err)
+		}
+
+		responses[i] = subtasks[i].ToResponse()
+		responses[i].Assignees = assignees
+	}
+
+	if err := s.enrichTaskResponses(responses); err != nil {
+		return nil, err
+	}
+
+	return responses, nil
+}
+
+// EnrichTaskResponse fills in the checklist and progress of a single task response
+func (s *TaskService) EnrichTaskResponse(response *models.TaskResponse) error {
+	responses := []models.TaskResponse{*response}
+	if err := s.enrichTaskResponses(responses); err != nil {
+		return err
+	}
+
+	*response = responses[0]
+	return nil
+}
+
+// enrichTaskResponses fills in the checklist and progress of task responses
+// using one query per attribute rather than one per task
+func (s *TaskService) enrichTaskResponses(tasks []models.TaskResponse) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	taskIDs := make([]uuid.UUID, len(tasks))
+	for i := range tasks {
+		taskIDs[i] = tasks[i].ID
+	}
+
+	var items []models.ChecklistItem
+	err := s.db.Where("task_id IN ?", taskIDs).Order("position ASC, created_at ASC").Find(&items).Error
+	if err != nil {
+		return fmt.Errorf("failed to get checklist items: %w", err)
+	}
+
+	checklists := make(map[uuid.UUID][]models.ChecklistItem)
+	for _, item := range items {
+		checklists[item.TaskID] = append(checklists[item.TaskID], item)
+	}
+
+	progress, err := s.getTaskProgress(taskIDs)
+	if err != nil {
+		return err
+	}
+
+	labels, err := getLabelsByOwner(s.db, "task_labels", "task_id", taskIDs)
+	if err != nil {
+		return err
+	}
+
+	blockers, err := s.getBlockers(taskIDs, false)
+	if err != nil {
+		return err
+	}
+
+	openBlockers, err := s.getBlockers(taskIDs, true)
+	if err != nil {
+		return err
+	}
+
+	doneTasks, err := s.getDoneTasks(taskIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range tasks {
+		tasks[i].Checklist = checklists[tasks[i].ID]
+		if tasks[i].Checklist == nil {
+			tasks[i].Checklist = []models.ChecklistItem{}
+		}
+		tasks[i].Labels = labels[tasks[i].ID]
+		if tasks[i].Labels == nil {
+			tasks[i].Labels = []models.Label{}
+		}
+		tasks[i].Progress = progress[tasks[i].ID]
+		tasks[i].BlockedBy = blockers[tasks[i].ID]
+		if tasks[i].BlockedBy == nil {
+			tasks[i].BlockedBy = []uuid.UUID{}
+		}
+		tasks[i].Blocked = len(openBlockers[tasks[i].ID]) > 0
+		tasks[i].Overdue = models.IsOverdue(tasks[i].Deadline, doneTasks[tasks[i].ID], now)
+	}
+
+	return nil
+}
+
+// getBlockers returns the IDs of the tasks blocking each of taskIDs. When
+// openOnly is set, blockers that are already done are left out.
+func (s *TaskService) getBlockers(taskIDs []uuid.UUID, openOnly bool) (map[uuid.UUID][]uuid.UUID, error) {
+	var rows []models.TaskDependency
+	query := s.db.Table("task_dependencies d").
+		Select("d.task_id, d.blocked_by_task_id").
+		Joins("JOIN tasks b ON b.id = d.blocked_by_task_id AND b.deleted_at IS NULL").
+		Where("d.task_id IN ?", taskIDs)
+	if openOnly {
+		query = query.Where(
+			"NOT EXISTS (SELECT 1 FROM workflow_columns wc WHERE wc.project_id = b.project_id AND wc.key = b.status AND wc.category = ?)",
+			models.WorkflowCategoryDone,
+		)
+	}
+
+	if err := query.Order("d.created_at ASC").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get task blockers: %w", err)
+	}
+
+	blockers := make(map[uuid.UUID][]uuid.UUID)
+	for _, row := range rows {
+		blockers[row.TaskID] = append(blockers[row.TaskID], row.BlockedByTaskID)
+	}
+
+	return blockers, nil
+}
+
+// getDoneTasks returns which of taskIDs are in a done-category column
+func (s *TaskService) getDoneTasks(taskIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	var doneIDs []uuid.UUID
+	err := s.db.Table("tasks t").
+		Joins("JOIN workflow_columns wc ON wc.project_id = t.project_id AND wc.key = t.status").
+		Where("t.id IN ? AND wc.category = ?", taskIDs, models.WorkflowCategoryDone).
+		Pluck("t.id", &doneIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task states: %w", err)
+	}
+
+	done := make(map[uuid.UUID]bool, len(doneIDs))
+	for _, id := range doneIDs {
+		done[id] = true
+	}
+
+	return done, nil
+}
+
+// GetProjectOrgID returns the organization a project belongs to
+func (s *TaskService) GetProjectOrgID(projectID uuid.UUID) (uuid.UUID, error) {
+	return s.getProjectOrg(s.db, projectID)
+}
+
+// getProjectOrg returns the organization a project belongs to
+func (s *TaskService) getProjectOrg(tx *gorm.DB, projectID uuid.UUID) (uuid.UUID, error) {
+	var project models.Project
+	if err := tx.Select("id, org_id").Where("id = ?", projectID).First(&project).Error; err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get project: %w", err)
+	}
+
+	return project.OrgID, nil
+}
+
+// validateTaskSizing checks a task's priority and estimates; nil values are
+// not checked
+func validateTaskSizing(priority *models.TaskPriority, storyPoints, estimateMinutes *int) error {
+	if priority != nil && !priority.IsValid() {
+		return fmt.Errorf("%w: priority must be one of low, medium, high, urgent", ErrInvalidTask)
+	}
+
+	if storyPoints != nil && *storyPoints < 0 {
+		return fmt.Errorf("%w: story points cannot be negative", ErrInvalidTask)
+	}
+
+	if estimateMinutes != nil && *estimateMinutes < 0 {
+		return fmt.Errorf("%w: estimate cannot be negative", ErrInvalidTask)
+	}
+
+	return nil
+}
+
+// taskSortColumns maps sort fields to expressions over tasks aliased t.
+// Priorities sort from low to urgent.
+var taskSortColumns = map[string]string{
+	models.TaskSortRank:        "t.rank",
+	models.TaskSortName:        "t.name",
+	models.TaskSortDeadline:    "t.deadline",
+	models.TaskSortPriority:    "CASE t.priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 WHEN 'urgent' THEN 4 END",
+	models.TaskSortStoryPoints: "t.story_points",
+	models.TaskSortCreatedAt:   "t.created_at",
+	models.TaskSortUpdatedAt:   "t.updated_at",
+}
+
+// taskSortOrder returns the ORDER BY term of a sort, with empty values last
+func taskSortOrder(sort *models.TaskSort) string {
+	direction := "ASC"
+	if sort.Desc {
+		direction = "DESC"
+	}
+	return taskSortColumns[sort.Field] + " " + direction + " NULLS LAST"
+}
+
+// applyTaskFilter narrows a query over tasks aliased t to those matching the filter
+func applyTaskFilter(query *gorm.DB, filter *models.TaskFilter) *gorm.DB {
+	if len(filter.Priorities) > 0 {
+		query = query.Where("t.priority IN ?", filter.Priorities)
+	}
+	if len(filter.LabelIDs) > 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM task_labels tl WHERE tl.task_id = t.id AND tl.label_id IN ?)", filter.LabelIDs)
+	}
+	if filter.MinStoryPoints != nil {
+		query = query.Where("t.story_points >= ?", *filter.MinStoryPoints)
+	}
+	if filter.MaxStoryPoints != nil {
+		query = query.Where("t.story_points <= ?", *filter.MaxStoryPoints)
+	}
+	if filter.MinEstimate != nil {
+		query = query.Where("t.estimate_minutes >= ?", *filter.MinEstimate)
+	}
+	if filter.MaxEstimate != nil {
+		query = query.Where("t.estimate_minutes <= ?", *filter.MaxEstimate)
+	}
+	if len(filter.CustomFields) > 0 {
+		query = applyCustomFieldFilter(query, "t.custom_fields", filter.CustomFields)
+	}
+
+	return query
+}
+
+// checkNotBlocked refuses moving any of taskIDs into column while they have
+// unfinished blockers, if the column's key or category is restricted
+func (s *TaskService) checkNotBlocked(taskIDs []uuid.UUID, column *models.WorkflowColumn) error {
+	restricted := s.blockedStatuses[string(column.Key)] || s.blockedStatuses[string(column.Category)]
+	if !restricted || len(taskIDs) == 0 {
+		return nil
+	}
+
+	openBlockers, err := s.getBlockers(taskIDs, true)
+	if err != nil {
+		return err
+	}
+
+	for _, taskID := range taskIDs {
+		if blockers := openBlockers[taskID]; len(blockers) > 0 {
+			return &BlockedTaskError{TaskID: taskID, BlockedBy: blockers}
+		}
+	}
+
+	return nil
+}
+
+// checkWIPLimits checks that moving taskIDs into column keeps it within its
+// WIP limits. Exceeded limits are refused unless opts allows an override, in
+// which case they are returned as warnings. It must run in the transaction
+// making the move: the column row stays locked until it ends, so concurrent
+// moves into the column are counted one after another.
+func (s *TaskService) checkWIPLimits(tx *gorm.DB, column *models.WorkflowColumn, taskIDs []uuid.UUID, opts MoveOptions) ([]models.WIPLimitWarning, error) {
+	if len(taskIDs) == 0 {
+		return nil, nil
+	}
+
+	var locked models.WorkflowColumn
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", column.ID).First(&locked).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock workflow column: %w", err)
+	}
+	column = &locked
+
+	if column.WIPLimit == nil && column.AssigneeWIPLimit == nil {
+		return nil, nil
+	}
+
+	// Reordering within the column adds nothing to it
+	var entering int64
+	err := tx.Model(&models.Task{}).Where("id IN ? AND status <> ?", taskIDs, column.Key).Count(&entering).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check WIP limits: %w", err)
+	}
+	if entering == 0 {
+		return nil, nil
+	}
+
+	var exceeded []models.WIPLimitWarning
+
+	if column.WIPLimit != nil {
+		var staying int64
+		err := tx.Model(&models.Task{}).
+			Where("project_id = ? AND status = ? AND id NOT IN ?", column.ProjectID, column.Key, taskIDs).
+			Count(&staying).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to check WIP limits: %w", err)
+		}
+
+		if count := int(staying) + len(taskIDs); count > *column.WIPLimit {
+			exceeded = append(exceeded, models.WIPLimitWarning{Status: column.Key, Limit: *column.WIPLimit, Count: count})
+		}
+	}
+
+	if column.AssigneeWIPLimit != nil {
+		type assigneeCount struct {
+			UserID uuid.UUID
+			Count  int
+		}
+
+		// Tasks each assignee of the moved tasks would have in the column
+		var counts []assigneeCount
+		err := tx.Raw(`
+			SELECT ta.user_id, COUNT(*) AS count
+			FROM task_assignees ta
+			JOIN tasks t ON t.id = ta.task_id
+			WHERE ta.user_id IN (SELECT user_id FROM task_assignees WHERE task_id IN ?)
+				AND (t.id IN ? OR (t.project_id = ? AND t.status = ?))
+				AND t.deleted_at IS NULL
+			GROUP BY ta.user_id
+			ORDER BY ta.user_id`, taskIDs, taskIDs, column.ProjectID, column.Key).Scan(&counts).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to check assignee WIP limits: %w", err)
+		}
+
+		for _, row := range counts {
+			if row.Count > *column.AssigneeWIPLimit {
+				assigneeID := row.UserID
+				exceeded = append(exceeded, models.WIPLimitWarning{
+					Status:     column.Key,
+					AssigneeID: &assigneeID,
+					Limit:      *column.AssigneeWIPLimit,
+					Count:      row.Count,
+				})
+			}
+		}
+	}
+
+	if len(exceeded) == 0 {
+		return nil, nil
+	}
+
+	if opts.IsAdmin && opts.OverrideWIPLimit {
+		return exceeded, nil
+	}
+
+	return nil, &WIPLimitError{Exceeded: exceeded}
+}
+
+// getTaskProgress counts done and total subtasks and checklist items per task
+func (s *TaskService) getTaskProgress(taskIDs []uuid.UUID) (map[uuid.UUID]models.TaskProgress, error) {
+	type progressRow struct {
+		TaskID uuid.UUID
+		Done   int
+		Total  int
+	}
+
+	var subtaskRows []progressRow
+	err := s.db.Table("tasks t").
+		Select("t.parent_task_id AS task_id, COUNT(*) FILTER (WHERE wc.category = ?) AS done, COUNT(*) AS total", models.WorkflowCategoryDone).
+		Joins("LEFT JOIN workflow_columns wc ON wc.project_id = t.project_id AND wc.key = t.status").
+		Where("t.parent_task_id IN ? AND t.deleted_at IS NULL", taskIDs).
+		Group("t.parent_task_id").
+		Scan(&subtaskRows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count subtasks: %w", err)
+	}
+
+	var checklistRows []progressRow
+	err = s.db.Table("checklist_items").
+		Select("task_id, COUNT(*) FILTER (WHERE done) AS done, COUNT(*) AS total").
+		Where("task_id IN ?", taskIDs).
+		Group("task_id").
+		Scan(&checklistRows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count checklist items: %w", err)
+	}
+
+	progress := make(map[uuid.UUID]models.TaskProgress)
+	for _, row := range append(subtaskRows, checklistRows...) {
+		p := progress[row.TaskID]
+		p.Done += row.Done
+		p.Total += row.Total
+		progress[row.TaskID] = p
+	}
+
+	return progress, nil
+}
+
+// validateParentTask checks that parentID can be the parent of taskID (nil for
+// a task being created): it must exist in the same project and must not be the
+// task itself or one of its descendants
+func (s *TaskService) validateParentTask(tx *gorm.DB, taskID *uuid.UUID, projectID, parentID uuid.UUID) error {
+	var parent models.Task
+	err := tx.Where("id = ?", parentID).First(&parent).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("%w: parent task not found", ErrInvalidParentTask)
+		}
+		return fmt.Errorf("failed to get parent task: %w", err)
+	}
+
+	if parent.ProjectID != projectID {
+		return fmt.Errorf("%w: parent task belongs to another project", ErrInvalidParentTask)
+	}
+
+	if taskID == nil {
+		return nil
+	}
+
+	// Walk up from the proposed parent; reaching the task itself means a cycle
+	var count int64
+	err = tx.Raw(`
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_task_id FROM tasks WHERE id = ?
+			UNION
+			SELECT t.id, t.parent_task_id FROM tasks t JOIN ancestors a ON t.id = a.parent_task_id
+		)
+		SELECT COUNT(*) FROM ancestors WHERE id = ?`, parentID, *taskID).Scan(&count).Error
+	if err != nil {
+		return fmt.Errorf("failed to check task hierarchy: %w", err)
+	}
+
+	if count > 0 {
+		return fmt.Errorf("%w: task cannot be its own ancestor", ErrInvalidParentTask)
+	}
+
+	return nil
+}
+
+// UpdateTask updates a task. Status changes must follow the project's
+// transition rules and WIP limits as set out by opts; the task goes to the
+// top of its new column. Any WIP limits exceeded by an override are returned.
+func (s *TaskService) UpdateTask(id uuid.UUID, req *models.TaskUpdateRequest, opts MoveOptions) (*models.Task, []models.WIPLimitWarning, error) {
 	// Start transaction
 	tx := s.db.Begin()
 	defer func() {
@@ -130,7 +722,16 @@ func (s *TaskService) UpdateTask(id uuid.UUID, req *models.TaskUpdateRequest) (*
 		}
 	}()
 
+	// Get current task, locked so that status checks see the status it
+	// changes from
+	var current models.Task
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&current).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
 	// Build update map
+	var warnings []models.WIPLimitWarning
 	updates := make(map[string]interface{})
 	if req.Name != nil {
 		updates["name"] = *req.Name
@@ -139,21 +740,105 @@ func (s *TaskService) UpdateTask(id uuid.UUID, req *models.TaskUpdateRequest) (*
 		updates["description"] = *req.Description
 	}
 	if req.Status != nil {
+		column, err := s.workflows.GetColumn(current.ProjectID, *req.Status)
+		if err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
+		if err := s.workflows.CheckTransition(current.ProjectID, current.Status, column.Key, opts.IsAdmin); err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
+		if err := s.checkNotBlocked([]uuid.UUID{id}, column); err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
+		warnings, err = s.checkWIPLimits(tx, column, []uuid.UUID{id}, opts)
+		if err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
 		updates["status"] = *req.Status
+
+		// Tasks changing column go to the top of the new one
+		if column.Key != current.Status {
+			ranks, err := placeCards(tx, taskColumn(current.ProjectID, column.Key), []uuid.UUID{id}, models.CardPosition{})
+			if err != nil {
+				tx.Rollback()
+				return nil, nil, err
+			}
+			updates["rank"] = ranks[id]
+		}
 	}
 	if req.Deadline != nil {
 		updates["deadline"] = *req.Deadline
 	}
+	if req.Priority != nil || req.StoryPoints != nil || req.EstimateMinutes != nil {
+		if err := validateTaskSizing(req.Priority, req.StoryPoints, req.EstimateMinutes); err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
+		if req.Priority != nil {
+			updates["priority"] = *req.Priority
+		}
+		if req.StoryPoints != nil {
+			updates["story_points"] = *req.StoryPoints
+		}
+		if req.EstimateMinutes != nil {
+			updates["estimate_minutes"] = *req.EstimateMinutes
+		}
+	}
+	if req.ParentTaskID != nil {
+		if *req.ParentTaskID == uuid.Nil {
+			updates["parent_task_id"] = nil
+		} else {
+			if err := s.validateParentTask(tx, &id, current.ProjectID, *req.ParentTaskID); err != nil {
+				tx.Rollback()
+				return nil, nil, err
+			}
+			updates["parent_task_id"] = *req.ParentTaskID
+		}
+	}
 
-	if len(updates) == 0 {
-		return nil, fmt.Errorf("no fields to update")
+	if req.CustomFields != nil {
+		orgID, err := s.getProjectOrg(tx, current.ProjectID)
+		if err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
+		values, err := resolveCustomFields(tx, orgID, models.CustomFieldEntityTask, current.CustomFields, req.CustomFields)
+		if err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
+		updates["custom_fields"] = values
+	}
+
+	if len(updates) == 0 && req.LabelIDs == nil {
+		tx.Rollback()
+		return nil, nil, fmt.Errorf("no fields to update")
 	}
 
 	// Update task
 	var task models.Task
-	if err := tx.Model(&task).Where("id = ?", id).Updates(updates).Error; err != nil {
-		tx.Rollback()
-		return nil, fmt.Errorf("failed to update task: %w", err)
+	if len(updates) > 0 {
+		if err := tx.Model(&task).Where("id = ?", id).Updates(updates).Error; err != nil {
+			tx.Rollback()
+			return nil, nil, fmt.Errorf("failed to update task: %w", err)
+		}
+	}
+
+	// Replace labels if provided
+	if req.LabelIDs != nil {
+		orgID, err := s.getProjectOrg(tx, current.ProjectID)
+		if err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
+		if err := setTaskLabels(tx, id, orgID, req.LabelIDs); err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
 	}
 
 	// Update assignees if provided
@@ -161,7 +846,7 @@ func (s *TaskService) UpdateTask(id uuid.UUID, req *models.TaskUpdateRequest) (*
 		// Remove existing assignees
 		if err := tx.Where("task_id = ?", id).Delete(&models.TaskAssignee{}).Error; err != nil {
 			tx.Rollback()
-			return nil, fmt.Errorf("failed to remove existing assignees: %w", err)
+			return nil, nil, fmt.Errorf("failed to remove existing assignees: %w", err)
 		}
 
 		// Add new assignees
@@ -173,7 +858,7 @@ func (s *TaskService) UpdateTask(id uuid.UUID, req *models.TaskUpdateRequest) (*
 			}
 			if err := tx.Create(assignee).Error; err != nil {
 				tx.Rollback()
-				return nil, fmt.Errorf("failed to add task assignee: %w", err)
+				return nil, nil, fmt.Errorf("failed to add task assignee: %w", err)
 			}
 		}
 	}
@@ -181,25 +866,30 @@ func (s *TaskService) UpdateTask(id uuid.UUID, req *models.TaskUpdateRequest) (*
 	// Get updated task
 	if err := tx.Where("id = ?", id).First(&task).Error; err != nil {
 		tx.Rollback()
-		return nil, fmt.Errorf("failed to get updated task: %w", err)
+		return nil, nil, fmt.Errorf("failed to get updated task: %w", err)
 	}
 
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return &task, nil
+	return &task, warnings, nil
 }
 
-// DeleteTask deletes a task
+// DeleteTask deletes a task; its subtasks are promoted to top-level tasks
 func (s *TaskService) DeleteTask(id uuid.UUID) error {
-	result := s.db.Where("id = ?", id).Delete(&models.Task{})
-	if result.Error != nil {
-		return fmt.Errorf("failed to delete task: %w", result.Error)
-	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Task{}).Where("parent_task_id = ?", id).Update("parent_task_id", nil).Error; err != nil {
+			return fmt.Errorf("failed to detach subtasks: %w", err)
+		}
 
-	return nil
+		if err := tx.Where("id = ?", id).Delete(&models.Task{}).Error; err != nil {
+			return fmt.Errorf("failed to delete task: %w", err)
+		}
+
+		return nil
+	})
 }
 
 // GetTaskAssignees retrieves assignees for a task
@@ -234,22 +924,159 @@ func (s *TaskService) IsTaskAssignee(taskID, userID uuid.UUID) (bool, error) {
 	return count > 0, nil
 }
 
-// MoveTask moves a task to a different status
-func (s *TaskService) MoveTask(id uuid.UUID, status models.TaskStatus) error {
-	result := s.db.Model(&models.Task{}).Where("id = ?", id).Update("status", status)
-	if result.Error != nil {
-		return fmt.Errorf("failed to move task: %w", result.Error)
+// MoveTask moves a task to a position in a status of its project's workflow.
+// The move must follow the project's transition rules and WIP limits as set
+// out by opts. Without a position the task goes to the top of a new column or
+// keeps its place in the same one.
+func (s *TaskService) MoveTask(id uuid.UUID, status models.TaskStatus, opts MoveOptions) (*MoveResult, error) {
+	moved := &MoveResult{}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the task so that the checks see the status it moves from
+		var task models.Task
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&task).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("task not found")
+			}
+			return fmt.Errorf("failed to get task: %w", err)
+		}
+
+		column, err := s.workflows.GetColumn(task.ProjectID, status)
+		if err != nil {
+			return err
+		}
+
+		if err := s.workflows.CheckTransition(task.ProjectID, task.Status, column.Key, opts.IsAdmin); err != nil {
+			return err
+		}
+
+		if err := s.checkNotBlocked([]uuid.UUID{id}, column); err != nil {
+			return err
+		}
+
+		// Staying put adds nothing to the column
+		if column.Key == task.Status && !opts.Position.IsSet() {
+			moved.Rank = task.Rank
+			return nil
+		}
+
+		moved.Warnings, err = s.checkWIPLimits(tx, column, []uuid.UUID{id}, opts)
+		if err != nil {
+			return err
+		}
+
+		ranks, err := placeCards(tx, taskColumn(task.ProjectID, column.Key), []uuid.UUID{id}, opts.Position)
+		if err != nil {
+			return err
+		}
+		moved.Rank = ranks[id]
+
+		result := tx.Model(&models.Task{}).Where("id = ?", id).Updates(map[string]interface{}{"status": status, "rank": moved.Rank})
+		if result.Error != nil {
+			return fmt.Errorf("failed to move task: %w", result.Error)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return moved, nil
 }
 
-// BulkMoveTasks moves multiple tasks to a different status
-func (s *TaskService) BulkMoveTasks(taskIDs []uuid.UUID, status models.TaskStatus) error {
-	result := s.db.Model(&models.Task{}).Where("id IN ?", taskIDs).Update("status", status)
-	if result.Error != nil {
-		return fmt.Errorf("failed to bulk move tasks: %w", result.Error)
+// BulkMoveTasks moves multiple tasks to a different status, which must be part
+// of the workflow of every project the tasks belong to. Every move must follow
+// the transition rules and WIP limits of its project as set out by opts. The
+// tasks are placed together at the position, or at the top of the column, in
+// the order given. Any WIP limits exceeded by an override are returned.
+func (s *TaskService) BulkMoveTasks(taskIDs []uuid.UUID, status models.TaskStatus, opts MoveOptions) (*BulkMoveResult, error) {
+	var warnings []models.WIPLimitWarning
+	newRanks := make(map[uuid.UUID]string, len(taskIDs))
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the tasks, in a fixed order, so that the checks see the
+		// statuses they move from
+		var tasks []models.Task
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id, project_id, status").
+			Where("id IN ?", taskIDs).
+			Order("id").
+			Find(&tasks).Error
+		if err != nil {
+			return fmt.Errorf("failed to get tasks: %w", err)
+		}
+
+		columns := make(map[uuid.UUID]*models.WorkflowColumn)
+		for _, task := range tasks {
+			column, ok := columns[task.ProjectID]
+			if !ok {
+				var err error
+				column, err = s.workflows.GetColumn(task.ProjectID, status)
+				if err != nil {
+					return err
+				}
+				columns[task.ProjectID] = column
+			}
+
+			if err := s.workflows.CheckTransition(task.ProjectID, task.Status, column.Key, opts.IsAdmin); err != nil {
+				return err
+			}
+		}
+
+		// Group the tasks by project, in the order they were given
+		projectTasks := make(map[uuid.UUID][]uuid.UUID)
+		taskProjects := make(map[uuid.UUID]uuid.UUID, len(tasks))
+		for _, task := range tasks {
+			taskProjects[task.ID] = task.ProjectID
+		}
+		for _, id := range taskIDs {
+			if projectID, ok := taskProjects[id]; ok {
+				projectTasks[projectID] = append(projectTasks[projectID], id)
+			}
+		}
+
+		// Visit the projects in a fixed order, so that concurrent bulk moves
+		// lock their columns in the same order instead of deadlocking
+		projectIDs := make([]uuid.UUID, 0, len(projectTasks))
+		for projectID := range projectTasks {
+			projectIDs = append(projectIDs, projectID)
+		}
+		sort.Slice(projectIDs, func(i, j int) bool {
+			return projectIDs[i].String() < projectIDs[j].String()
+		})
+
+		for _, projectID := range projectIDs {
+			if err := s.checkNotBlocked(projectTasks[projectID], columns[projectID]); err != nil {
+				return err
+			}
+		}
+
+		for _, projectID := range projectIDs {
+			ids := projectTasks[projectID]
+			exceeded, err := s.checkWIPLimits(tx, columns[projectID], ids, opts)
+			if err != nil {
+				return err
+			}
+			warnings = append(warnings, exceeded...)
+
+			ranks, err := placeCards(tx, taskColumn(projectID, status), ids, opts.Position)
+			if err != nil {
+				return err
+			}
+
+			for id, r := range ranks {
+				result := tx.Model(&models.Task{}).Where("id = ?", id).Updates(map[string]interface{}{"status": status, "rank": r})
+				if result.Error != nil {
+					return fmt.Errorf("failed to bulk move tasks: %w", result.Error)
+				}
+				newRanks[id] = r
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &BulkMoveResult{Ranks: newRanks, Warnings: warnings}, nil
 }
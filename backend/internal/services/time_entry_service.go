@@ -0,0 +1,317 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"taskman-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidTimeEntry is returned when a time entry's times or note are rejected
+var ErrInvalidTimeEntry = errors.New("invalid time entry")
+
+// ErrNoRunningTimer is returned when stopping a timer while none is running
+var ErrNoRunningTimer = errors.New("no running timer")
+
+// ErrTimerRunning is returned when starting a timer while another one runs
+var ErrTimerRunning = errors.New("timer already running")
+
+// TimerRunningError describes a timer start refused because of the user's
+// running timer
+type TimerRunningError struct {
+	Running *models.TimeEntry
+}
+
+func (e *TimerRunningError) Error() string {
+	return fmt.Sprintf("a timer is already running on task %s", e.Running.TaskID)
+}
+
+func (e *TimerRunningError) Unwrap() error {
+	return ErrTimerRunning
+}
+
+// maxTimeEntryDuration is the longest time a single entry may cover
+const maxTimeEntryDuration = 24 * time.Hour
+
+// timesheetCSVHeader is the column order used for timesheet exports
+var timesheetCSVHeader = []string{"day", "user_id", "user_name", "project_id", "project_name", "hours"}
+
+// TimeEntryService handles time tracking on tasks
+type TimeEntryService struct {
+	db *gorm.DB
+}
+
+// NewTimeEntryService creates a new time entry service
+func NewTimeEntryService(db *gorm.DB) *TimeEntryService {
+	return &TimeEntryService{db: db}
+}
+
+// StartTimer starts a timer for a user on a task. A user can only have one
+// running timer; starting another returns a TimerRunningError.
+func (s *TimeEntryService) StartTimer(taskID, userID uuid.UUID, note string) (*models.TimeEntry, error) {
+	if len(note) > 500 {
+		return nil, fmt.Errorf("%w: note must be at most 500 characters", ErrInvalidTimeEntry)
+	}
+
+	running, err := s.GetRunningTimer(userID)
+	if err != nil {
+		return nil, err
+	}
+	if running != nil {
+		return nil, &TimerRunningError{Running: running}
+	}
+
+	entry := &models.TimeEntry{
+		TaskID:    taskID,
+		UserID:    userID,
+		StartedAt: time.Now(),
+		Note:      note,
+	}
+
+	if err := s.db.Create(entry).Error; err != nil {
+		// A concurrent start wins the unique index on running timers
+		if running, _ := s.GetRunningTimer(userID); running != nil {
+			return nil, &TimerRunningError{Running: running}
+		}
+		return nil, fmt.Errorf("failed to start timer: %w", err)
+	}
+
+	return entry, nil
+}
+
+// StopTimer stops the user's running timer. Timers left running for more
+// than a day stop at the longest duration an entry may cover, so the entry
+// stays editable.
+func (s *TimeEntryService) StopTimer(userID uuid.UUID) (*models.TimeEntry, error) {
+	running, err := s.GetRunningTimer(userID)
+	if err != nil {
+		return nil, err
+	}
+	if running == nil {
+		return nil, ErrNoRunningTimer
+	}
+
+	endedAt := time.Now()
+	if endedAt.Sub(running.StartedAt) > maxTimeEntryDuration {
+		endedAt = running.StartedAt.Add(maxTimeEntryDuration)
+	}
+
+	result := s.db.Model(&models.TimeEntry{}).
+		Where("id = ? AND ended_at IS NULL", running.ID).
+		Update("ended_at", endedAt)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to stop timer: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNoRunningTimer
+	}
+
+	return s.GetEntryByID(running.ID)
+}
+
+// GetRunningTimer retrieves the user's running timer, or nil when none is running
+func (s *TimeEntryService) GetRunningTimer(userID uuid.UUID) (*models.TimeEntry, error) {
+	var entries []models.TimeEntry
+	if err := s.db.Where("user_id = ? AND ended_at IS NULL", userID).Limit(1).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get running timer: %w", err)
+	}
+
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	return &entries[0], nil
+}
+
+// CreateEntry logs time on a task manually
+func (s *TimeEntryService) CreateEntry(taskID, userID uuid.UUID, req *models.TimeEntryCreateRequest) (*models.TimeEntry, error) {
+	entry := &models.TimeEntry{
+		TaskID:    taskID,
+		UserID:    userID,
+		StartedAt: req.StartedAt,
+		EndedAt:   &req.EndedAt,
+		Note:      req.Note,
+	}
+
+	if err := validateTimeEntry(entry); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(entry).Error; err != nil {
+		return nil, fmt.Errorf("failed to create time entry: %w", err)
+	}
+
+	entry.SetDuration()
+	return entry, nil
+}
+
+// GetEntryByID retrieves a time entry by ID
+func (s *TimeEntryService) GetEntryByID(id uuid.UUID) (*models.TimeEntry, error) {
+	var entry models.TimeEntry
+	err := s.db.Where("id = ?", id).First(&entry).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("time entry not found")
+		}
+		return nil, fmt.Errorf("failed to get time entry: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// GetTaskEntries retrieves the time entries of a task, most recent first
+func (s *TimeEntryService) GetTaskEntries(taskID uuid.UUID) ([]models.TimeEntry, error) {
+	var entries []models.TimeEntry
+	if err := s.db.Where("task_id = ?", taskID).Order("started_at DESC").Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to get time entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// UpdateEntry updates a time entry. A running timer can only have its start
+// time and note changed.
+func (s *TimeEntryService) UpdateEntry(id uuid.UUID, req *models.TimeEntryUpdateRequest) (*models.TimeEntry, error) {
+	entry, err := s.GetEntryByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.StartedAt == nil && req.EndedAt == nil && req.Note == nil {
+		return nil, fmt.Errorf("no fields to update")
+	}
+	if req.EndedAt != nil && entry.EndedAt == nil {
+		return nil, fmt.Errorf("%w: stop the timer instead of setting its end time", ErrInvalidTimeEntry)
+	}
+	if req.StartedAt != nil {
+		entry.StartedAt = *req.StartedAt
+	}
+	if req.EndedAt != nil {
+		entry.EndedAt = req.EndedAt
+	}
+	if req.Note != nil {
+		entry.Note = *req.Note
+	}
+
+	if err := validateTimeEntry(entry); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Model(&models.TimeEntry{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"started_at": entry.StartedAt,
+		"ended_at":   entry.EndedAt,
+		"note":       entry.Note,
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to update time entry: %w", err)
+	}
+
+	return s.GetEntryByID(id)
+}
+
+// DeleteEntry deletes a time entry
+func (s *TimeEntryService) DeleteEntry(id uuid.UUID) error {
+	if err := s.db.Where("id = ?", id).Delete(&models.TimeEntry{}).Error; err != nil {
+		return fmt.Errorf("failed to delete time entry: %w", err)
+	}
+
+	return nil
+}
+
+// GetTimesheet totals the finished time entries of an organization per user,
+// project and day, leaving out deleted tasks and projects. Entries count
+// towards the UTC day they started on.
+func (s *TimeEntryService) GetTimesheet(orgID uuid.UUID, filter *models.TimesheetFilter) ([]models.TimesheetRow, error) {
+	query := s.db.Table("time_entries te").
+		Select(`te.user_id, u.full_name AS user_name, p.id AS project_id, p.name AS project_name,
+			TO_CHAR(te.started_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day,
+			SUM(EXTRACT(EPOCH FROM te.ended_at - te.started_at))::BIGINT AS seconds`).
+		Joins("JOIN tasks t ON t.id = te.task_id AND t.deleted_at IS NULL").
+		Joins("JOIN projects p ON p.id = t.project_id AND p.deleted_at IS NULL").
+		Joins("JOIN users u ON u.id = te.user_id").
+		Where("p.org_id = ? AND te.ended_at IS NOT NULL", orgID).
+		Where("te.started_at >= ? AND te.started_at < ?", filter.From, filter.To)
+
+	if filter.UserID != nil {
+		query = query.Where("te.user_id = ?", *filter.UserID)
+	}
+	if filter.ProjectID != nil {
+		query = query.Where("p.id = ?", *filter.ProjectID)
+	}
+
+	var rows []models.TimesheetRow
+	err := query.
+		Group("te.user_id, u.full_name, p.id, p.name, day").
+		Order("day ASC, u.full_name ASC, p.name ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timesheet: %w", err)
+	}
+
+	return rows, nil
+}
+
+// WriteTimesheetCSV writes timesheet rows as CSV with a header row, giving
+// time in hours
+func WriteTimesheetCSV(w io.Writer, rows []models.TimesheetRow) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(timesheetCSVHeader); err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+
+	for _, row := range rows {
+		err := writer.Write([]string{
+			row.Day,
+			row.UserID.String(),
+			row.UserName,
+			row.ProjectID.String(),
+			row.ProjectName,
+			strconv.FormatFloat(float64(row.Seconds)/3600, 'f', 2, 64),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write timesheet row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// validateTimeEntry checks a time entry's times and note
+func validateTimeEntry(entry *models.TimeEntry) error {
+	if len(entry.Note) > 500 {
+		return fmt.Errorf("%w: note must be at most 500 characters", ErrInvalidTimeEntry)
+	}
+
+	if entry.StartedAt.IsZero() {
+		return fmt.Errorf("%w: start time is required", ErrInvalidTimeEntry)
+	}
+
+	if entry.StartedAt.After(time.Now()) {
+		return fmt.Errorf("%w: start time cannot be in the future", ErrInvalidTimeEntry)
+	}
+
+	if entry.EndedAt == nil {
+		return nil
+	}
+
+	if !entry.EndedAt.After(entry.StartedAt) {
+		return fmt.Errorf("%w: end time must be after start time", ErrInvalidTimeEntry)
+	}
+
+	if entry.EndedAt.After(time.Now()) {
+		return fmt.Errorf("%w: end time cannot be in the future", ErrInvalidTimeEntry)
+	}
+
+	if entry.EndedAt.Sub(entry.StartedAt) > maxTimeEntryDuration {
+		return fmt.Errorf("%w: an entry cannot span more than 24 hours", ErrInvalidTimeEntry)
+	}
+
+	return nil
+}
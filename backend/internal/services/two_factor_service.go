@@ -0,0 +1,376 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"taskman-backend/internal/auth"
+	"taskman-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Two-factor authentication errors
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor authentication has not been set up")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorLocked         = errors.New("too many invalid two-factor codes, try again later")
+	ErrTwoFactorRequired       = errors.New("an organization you administer requires two-factor authentication")
+)
+
+// Two-factor tuning
+const (
+	recoveryCodeCount = 10
+
+	// maxTwoFactorAttempts consecutive wrong codes lock verification for
+	// twoFactorLockout
+	maxTwoFactorAttempts = 5
+	twoFactorLockout     = 15 * time.Minute
+)
+
+// TwoFactorService handles TOTP two-factor authentication and recovery codes
+type TwoFactorService struct {
+	db     *gorm.DB
+	issuer string
+}
+
+// NewTwoFactorService creates a new two-factor service. The issuer names the
+// app in users' authenticators.
+func NewTwoFactorService(db *gorm.DB, issuer string) *TwoFactorService {
+	return &TwoFactorService{db: db, issuer: issuer}
+}
+
+// GetStatus retrieves a user's two-factor setup
+func (s *TwoFactorService) GetStatus(userID uuid.UUID) (*models.TwoFactorStatus, error) {
+	twoFactor, err := s.get(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.TwoFactorStatus{}
+	if twoFactor == nil || !twoFactor.Enabled {
+		return status, nil
+	}
+
+	status.Enabled = true
+	status.EnabledAt = twoFactor.EnabledAt
+	err = s.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&status.RecoveryCodesRemaining).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return status, nil
+}
+
+// IsEnabled reports whether a user has two-factor authentication turned on
+func (s *TwoFactorService) IsEnabled(userID uuid.UUID) (bool, error) {
+	twoFactor, err := s.get(s.db, userID)
+	if err != nil {
+		return false, err
+	}
+	return twoFactor != nil && twoFactor.Enabled, nil
+}
+
+// BeginEnrollment generates a new pending secret for a user, replacing any
+// earlier pending one. It does not take effect until Enable.
+func (s *TwoFactorService) BeginEnrollment(userID uuid.UUID, email string) (*models.TwoFactorEnrollment, error) {
+	existing, err := s.get(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor := &models.UserTwoFactor{UserID: userID, Secret: secret}
+	if existing != nil {
+		twoFactor = existing
+		twoFactor.Secret = secret
+		twoFactor.FailedAttempts = 0
+		twoFactor.LockedUntil = nil
+	}
+	if err := s.db.Save(twoFactor).Error; err != nil {
+		return nil, fmt.Errorf("failed to save two-factor secret: %w", err)
+	}
+
+	return &models.TwoFactorEnrollment{
+		Secret: secret,
+		URI:    auth.TOTPURI(s.issuer, email, secret),
+	}, nil
+}
+
+// Enable turns on two-factor authentication once the user enters a code from
+// their newly enrolled authenticator, and returns their recovery codes
+func (s *TwoFactorService) Enable(userID uuid.UUID, code string) ([]string, error) {
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		twoFactor, err := s.get(tx, userID)
+		if err != nil {
+			return err
+		}
+		if twoFactor == nil {
+			return ErrTwoFactorNotEnrolled
+		}
+		if twoFactor.Enabled {
+			return ErrTwoFactorAlreadyEnabled
+		}
+
+		now := time.Now()
+		step, ok := auth.ValidateTOTP(twoFactor.Secret, code, now)
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+
+		err = tx.Model(twoFactor).Updates(map[string]interface{}{
+			"enabled":         true,
+			"enabled_at":      now,
+			"last_used_step":  step,
+			"failed_attempts": 0,
+			"locked_until":    nil,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+		}
+
+		codes, err = s.replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable turns off two-factor authentication after checking a code. Admins
+// of organizations that require it cannot turn it off.
+func (s *TwoFactorService) Disable(userID uuid.UUID, code string) error {
+	var required int64
+	err := s.db.Table("org_members om").
+		Joins("JOIN organizations o ON o.id = om.org_id AND o.deleted_at IS NULL").
+		Where("om.user_id = ? AND om.role = ? AND o.require_admin_2fa = ?", userID, models.RoleAdmin, true).
+		Count(&required).Error
+	if err != nil {
+		return fmt.Errorf("failed to check organization policies: %w", err)
+	}
+	if required > 0 {
+		return ErrTwoFactorRequired
+	}
+
+	if _, err := s.Verify(userID, code); err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserTwoFactor{}).Error; err != nil {
+			return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+		}
+		return nil
+	})
+}
+
+// RegenerateRecoveryCodes replaces a user's recovery codes after checking a
+// code, and returns the new ones
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uuid.UUID, code string) ([]string, error) {
+	if _, err := s.Verify(userID, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = s.replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Verify checks a TOTP code, or failing that a recovery code, for a user with
+// two-factor authentication enabled. It reports whether a recovery code was
+// used. Each code works once, and repeated wrong codes lock verification for
+// a while.
+func (s *TwoFactorService) Verify(userID uuid.UUID, code string) (bool, error) {
+	twoFactor, err := s.get(s.db, userID)
+	if err != nil {
+		return false, err
+	}
+	if twoFactor == nil || !twoFactor.Enabled {
+		return false, ErrTwoFactorNotEnabled
+	}
+
+	now := time.Now()
+	if twoFactor.LockedUntil != nil && now.Before(*twoFactor.LockedUntil) {
+		return false, ErrTwoFactorLocked
+	}
+
+	if step, ok := auth.ValidateTOTP(twoFactor.Secret, code, now); ok {
+		// Claim the step so that the same code cannot be replayed
+		result := s.db.Model(&models.UserTwoFactor{}).
+			Where("user_id = ? AND last_used_step < ?", userID, step).
+			Updates(map[string]interface{}{"last_used_step": step, "failed_attempts": 0, "locked_until": nil})
+		if result.Error != nil {
+			return false, fmt.Errorf("failed to record two-factor code: %w", result.Error)
+		}
+		if result.RowsAffected == 1 {
+			return false, nil
+		}
+	} else if isRecoveryCode(code) {
+		used, err := s.useRecoveryCode(userID, code, now)
+		if err != nil {
+			return false, err
+		}
+		if used {
+			err := s.db.Model(&models.UserTwoFactor{}).
+				Where("user_id = ?", userID).
+				Updates(map[string]interface{}{"failed_attempts": 0, "locked_until": nil}).Error
+			if err != nil {
+				return true, fmt.Errorf("failed to reset two-factor attempts: %w", err)
+			}
+			return true, nil
+		}
+	}
+
+	// Count the failure in the database so that concurrent guesses cannot
+	// all slip in under the limit
+	var failedAttempts int
+	err = s.db.Raw(`
+		UPDATE user_two_factors SET failed_attempts = failed_attempts + 1
+		WHERE user_id = ?
+		RETURNING failed_attempts`, userID).Scan(&failedAttempts).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to record two-factor attempt: %w", err)
+	}
+	if failedAttempts >= maxTwoFactorAttempts {
+		err := s.db.Model(&models.UserTwoFactor{}).
+			Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"failed_attempts": 0, "locked_until": now.Add(twoFactorLockout)}).Error
+		if err != nil {
+			return false, fmt.Errorf("failed to lock two-factor verification: %w", err)
+		}
+	}
+
+	return false, ErrInvalidTwoFactorCode
+}
+
+// get retrieves a user's two-factor record, or nil when they have none
+func (s *TwoFactorService) get(db *gorm.DB, userID uuid.UUID) (*models.UserTwoFactor, error) {
+	var twoFactor models.UserTwoFactor
+	err := db.Where("user_id = ?", userID).First(&twoFactor).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get two-factor settings: %w", err)
+	}
+
+	return &twoFactor, nil
+}
+
+// useRecoveryCode marks one of a user's unused recovery codes as used if it
+// matches code, reporting whether one did
+func (s *TwoFactorService) useRecoveryCode(userID uuid.UUID, code string, now time.Time) (bool, error) {
+	var recoveryCodes []models.RecoveryCode
+	if err := s.db.Where("user_id = ? AND used_at IS NULL", userID).Find(&recoveryCodes).Error; err != nil {
+		return false, fmt.Errorf("failed to get recovery codes: %w", err)
+	}
+
+	normalized := []byte(normalizeRecoveryCode(code))
+	for _, recoveryCode := range recoveryCodes {
+		if bcrypt.CompareHashAndPassword([]byte(recoveryCode.CodeHash), normalized) != nil {
+			continue
+		}
+
+		// Only one request can use the code
+		result := s.db.Model(&models.RecoveryCode{}).
+			Where("id = ? AND used_at IS NULL", recoveryCode.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return false, fmt.Errorf("failed to use recovery code: %w", result.Error)
+		}
+		return result.RowsAffected == 1, nil
+	}
+
+	return false, nil
+}
+
+// replaceRecoveryCodes deletes a user's recovery codes and stores a new set,
+// returning the codes themselves
+func (s *TwoFactorService) replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(normalizeRecoveryCode(code)), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash recovery code: %w", err)
+		}
+		codes[i] = code
+		records[i] = models.RecoveryCode{UserID: userID, CodeHash: string(hash)}
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to create recovery codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+// generateRecoveryCode creates a random code like "k3fj2-9dm4q"
+func generateRecoveryCode() (string, error) {
+	data := make([]byte, 7)
+	if _, err := rand.Read(data); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(data))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// isRecoveryCode reports whether code is shaped like a recovery code: ten
+// base32 characters once normalized. Anything else is not worth comparing
+// against the stored hashes.
+func isRecoveryCode(code string) bool {
+	normalized := normalizeRecoveryCode(code)
+	if len(normalized) != 10 {
+		return false
+	}
+	for _, r := range normalized {
+		if (r < 'a' || r > 'z') && (r < '2' || r > '7') {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeRecoveryCode drops case, spaces and dashes from a recovery code so
+// that it matches however it was typed
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+}
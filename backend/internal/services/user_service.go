@@ -1,12 +1,37 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"sync"
 	"taskman-backend/internal/models"
+	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// unusablePasswordHash is stored for users who cannot sign in with a
+// password. It is not a bcrypt hash, so no password ever matches it.
+const unusablePasswordHash = "!"
+
+// registrationExpiry is how long the link in a registration email works
+const registrationExpiry = 24 * time.Hour
+
+// User errors
+var (
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrEmailTaken          = errors.New("email is already registered")
+	ErrInvalidRegistration = errors.New("invalid or expired registration")
+)
+
+// dummyPasswordHash is compared against when there is no real hash to check,
+// so that such sign-ins take as long as a wrong password
+var (
+	dummyPasswordHash     []byte
+	dummyPasswordHashOnce sync.Once
 )
 
 // UserService handles user-related operations
@@ -19,23 +44,92 @@ func NewUserService(db *gorm.DB) *UserService {
 	return &UserService{db: db}
 }
 
-// CreateUser creates a new user
-func (s *UserService) CreateUser(req *models.UserCreateRequest) (*models.User, error) {
+// StartRegistration records a sign-up until the owner of its email confirms
+// it, returning the token to email them. A registered email gets
+// ErrEmailTaken, after the same work as a new one, so that the response time
+// does not give it away. Expired sign-ups are cleaned up here.
+func (s *UserService) StartRegistration(req *models.UserCreateRequest) (string, error) {
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		return nil, fmt.Errorf("failed to hash password: %w", err)
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	now := time.Now()
+	if err := s.db.Where("expires_at < ?", now).Delete(&models.PendingRegistration{}).Error; err != nil {
+		return "", fmt.Errorf("failed to clean up registrations: %w", err)
+	}
+
+	var existing int64
+	if err := s.db.Model(&models.User{}).Where("email = ?", req.Email).Count(&existing).Error; err != nil {
+		return "", fmt.Errorf("failed to check email: %w", err)
+	}
+	if existing > 0 {
+		return "", ErrEmailTaken
+	}
+
+	token, err := generateAPIToken("")
+	if err != nil {
+		return "", err
 	}
 
-	// Create user
-	user := &models.User{
+	registration := &models.PendingRegistration{
+		TokenHash:    hashAPIToken(token),
 		Email:        req.Email,
 		FullName:     req.FullName,
 		PasswordHash: string(hashedPassword),
+		ExpiresAt:    now.Add(registrationExpiry),
 	}
+	if err := s.db.Create(registration).Error; err != nil {
+		return "", fmt.Errorf("failed to start registration: %w", err)
+	}
+
+	return token, nil
+}
+
+// CompleteRegistration creates the user of a confirmed sign-up. Each token
+// works once, and the other sign-ups for the same email are dropped.
+func (s *UserService) CompleteRegistration(token string) (*models.User, error) {
+	var user *models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var registrations []models.PendingRegistration
+		err := tx.Clauses(clause.Returning{}).
+			Where("token_hash = ? AND expires_at > ?", hashAPIToken(token), time.Now()).
+			Delete(&registrations).Error
+		if err != nil {
+			return fmt.Errorf("failed to consume registration: %w", err)
+		}
+		if len(registrations) == 0 {
+			return ErrInvalidRegistration
+		}
+		registration := registrations[0]
+
+		if err := tx.Where("email = ?", registration.Email).Delete(&models.PendingRegistration{}).Error; err != nil {
+			return fmt.Errorf("failed to delete registrations: %w", err)
+		}
 
-	if err := s.db.Create(user).Error; err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		var existing int64
+		if err := tx.Model(&models.User{}).Where("email = ?", registration.Email).Count(&existing).Error; err != nil {
+			return fmt.Errorf("failed to check email: %w", err)
+		}
+		if existing > 0 {
+			return ErrEmailTaken
+		}
+
+		// Create user
+		user = &models.User{
+			Email:        registration.Email,
+			FullName:     registration.FullName,
+			PasswordHash: registration.PasswordHash,
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return user, nil
@@ -74,6 +168,33 @@ func (s *UserService) ValidatePassword(user *models.User, password string) error
 	return bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
 }
 
+// Authenticate checks an email and password, returning ErrInvalidCredentials
+// when they do not match. Unknown emails and users without a password take
+// as long as a wrong password, so that timing does not reveal which emails
+// are registered. The user is returned with the error when the email matched.
+func (s *UserService) Authenticate(email, password string) (*models.User, error) {
+	var user models.User
+	err := s.db.Where("email = ?", email).First(&user).Error
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+
+	if user.PasswordHash == unusablePasswordHash {
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return &user, ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return &user, ErrInvalidCredentials
+	}
+
+	return &user, nil
+}
+
 // UpdateUser updates a user's information
 func (s *UserService) UpdateUser(id uuid.UUID, updates map[string]interface{}) error {
 	result := s.db.Model(&models.User{}).Where("id = ?", id).Updates(updates)
@@ -87,3 +208,11 @@ func (s *UserService) UpdateUser(id uuid.UUID, updates map[string]interface{}) e
 
 	return nil
 }
+
+// dummyHash returns a bcrypt hash at the cost real passwords use
+func dummyHash() []byte {
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("taskman-dummy-password"), bcrypt.DefaultCost)
+	})
+	return dummyPasswordHash
+}
@@ -0,0 +1,558 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"taskman-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidWebhook is returned when a webhook's URL or events are rejected
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// Headers sent with every webhook request. The signature is the hex encoded
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook's secret.
+const (
+	WebhookSignatureHeader = "X-Taskman-Signature"
+	WebhookTimestampHeader = "X-Taskman-Timestamp"
+	WebhookEventHeader     = "X-Taskman-Event"
+	WebhookDeliveryHeader  = "X-Taskman-Delivery"
+)
+
+// Delivery tuning
+const (
+	webhookMaxAttempts    = 8
+	webhookBaseBackoff    = 30 * time.Second
+	webhookMaxBackoff     = 6 * time.Hour
+	webhookRequestTimeout = 10 * time.Second
+	webhookBatchSize      = 5
+	// A lease covers sending a whole batch, so it never runs out while the
+	// batch is still being worked through
+	webhookLease           = webhookBatchSize*webhookRequestTimeout + time.Minute
+	webhookMaxResponseBody = 1024
+)
+
+// WebhookService handles webhook subscriptions and delivers their events
+type WebhookService struct {
+	db     *gorm.DB
+	client *http.Client
+}
+
+// NewWebhookService creates a new webhook service. Unless allowPrivate is
+// set, deliveries to loopback, private and link-local addresses are refused
+// so webhooks cannot be used to reach internal services.
+func NewWebhookService(db *gorm.DB, allowPrivate bool) *WebhookService {
+	dialer := &net.Dialer{Timeout: webhookRequestTimeout}
+	if !allowPrivate {
+		dialer.Control = refusePrivateAddress
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &WebhookService{
+		db: db,
+		client: &http.Client{
+			Timeout:   webhookRequestTimeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// GetWebhooks retrieves an organization's webhooks
+func (s *WebhookService) GetWebhooks(orgID uuid.UUID) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := s.db.Where("org_id = ?", orgID).Order("created_at ASC").Find(&webhooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhookByID retrieves a webhook by ID
+func (s *WebhookService) GetWebhookByID(id uuid.UUID) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := s.db.Where("id = ?", id).First(&webhook).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("webhook not found")
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return &webhook, nil
+}
+
+// CreateWebhook creates a webhook, generating its secret when none is given
+func (s *WebhookService) CreateWebhook(orgID, createdBy uuid.UUID, req *models.WebhookCreateRequest) (*models.Webhook, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookEvents(req.Events); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	} else if len(secret) < 16 {
+		return nil, fmt.Errorf("%w: secret must be at least 16 characters", ErrInvalidWebhook)
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	webhook := &models.Webhook{
+		OrgID:     orgID,
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    models.StringList(req.Events),
+		Active:    active,
+		CreatedBy: createdBy,
+	}
+
+	if err := s.db.Create(webhook).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// UpdateWebhook updates a webhook
+func (s *WebhookService) UpdateWebhook(id uuid.UUID, req *models.WebhookUpdateRequest) (*models.Webhook, error) {
+	updates := map[string]interface{}{}
+
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return nil, err
+		}
+		updates["url"] = *req.URL
+	}
+	if req.Secret != nil {
+		if len(*req.Secret) < 16 {
+			return nil, fmt.Errorf("%w: secret must be at least 16 characters", ErrInvalidWebhook)
+		}
+		updates["secret"] = *req.Secret
+	}
+	if req.Events != nil {
+		if err := validateWebhookEvents(req.Events); err != nil {
+			return nil, err
+		}
+		updates["events"] = models.StringList(req.Events)
+	}
+	if req.Active != nil {
+		updates["active"] = *req.Active
+	}
+
+	if len(updates) > 0 {
+		if err := s.db.Model(&models.Webhook{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return nil, fmt.Errorf("failed to update webhook: %w", err)
+		}
+	}
+
+	return s.GetWebhookByID(id)
+}
+
+// DeleteWebhook deletes a webhook along with its delivery log
+func (s *WebhookService) DeleteWebhook(id uuid.UUID) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+		}
+		if err := tx.Where("id = ?", id).Delete(&models.Webhook{}).Error; err != nil {
+			return fmt.Errorf("failed to delete webhook: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetDeliveries retrieves a page of a webhook's deliveries, newest first,
+// along with the total matching the filter
+func (s *WebhookService) GetDeliveries(webhookID uuid.UUID, filter *models.WebhookDeliveryFilter) ([]models.WebhookDelivery, int64, error) {
+	query := s.db.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	var deliveries []models.WebhookDelivery
+	err := query.
+		Order("created_at DESC").
+		Limit(filter.PerPage).
+		Offset((filter.Page - 1) * filter.PerPage).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	return deliveries, total, nil
+}
+
+// Publish queues an event for every active webhook of the organization that
+// subscribes to it. Failures are logged rather than returned so that
+// webhooks never break the change being published.
+func (s *WebhookService) Publish(orgID uuid.UUID, event string, data interface{}) {
+	var webhooks []models.Webhook
+	if err := s.db.Where("org_id = ? AND active = ?", orgID, true).Find(&webhooks).Error; err != nil {
+		log.Printf("Failed to get webhooks for %s: %v", event, err)
+		return
+	}
+
+	var subscribed []models.Webhook
+	for _, webhook := range webhooks {
+		if webhook.Subscribes(event) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+	if len(subscribed) == 0 {
+		return
+	}
+
+	payload, err := encodeWebhookPayload(orgID, event, data)
+	if err != nil {
+		log.Printf("Failed to encode webhook payload for %s: %v", event, err)
+		return
+	}
+
+	now := time.Now()
+	deliveries := make([]models.WebhookDelivery, len(subscribed))
+	for i, webhook := range subscribed {
+		deliveries[i] = models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			Event:         event,
+			Payload:       payload,
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: &now,
+		}
+	}
+
+	if err := s.db.Create(&deliveries).Error; err != nil {
+		log.Printf("Failed to queue webhook deliveries for %s: %v", event, err)
+	}
+}
+
+// SendTestEvent delivers a test event to a webhook right away, whether or
+// not it is active or subscribed, and returns the delivery with its outcome.
+// A failed test is retried like any other delivery.
+func (s *WebhookService) SendTestEvent(webhook *models.Webhook, userID uuid.UUID) (*models.WebhookDelivery, error) {
+	payload, err := encodeWebhookPayload(webhook.OrgID, models.WebhookEventTest, map[string]interface{}{
+		"webhook_id": webhook.ID,
+		"user_id":    userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	// Leased from the start so the delivery job leaves it to this attempt
+	lease := time.Now().Add(webhookLease).Truncate(time.Microsecond)
+	delivery := &models.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		Event:         models.WebhookEventTest,
+		Payload:       payload,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: &lease,
+	}
+	if err := s.db.Create(delivery).Error; err != nil {
+		return nil, fmt.Errorf("failed to queue webhook delivery: %w", err)
+	}
+
+	if _, err := s.attempt(webhook, delivery, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// RunDeliveries attempts the pending deliveries that are due and returns how
+// many succeeded. Deliveries are leased before they are attempted, so running
+// on several replicas does not send them twice; they are claimed a few at a
+// time so each batch is sent well within its lease.
+func (s *WebhookService) RunDeliveries(now time.Time) (int, error) {
+	succeeded := 0
+	for {
+		var deliveries []models.WebhookDelivery
+		err := s.db.Raw(`
+			UPDATE webhook_deliveries SET next_attempt_at = ?
+			WHERE id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE status = ? AND next_attempt_at <= ?
+				ORDER BY next_attempt_at ASC
+				LIMIT ?
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *`,
+			now.Add(webhookLease).Truncate(time.Microsecond), models.WebhookDeliveryPending, now, webhookBatchSize,
+		).Scan(&deliveries).Error
+		if err != nil {
+			return succeeded, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+		}
+		if len(deliveries) == 0 {
+			return succeeded, nil
+		}
+
+		for i := range deliveries {
+			delivery := &deliveries[i]
+			var webhook models.Webhook
+			err := s.db.Where("id = ?", delivery.WebhookID).First(&webhook).Error
+			if err != nil && err != gorm.ErrRecordNotFound {
+				// Leave the delivery leased; it is picked up again once the lease expires
+				log.Printf("Failed to get webhook %s for delivery %s: %v", delivery.WebhookID, delivery.ID, err)
+				continue
+			}
+			if err == gorm.ErrRecordNotFound || !webhook.Active {
+				reason := "webhook is inactive"
+				if err != nil {
+					reason = "webhook no longer exists"
+				}
+				if err := s.abandon(delivery, reason); err != nil {
+					return succeeded, err
+				}
+				continue
+			}
+
+			recorded, err := s.attempt(&webhook, delivery, time.Now())
+			if err != nil {
+				return succeeded, err
+			}
+			if recorded && delivery.Status == models.WebhookDeliverySucceeded {
+				succeeded++
+			}
+		}
+
+		if len(deliveries) < webhookBatchSize {
+			return succeeded, nil
+		}
+	}
+}
+
+// attempt sends a delivery once and records the outcome, scheduling a retry
+// with exponential backoff when it fails and attempts remain. The outcome is
+// only recorded while the delivery is still leased to this attempt; it
+// reports whether it was.
+func (s *WebhookService) attempt(webhook *models.Webhook, delivery *models.WebhookDelivery, now time.Time) (bool, error) {
+	lease := delivery.NextAttemptAt
+	if lease == nil {
+		return false, fmt.Errorf("webhook delivery %s is not leased", delivery.ID)
+	}
+
+	code, body, sendErr := s.send(webhook, delivery, now)
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseCode = code
+	delivery.ResponseBody = body
+	delivery.Error = ""
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= webhookMaxAttempts:
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.Error = sendErr.Error()
+	default:
+		next := now.Add(webhookBackoff(delivery.Attempts))
+		delivery.Status = models.WebhookDeliveryPending
+		delivery.NextAttemptAt = &next
+		delivery.Error = sendErr.Error()
+	}
+
+	result := s.db.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, models.WebhookDeliveryPending, *lease).
+		Updates(map[string]interface{}{
+			"status":          delivery.Status,
+			"attempts":        delivery.Attempts,
+			"next_attempt_at": delivery.NextAttemptAt,
+			"last_attempt_at": delivery.LastAttemptAt,
+			"response_code":   delivery.ResponseCode,
+			"response_body":   delivery.ResponseBody,
+			"error":           delivery.Error,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record webhook delivery: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		log.Printf("Webhook delivery %s lost its lease before its outcome was recorded", delivery.ID)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// abandon marks a leased delivery as failed without sending it, for webhooks
+// that were deleted or deactivated after it was queued
+func (s *WebhookService) abandon(delivery *models.WebhookDelivery, reason string) error {
+	lease := delivery.NextAttemptAt
+	if lease == nil {
+		return fmt.Errorf("webhook delivery %s is not leased", delivery.ID)
+	}
+
+	err := s.db.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, models.WebhookDeliveryPending, *lease).
+		Updates(map[string]interface{}{
+			"status":          models.WebhookDeliveryFailed,
+			"next_attempt_at": nil,
+			"error":           reason,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// send posts a delivery's payload to its webhook. Any 2xx response counts
+// as delivered.
+func (s *WebhookService) send(webhook *models.Webhook, delivery *models.WebhookDelivery, now time.Time) (*int, string, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid webhook request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Taskman-Webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.String())
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxResponseBody))
+	code := resp.StatusCode
+	if code < 200 || code > 299 {
+		return &code, string(body), fmt.Errorf("webhook responded with status %d", code)
+	}
+
+	return &code, string(body), nil
+}
+
+// SignWebhookPayload returns the hex encoded HMAC-SHA256 signature receivers
+// should expect in the signature header for a timestamp and body
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff returns the wait before retrying after the given number of
+// failed attempts
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > webhookMaxBackoff {
+		backoff = webhookMaxBackoff
+	}
+	return backoff
+}
+
+// encodeWebhookPayload builds the JSON body for an event
+func encodeWebhookPayload(orgID uuid.UUID, event string, data interface{}) (models.RawJSON, error) {
+	payload, err := json.Marshal(models.WebhookPayload{
+		ID:        uuid.New(),
+		Event:     event,
+		OrgID:     orgID,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return models.RawJSON(payload), nil
+}
+
+// generateWebhookSecret creates a random signing secret
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// validateWebhookURL checks that a webhook URL is an absolute http(s) URL
+func validateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	if parsed.User != nil {
+		return fmt.Errorf("%w: url must not contain credentials", ErrInvalidWebhook)
+	}
+	return nil
+}
+
+// validateWebhookEvents checks that every event is one webhooks can subscribe to
+func validateWebhookEvents(events []string) error {
+	for _, event := range events {
+		known := false
+		for _, webhookEvent := range models.WebhookEvents {
+			if event == webhookEvent {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, event)
+		}
+	}
+	return nil
+}
+
+// refusePrivateAddress is a dialer control that refuses connections to
+// loopback, private, link-local and unspecified addresses. It runs after DNS
+// resolution, so hostnames that resolve to such addresses are refused too.
+func refusePrivateAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("webhook address %s is not allowed", host)
+	}
+
+	return nil
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"taskman-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidStatus is returned when a task status is not a column of the
+// project's workflow
+var ErrInvalidStatus = errors.New("invalid task status")
+
+// ErrInvalidWorkflow is returned when a workflow definition is rejected
+var ErrInvalidWorkflow = errors.New("invalid workflow")
+
+// ErrTransitionNotAllowed is returned when a task move is not permitted by
+// the project's transition table
+var ErrTransitionNotAllowed = errors.New("status transition not allowed")
+
+// TransitionNotAllowedError describes a refused move and the moves the actor
+// could make instead
+type TransitionNotAllowedError struct {
+	From          models.TaskStatus
+	To            models.TaskStatus
+	RequiresAdmin bool
+	Allowed       []models.TaskStatus
+}
+
+func (e *TransitionNotAllowedError) Error() string {
+	if e.RequiresAdmin {
+		return fmt.Sprintf("moving from %q to %q requires an admin", e.From, e.To)
+	}
+	return fmt.Sprintf("moving from %q to %q is not allowed", e.From, e.To)
+}
+
+func (e *TransitionNotAllowedError) Unwrap() error {
+	return ErrTransitionNotAllowed
+}
+
+// InvalidStatusError describes a status that is not part of a project's workflow
+type InvalidStatusError struct {
+	Status  models.TaskStatus
+	Allowed []models.TaskStatus
+}
+
+func (e *InvalidStatusError) Error() string {
+	allowed := make([]string, len(e.Allowed))
+	for i, status := range e.Allowed {
+		allowed[i] = string(status)
+	}
+	return fmt.Sprintf("status %q is not part of the project workflow (allowed: %s)", e.Status, strings.Join(allowed, ", "))
+}
+
+func (e *InvalidStatusError) Unwrap() error {
+	return ErrInvalidStatus
+}
+
+// WorkflowService handles per-project workflow columns
+type WorkflowService struct {
+	db *gorm.DB
+}
+
+// NewWorkflowService creates a new workflow service
+func NewWorkflowService(db *gorm.DB) *WorkflowService {
+	return &WorkflowService{db: db}
+}
+
+// GetWorkflow retrieves the columns and transitions of a project's workflow
+func (s *WorkflowService) GetWorkflow(projectID uuid.UUID) (*models.WorkflowResponse, error) {
+	columns, err := s.getColumns(s.db, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	transitions, err := s.getTransitions(s.db, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.WorkflowResponse{Columns: columns, Transitions: transitions}, nil
+}
+
+// CheckTransition checks that a task may move from one status to another in
+// the project. Staying in the same status is always allowed, as is every move
+// in a project that defines no transitions.
+func (s *WorkflowService) CheckTransition(projectID uuid.UUID, from, to models.TaskStatus, isAdmin bool) error {
+	if from == to {
+		return nil
+	}
+
+	transitions, err := s.getTransitions(s.db, projectID)
+	if err != nil {
+		return err
+	}
+
+	if len(transitions) == 0 {
+		return nil
+	}
+
+	refused := &TransitionNotAllowedError{From: from, To: to, Allowed: []models.TaskStatus{}}
+	for _, transition := range transitions {
+		if transition.FromKey != from {
+			continue
+		}
+
+		permitted := !transition.AdminOnly || isAdmin
+		if transition.ToKey == to {
+			if permitted {
+				return nil
+			}
+			refused.RequiresAdmin = true
+		}
+		if permitted {
+			refused.Allowed = append(refused.Allowed, transition.ToKey)
+		}
+	}
+
+	return refused
+}
+
+// GetColumn resolves a status to its column in the project's workflow
+func (s *WorkflowService) GetColumn(projectID uuid.UUID, status models.TaskStatus) (*models.WorkflowColumn, error) {
+	columns, err := s.getColumns(s.db, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range columns {
+		if columns[i].Key == status {
+			return &columns[i], nil
+		}
+	}
+
+	allowed := make([]models.TaskStatus, len(columns))
+	for i, column := range columns {
+		allowed[i] = column.Key
+	}
+
+	return nil, &InvalidStatusError{Status: status, Allowed: allowed}
+}
+
+// InitialStatus returns the status new tasks in the project start in: the
+// first column of the todo category
+func (s *WorkflowService) InitialStatus(projectID uuid.UUID) (models.TaskStatus, error) {
+	columns, err := s.getColumns(s.db, projectID)
+	if err != nil {
+		return "", err
+	}
+
+	for _, column := range columns {
+		if column.Category == models.WorkflowCategoryTodo {
+			return column.Key, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: project has no todo column", ErrInvalidWorkflow)
+}
+
+// UpdateWorkflow replaces the columns of a project's workflow. Tasks in
+// columns that are removed must be remapped to one of the new columns.
+func (s *WorkflowService) UpdateWorkflow(projectID uuid.UUID, req *models.WorkflowUpdateRequest) (*models.WorkflowResponse, error) {
+	if err := validateWorkflowColumns(req.Columns); err != nil {
+		return nil, err
+	}
+
+	newKeys := make(map[models.TaskStatus]bool)
+	for _, column := range req.Columns {
+		newKeys[column.Key] = true
+	}
+
+	if err := validateWorkflowTransitions(req.Transitions, newKeys); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		existing, err := s.getColumns(tx, projectID)
+		if err != nil {
+			return err
+		}
+
+		// Move tasks out of removed columns
+		for _, column := range existing {
+			if newKeys[column.Key] {
+				continue
+			}
+
+			var taskCount int64
+			if err := tx.Model(&models.Task{}).Where("project_id = ? AND status = ?", projectID, column.Key).Count(&taskCount).Error; err != nil {
+				return fmt.Errorf("failed to count tasks in column: %w", err)
+			}
+
+			target, ok := req.Remap[column.Key]
+			if !ok {
+				if taskCount > 0 {
+					return fmt.Errorf("%w: column %q still has %d task(s); provide a remap target", ErrInvalidWorkflow, column.Key, taskCount)
+				}
+				continue
+			}
+
+			if !newKeys[target] {
+				return fmt.Errorf("%w: remap target %q is not a column of the new workflow", ErrInvalidWorkflow, target)
+			}
+
+			// Soft-deleted tasks are moved too so they stay valid if restored
+			err := tx.Unscoped().Model(&models.Task{}).
+				Where("project_id = ? AND status = ?", projectID, column.Key).
+				Update("status", target).Error
+			if err != nil {
+				return fmt.Errorf("failed to remap tasks: %w", err)
+			}
+		}
+
+		if err := tx.Where("project_id = ?", projectID).Delete(&models.WorkflowColumn{}).Error; err != nil {
+			return fmt.Errorf("failed to remove workflow columns: %w", err)
+		}
+
+		columns := make([]models.WorkflowColumn, len(req.Columns))
+		for i, input := range req.Columns {
+			columns[i] = models.WorkflowColumn{
+				ProjectID:        projectID,
+				Key:              input.Key,
+				Name:             input.Name,
+				Category:         input.Category,
+				Position:         i,
+				WIPLimit:         input.WIPLimit,
+				AssigneeWIPLimit: input.AssigneeWIPLimit,
+			}
+		}
+
+		if err := tx.Create(&columns).Error; err != nil {
+			return fmt.Errorf("failed to create workflow columns: %w", err)
+		}
+
+		if req.Transitions == nil {
+			// Existing transitions are kept as they are, which only works
+			// while the columns they connect stay the same: new columns
+			// would be unreachable and removed ones would leave gaps
+			transitions, err := s.getTransitions(tx, projectID)
+			if err != nil {
+				return err
+			}
+			if len(transitions) > 0 && !sameColumnKeys(existing, newKeys) {
+				return fmt.Errorf("%w: columns changed; provide the transitions of the new workflow", ErrInvalidWorkflow)
+			}
+			return nil
+		}
+
+		if err := tx.Where("project_id = ?", projectID).Delete(&models.WorkflowTransition{}).Error; err != nil {
+			return fmt.Errorf("failed to remove workflow transitions: %w", err)
+		}
+
+		if len(req.Transitions) == 0 {
+			return nil
+		}
+
+		transitions := make([]models.WorkflowTransition, len(req.Transitions))
+		for i, input := range req.Transitions {
+			transitions[i] = models.WorkflowTransition{
+				ProjectID: projectID,
+				FromKey:   input.From,
+				ToKey:     input.To,
+				AdminOnly: input.AdminOnly,
+			}
+		}
+
+		if err := tx.Create(&transitions).Error; err != nil {
+			return fmt.Errorf("failed to create workflow transitions: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetWorkflow(projectID)
+}
+
+// sameColumnKeys reports whether columns have exactly the given keys
+func sameColumnKeys(columns []models.WorkflowColumn, keys map[models.TaskStatus]bool) bool {
+	if len(columns) != len(keys) {
+		return false
+	}
+	for _, column := range columns {
+		if !keys[column.Key] {
+			return false
+		}
+	}
+	return true
+}
+
+// getColumns retrieves the ordered columns of a project's workflow
+func (s *WorkflowService) getColumns(db *gorm.DB, projectID uuid.UUID) ([]models.WorkflowColumn, error) {
+	var columns []models.WorkflowColumn
+	err := db.Where("project_id = ?", projectID).Order("position ASC").Find(&columns).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow: %w", err)
+	}
+
+	return columns, nil
+}
+
+// getTransitions retrieves the transition table of a project's workflow
+func (s *WorkflowService) getTransitions(db *gorm.DB, projectID uuid.UUID) ([]models.WorkflowTransition, error) {
+	var transitions []models.WorkflowTransition
+	err := db.Where("project_id = ?", projectID).Order("created_at ASC").Find(&transitions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow transitions: %w", err)
+	}
+
+	return transitions, nil
+}
+
+// validateWorkflowTransitions checks that transitions connect distinct
+// columns of the workflow and are not repeated
+func validateWorkflowTransitions(transitions []models.WorkflowTransitionInput, keys map[models.TaskStatus]bool) error {
+	seen := make(map[[2]models.TaskStatus]bool)
+	for _, transition := range transitions {
+		if !keys[transition.From] || !keys[transition.To] {
+			return fmt.Errorf("%w: transition %q -> %q references an unknown column", ErrInvalidWorkflow, transition.From, transition.To)
+		}
+		if transition.From == transition.To {
+			return fmt.Errorf("%w: transition %q -> %q does not change status", ErrInvalidWorkflow, transition.From, transition.To)
+		}
+
+		pair := [2]models.TaskStatus{transition.From, transition.To}
+		if seen[pair] {
+			return fmt.Errorf("%w: duplicate transition %q -> %q", ErrInvalidWorkflow, transition.From, transition.To)
+		}
+		seen[pair] = true
+	}
+
+	return nil
+}
+
+// validateWorkflowColumns checks a workflow definition: keys must be unique
+// and non-empty, categories valid, WIP limits positive, and there must be at
+// least one todo and one done column
+func validateWorkflowColumns(columns []models.WorkflowColumnInput) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("%w: at least one column is required", ErrInvalidWorkflow)
+	}
+
+	seen := make(map[models.TaskStatus]bool)
+	categories := make(map[models.WorkflowCategory]bool)
+	for _, column := range columns {
+		key := strings.TrimSpace(string(column.Key))
+		if key == "" || key != string(column.Key) || len(key) > 50 {
+			return fmt.Errorf("%w: column key %q must be 1-50 characters without surrounding spaces", ErrInvalidWorkflow, column.Key)
+		}
+		if seen[column.Key] {
+			return fmt.Errorf("%w: duplicate column key %q", ErrInvalidWorkflow, column.Key)
+		}
+		seen[column.Key] = true
+
+		if strings.TrimSpace(column.Name) == "" {
+			return fmt.Errorf("%w: column %q needs a name", ErrInvalidWorkflow, column.Key)
+		}
+
+		if !column.Category.IsValid() {
+			return fmt.Errorf("%w: column %q has unknown category %q", ErrInvalidWorkflow, column.Key, column.Category)
+		}
+		categories[column.Category] = true
+
+		if column.WIPLimit != nil && *column.WIPLimit < 1 {
+			return fmt.Errorf("%w: column %q WIP limit must be at least 1", ErrInvalidWorkflow, column.Key)
+		}
+		if column.AssigneeWIPLimit != nil && *column.AssigneeWIPLimit < 1 {
+			return fmt.Errorf("%w: column %q assignee WIP limit must be at least 1", ErrInvalidWorkflow, column.Key)
+		}
+	}
+
+	if !categories[models.WorkflowCategoryTodo] || !categories[models.WorkflowCategoryDone] {
+		return fmt.Errorf("%w: a workflow needs at least one todo and one done column", ErrInvalidWorkflow)
+	}
+
+	return nil
+}
@@ -1,16 +1,23 @@
 package main
 
 import (
+	"context"
 	"log"
 	"strings"
+	"taskman-backend/internal/audit"
 	"taskman-backend/internal/auth"
 	"taskman-backend/internal/config"
 	"taskman-backend/internal/database"
 	"taskman-backend/internal/handlers"
+	"taskman-backend/internal/mailer"
 	"taskman-backend/internal/middleware"
+	"taskman-backend/internal/oidc"
+	"taskman-backend/internal/ratelimit"
+	"taskman-backend/internal/scheduler"
 	"taskman-backend/internal/services"
 
 	"time"
+	_ "time/tzdata" // timezones for notification quiet hours and digests
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -21,6 +28,9 @@ import (
 func main() {
 	// Load configuration
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
 	// Initialize database
 	err := database.Init(cfg)
@@ -31,6 +41,12 @@ func main() {
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
+		// Behind a load balancer every request comes from its address, so
+		// rate limits and login lockouts key on the forwarded client address
+		ProxyHeader:             fiber.HeaderXForwardedFor,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.TrustedProxies,
+		EnableIPValidation:      true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
@@ -57,35 +73,137 @@ func main() {
 	userService := services.NewUserService(database.GetDB())
 	orgService := services.NewOrganizationService(database.GetDB())
 	projectService := services.NewProjectService(database.GetDB())
-	taskService := services.NewTaskService(database.GetDB())
+	taskService := services.NewTaskService(database.GetDB(), cfg.BlockedTaskStatuses)
+	checklistService := services.NewChecklistService(database.GetDB())
+	dependencyService := services.NewDependencyService(database.GetDB())
+	workflowService := services.NewWorkflowService(database.GetDB())
+	labelService := services.NewLabelService(database.GetDB())
+	customFieldService := services.NewCustomFieldService(database.GetDB())
+	timeEntryService := services.NewTimeEntryService(database.GetDB())
+	mail := mailer.New(cfg)
+	notificationService := services.NewNotificationService(database.GetDB(), mail)
+	recurringTaskService := services.NewRecurringTaskService(database.GetDB(), taskService, notificationService)
+	webhookService := services.NewWebhookService(database.GetDB(), cfg.WebhookAllowPrivateNetworks)
+	apiTokenService := services.NewAPITokenService(database.GetDB())
+	ssoService := services.NewSSOService(database.GetDB())
+	twoFactorService := services.NewTwoFactorService(database.GetDB(), cfg.TOTPIssuer)
+	searchService := services.NewSearchService(database.GetDB())
+	savedViewService := services.NewSavedViewService(database.GetDB())
+	deadlineReminderService := services.NewDeadlineReminderService(database.GetDB(), notificationService, cfg.DeadlineReminderLead)
+	auditLogger := audit.NewLogger(database.GetDB())
 
 	// Initialize JWT manager
-	jwtManager := auth.NewJWTManager(cfg.JWTSecret, 24*time.Hour)
+	jwtManager, err := newJWTManager(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize token signing: %v", err)
+	}
+
+	// Throttle password guessing and request volume. The in-memory store is
+	// per replica.
+	limitStore := ratelimit.NewMemoryStore()
+	loginPolicy := ratelimit.DefaultLoginPolicy()
+	loginPolicy.MaxAccountFailures = cfg.LoginMaxAccountFailures
+	loginPolicy.MaxIPFailures = cfg.LoginMaxIPFailures
+	loginPolicy.Lockout = cfg.LoginLockout
+	loginGuard := ratelimit.NewLoginGuard(limitStore, loginPolicy)
+	limiter := ratelimit.NewLimiter(limitStore)
+	publicLimit := middleware.RateLimitMiddleware(limiter,
+		middleware.RateLimit{Name: "public", Limit: cfg.RateLimitPublic, Key: middleware.RateLimitByIP})
+	apiLimit := middleware.RateLimitMiddleware(limiter,
+		middleware.RateLimit{Name: "api", Limit: cfg.RateLimitUser, Key: middleware.RateLimitByClient},
+		middleware.RateLimit{Name: "api", Limit: cfg.RateLimitOrg, Key: middleware.RateLimitByOrg(orgService)})
+	exportLimit := middleware.RateLimitMiddleware(limiter,
+		middleware.RateLimit{Name: "export", Limit: cfg.RateLimitExport, Key: middleware.RateLimitByClient})
+	// Timesheets count as exports when downloaded as CSV
+	timesheetLimit := func(c *fiber.Ctx) error {
+		if c.Query("format") == "csv" {
+			return exportLimit(c)
+		}
+		return c.Next()
+	}
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(userService, jwtManager)
-	orgHandler := handlers.NewOrganizationHandler(orgService)
-	projectHandler := handlers.NewProjectHandler(projectService, orgService)
-	taskHandler := handlers.NewTaskHandler(taskService, projectService, orgService)
-	wsHandler := handlers.NewWebSocketHandler()
+	authHandler := handlers.NewAuthHandler(userService, twoFactorService, jwtManager, loginGuard, auditLogger, mail, cfg.AppURL)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService, userService, jwtManager, loginGuard, auditLogger)
+	orgHandler := handlers.NewOrganizationHandler(orgService, auditLogger)
+	projectHandler := handlers.NewProjectHandler(projectService, orgService, auditLogger, notificationService, webhookService)
+	taskHandler := handlers.NewTaskHandler(taskService, projectService, orgService, notificationService, webhookService)
+	wsHandler := handlers.NewWebSocketHandler(taskService, projectService, orgService, notificationService, webhookService)
+	auditHandler := handlers.NewAuditHandler(auditLogger, orgService)
+	checklistHandler := handlers.NewChecklistHandler(checklistService, taskService, orgService)
+	dependencyHandler := handlers.NewDependencyHandler(dependencyService, taskService, orgService)
+	workflowHandler := handlers.NewWorkflowHandler(workflowService, projectService, orgService)
+	labelHandler := handlers.NewLabelHandler(labelService, orgService)
+	customFieldHandler := handlers.NewCustomFieldHandler(customFieldService, orgService)
+	timeEntryHandler := handlers.NewTimeEntryHandler(timeEntryService, taskService, orgService)
+	recurringTaskHandler := handlers.NewRecurringTaskHandler(recurringTaskService, projectService, orgService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService, orgService)
+	apiTokenHandler := handlers.NewAPITokenHandler(apiTokenService, orgService, auditLogger)
+	searchHandler := handlers.NewSearchHandler(searchService, orgService)
+	savedViewHandler := handlers.NewSavedViewHandler(savedViewService, projectService, orgService)
+
+	// Single sign-on providers
+	var ssoProviders []*oidc.Provider
+	if cfg.OIDCIssuer != "" {
+		ssoProviders = append(ssoProviders, oidc.NewProvider(oidc.Config{
+			Name:         cfg.OIDCProviderName,
+			Issuer:       cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       cfg.OIDCScopes,
+		}))
+	}
+	ssoHandler := handlers.NewSSOHandler(ssoService, ssoProviders, jwtManager, auditLogger)
+
+	// Push new notifications to their users' open WebSocket connections
+	notificationService.Subscribe(wsHandler.PushNotification)
 
 	// API routes
 	api := app.Group("/api/v1")
 
 	// Public routes
-	api.Post("/auth/register", authHandler.Register)
-	api.Post("/auth/login", authHandler.Login)
-	api.Post("/auth/refresh", authHandler.RefreshToken)
+	api.Post("/auth/register", publicLimit, authHandler.Register)
+	api.Post("/auth/register/confirm", publicLimit, authHandler.ConfirmRegistration)
+	api.Post("/auth/login", publicLimit, authHandler.Login)
+	api.Post("/auth/refresh", publicLimit, authHandler.RefreshToken)
+	api.Post("/auth/2fa/verify", publicLimit, twoFactorHandler.Verify)
+	api.Get("/auth/sso", publicLimit, ssoHandler.GetProviders)
+	api.Get("/auth/sso/:provider/login", publicLimit, ssoHandler.Login)
+	api.Get("/auth/sso/:provider/callback", publicLimit, ssoHandler.Callback)
+	api.Post("/auth/sso/:provider/callback", publicLimit, ssoHandler.Callback)
 
 	// Protected routes
 	protected := api.Group("")
-	protected.Use(middleware.AuthMiddleware(jwtManager))
+	protected.Use(middleware.AuthMiddleware(jwtManager, apiTokenService))
+	protected.Use(apiLimit)
+	protected.Use(middleware.OrgPolicyMiddleware(orgService))
 
 	// Auth routes
 	protected.Get("/auth/me", authHandler.GetMe)
 	protected.Get("/auth/profile", authHandler.GetProfile)
 	protected.Put("/auth/profile", authHandler.UpdateProfile)
 
+	// Two-factor authentication routes
+	protected.Get("/auth/2fa", twoFactorHandler.GetStatus)
+	protected.Post("/auth/2fa/enroll", twoFactorHandler.Enroll)
+	protected.Post("/auth/2fa/enable", twoFactorHandler.Enable)
+	protected.Post("/auth/2fa/disable", twoFactorHandler.Disable)
+	protected.Post("/auth/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+
+	// API token routes
+	protected.Get("/auth/tokens", apiTokenHandler.GetTokens)
+	protected.Post("/auth/tokens", apiTokenHandler.CreateToken)
+	protected.Delete("/auth/tokens/:tokenId", apiTokenHandler.DeleteToken)
+
+	// Current user's work across organizations
+	protected.Get("/me/tasks", taskHandler.GetMyTasks)
+	protected.Get("/me/views", savedViewHandler.GetMyTaskViews)
+	protected.Post("/me/views", savedViewHandler.CreateMyTaskView)
+	protected.Put("/me/views/:viewId", savedViewHandler.UpdateMyTaskView)
+	protected.Delete("/me/views/:viewId", savedViewHandler.DeleteMyTaskView)
+
 	// Organization routes
 	protected.Post("/organizations", orgHandler.CreateOrganization)
 	protected.Post("/organizations/join", orgHandler.JoinOrganization)
@@ -93,7 +211,44 @@ func main() {
 	protected.Get("/organizations/:orgId", orgHandler.GetOrganization)
 	protected.Get("/organizations/:orgId/members", orgHandler.GetOrganizationMembers)
 	protected.Delete("/organizations/:orgId/members/:memberId", orgHandler.RemoveMember)
+	protected.Put("/organizations/:orgId/members/:memberId/role", orgHandler.UpdateMemberRole)
 	protected.Put("/organizations/:orgId", orgHandler.UpdateOrganization)
+	protected.Post("/organizations/:orgId/invite-code", orgHandler.RegenerateInviteCode)
+
+	// Audit routes
+	protected.Get("/organizations/:orgId/audit", auditHandler.GetAuditLog)
+	protected.Get("/organizations/:orgId/audit/export", exportLimit, auditHandler.ExportAuditLog)
+
+	// Search routes
+	protected.Get("/organizations/:orgId/search", searchHandler.Search)
+
+	// Label routes
+	protected.Get("/organizations/:orgId/labels", labelHandler.GetLabels)
+	protected.Post("/organizations/:orgId/labels", labelHandler.CreateLabel)
+	protected.Put("/organizations/:orgId/labels/:labelId", labelHandler.UpdateLabel)
+	protected.Delete("/organizations/:orgId/labels/:labelId", labelHandler.DeleteLabel)
+
+	// Custom field routes
+	protected.Get("/organizations/:orgId/custom-fields", customFieldHandler.GetCustomFields)
+	protected.Post("/organizations/:orgId/custom-fields", customFieldHandler.CreateCustomField)
+	protected.Put("/organizations/:orgId/custom-fields/:fieldId", customFieldHandler.UpdateCustomField)
+	protected.Delete("/organizations/:orgId/custom-fields/:fieldId", customFieldHandler.DeleteCustomField)
+
+	// Webhook routes
+	protected.Get("/organizations/:orgId/webhooks", webhookHandler.GetWebhooks)
+	protected.Post("/organizations/:orgId/webhooks", webhookHandler.CreateWebhook)
+	protected.Put("/organizations/:orgId/webhooks/:webhookId", webhookHandler.UpdateWebhook)
+	protected.Delete("/organizations/:orgId/webhooks/:webhookId", webhookHandler.DeleteWebhook)
+	protected.Get("/organizations/:orgId/webhooks/:webhookId/deliveries", webhookHandler.GetDeliveries)
+	protected.Post("/organizations/:orgId/webhooks/:webhookId/test", webhookHandler.SendTestEvent)
+
+	// Service account routes
+	protected.Get("/organizations/:orgId/service-accounts", apiTokenHandler.GetServiceAccounts)
+	protected.Post("/organizations/:orgId/service-accounts", apiTokenHandler.CreateServiceAccount)
+	protected.Delete("/organizations/:orgId/service-accounts/:accountId", apiTokenHandler.DeleteServiceAccount)
+	protected.Get("/organizations/:orgId/service-accounts/:accountId/tokens", apiTokenHandler.GetServiceAccountTokens)
+	protected.Post("/organizations/:orgId/service-accounts/:accountId/tokens", apiTokenHandler.CreateServiceAccountToken)
+	protected.Delete("/organizations/:orgId/service-accounts/:accountId/tokens/:tokenId", apiTokenHandler.DeleteServiceAccountToken)
 
 	// Project routes
 	protected.Post("/organizations/:orgId/projects", projectHandler.CreateProject)
@@ -103,6 +258,18 @@ func main() {
 	protected.Delete("/organizations/:orgId/projects/:projectId", projectHandler.DeleteProject)
 	protected.Patch("/organizations/:orgId/projects/:projectId/move", projectHandler.MoveProject)
 	protected.Patch("/organizations/:orgId/projects/move", projectHandler.BulkMoveProjects)
+	protected.Post("/organizations/:orgId/projects/:projectId/clone", projectHandler.CloneProject)
+	protected.Post("/organizations/:orgId/projects/:projectId/template", projectHandler.CreateTemplate)
+
+	// Project template routes
+	protected.Get("/organizations/:orgId/templates", projectHandler.GetTemplates)
+	protected.Get("/organizations/:orgId/templates/:templateId", projectHandler.GetTemplate)
+	protected.Post("/organizations/:orgId/templates/:templateId/instantiate", projectHandler.InstantiateTemplate)
+	protected.Delete("/organizations/:orgId/templates/:templateId", projectHandler.DeleteTemplate)
+
+	// Workflow routes
+	protected.Get("/organizations/:orgId/projects/:projectId/workflow", workflowHandler.GetWorkflow)
+	protected.Put("/organizations/:orgId/projects/:projectId/workflow", workflowHandler.UpdateWorkflow)
 
 	// Task routes
 	protected.Post("/organizations/:orgId/projects/:projectId/tasks", taskHandler.CreateTask)
@@ -112,18 +279,144 @@ func main() {
 	protected.Delete("/organizations/:orgId/projects/:projectId/tasks/:taskId", taskHandler.DeleteTask)
 	protected.Patch("/organizations/:orgId/projects/:projectId/tasks/:taskId/move", taskHandler.MoveTask)
 	protected.Patch("/organizations/:orgId/projects/:projectId/tasks/move", taskHandler.BulkMoveTasks)
+	protected.Get("/organizations/:orgId/projects/:projectId/tasks/:taskId/subtasks", taskHandler.GetSubtasks)
+
+	// Saved view routes
+	protected.Get("/organizations/:orgId/projects/:projectId/views", savedViewHandler.GetProjectViews)
+	protected.Post("/organizations/:orgId/projects/:projectId/views", savedViewHandler.CreateProjectView)
+	protected.Put("/organizations/:orgId/projects/:projectId/views/:viewId", savedViewHandler.UpdateProjectView)
+	protected.Delete("/organizations/:orgId/projects/:projectId/views/:viewId", savedViewHandler.DeleteProjectView)
+
+	// Recurring task routes
+	protected.Get("/organizations/:orgId/projects/:projectId/recurring-tasks", recurringTaskHandler.GetRecurringTasks)
+	protected.Post("/organizations/:orgId/projects/:projectId/recurring-tasks", recurringTaskHandler.CreateRecurringTask)
+	protected.Put("/organizations/:orgId/projects/:projectId/recurring-tasks/:recurringId", recurringTaskHandler.UpdateRecurringTask)
+	protected.Delete("/organizations/:orgId/projects/:projectId/recurring-tasks/:recurringId", recurringTaskHandler.DeleteRecurringTask)
+
+	// Checklist routes
+	protected.Get("/organizations/:orgId/projects/:projectId/tasks/:taskId/checklist", checklistHandler.GetChecklist)
+	protected.Post("/organizations/:orgId/projects/:projectId/tasks/:taskId/checklist", checklistHandler.AddChecklistItem)
+	protected.Patch("/organizations/:orgId/projects/:projectId/tasks/:taskId/checklist/reorder", checklistHandler.ReorderChecklist)
+	protected.Put("/organizations/:orgId/projects/:projectId/tasks/:taskId/checklist/:itemId", checklistHandler.UpdateChecklistItem)
+	protected.Delete("/organizations/:orgId/projects/:projectId/tasks/:taskId/checklist/:itemId", checklistHandler.DeleteChecklistItem)
+
+	// Dependency routes
+	protected.Get("/organizations/:orgId/projects/:projectId/tasks/:taskId/dependencies", dependencyHandler.GetDependencies)
+	protected.Post("/organizations/:orgId/projects/:projectId/tasks/:taskId/dependencies", dependencyHandler.AddDependency)
+	protected.Delete("/organizations/:orgId/projects/:projectId/tasks/:taskId/dependencies/:blockedById", dependencyHandler.RemoveDependency)
+
+	// Time tracking routes
+	protected.Get("/timer", timeEntryHandler.GetRunningTimer)
+	protected.Post("/timer/stop", timeEntryHandler.StopTimer)
+	protected.Post("/organizations/:orgId/projects/:projectId/tasks/:taskId/timer/start", timeEntryHandler.StartTimer)
+	protected.Get("/organizations/:orgId/projects/:projectId/tasks/:taskId/time-entries", timeEntryHandler.GetTimeEntries)
+	protected.Post("/organizations/:orgId/projects/:projectId/tasks/:taskId/time-entries", timeEntryHandler.CreateTimeEntry)
+	protected.Put("/organizations/:orgId/projects/:projectId/tasks/:taskId/time-entries/:entryId", timeEntryHandler.UpdateTimeEntry)
+	protected.Delete("/organizations/:orgId/projects/:projectId/tasks/:taskId/time-entries/:entryId", timeEntryHandler.DeleteTimeEntry)
+	protected.Get("/organizations/:orgId/timesheet", timesheetLimit, timeEntryHandler.GetTimesheet)
+
+	// Notification routes
+	protected.Get("/notifications", notificationHandler.GetNotifications)
+	protected.Get("/notifications/preferences", notificationHandler.GetPreferences)
+	protected.Put("/notifications/preferences", notificationHandler.UpdatePreferences)
+	protected.Post("/notifications/read-all", notificationHandler.MarkAllNotificationsRead)
+	protected.Post("/notifications/:notificationId/read", notificationHandler.MarkNotificationRead)
 
 	// WebSocket route
 	protected.Get("/ws", wsHandler.HandleWebSocket)
 
+	// Public keys for verifying tokens
+	app.Get("/.well-known/jwks.json", authHandler.GetJWKS)
+
 	// Health check
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "ok"})
 	})
 
+	// Background jobs
+	jobs := scheduler.New()
+	jobs.Add(scheduler.Job{
+		Name:     "recurring-tasks",
+		Interval: cfg.RecurringTaskInterval,
+		Run: func(now time.Time) error {
+			created, err := recurringTaskService.RunDue(now)
+			if created > 0 {
+				log.Printf("Created %d recurring task(s)", created)
+			}
+			return err
+		},
+	})
+	jobs.Add(scheduler.Job{
+		Name:     "deadline-reminders",
+		Interval: cfg.DeadlineReminderInterval,
+		Run: func(now time.Time) error {
+			sent, err := deadlineReminderService.RunReminders(now)
+			if sent > 0 {
+				log.Printf("Sent %d deadline reminder(s)", sent)
+			}
+			return err
+		},
+	})
+	jobs.Add(scheduler.Job{
+		Name:     "notification-emails",
+		Interval: cfg.NotificationEmailInterval,
+		Run: func(now time.Time) error {
+			sent, err := notificationService.RunEmails(now)
+			if sent > 0 {
+				log.Printf("Sent %d notification email(s)", sent)
+			}
+			return err
+		},
+	})
+	jobs.Add(scheduler.Job{
+		Name:     "webhook-deliveries",
+		Interval: cfg.WebhookDeliveryInterval,
+		Run: func(now time.Time) error {
+			delivered, err := webhookService.RunDeliveries(now)
+			if delivered > 0 {
+				log.Printf("Delivered %d webhook event(s)", delivered)
+			}
+			return err
+		},
+	})
+
+	if cfg.RunScheduler {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		jobs.Start(ctx)
+	}
+
 	// Start server
 	log.Printf("Starting server on port %s", cfg.Port)
 	if err := app.Listen(":" + cfg.Port); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// newJWTManager signs tokens with the configured private key, or falls back
+// to the shared secret
+func newJWTManager(cfg *config.Config) (*auth.JWTManager, error) {
+	if cfg.JWTSigningKeyFile == "" {
+		if cfg.JWTSecret == config.DefaultJWTSecret {
+			log.Println("Signing tokens with the default development secret")
+		}
+		return auth.NewJWTManager(cfg.JWTSecret, cfg.JWTExpiry), nil
+	}
+
+	signingKey, err := auth.LoadKey(cfg.JWTSigningKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var verificationKeys []*auth.Key
+	for _, path := range cfg.JWTVerificationKeyFiles {
+		key, err := auth.LoadKey(path)
+		if err != nil {
+			return nil, err
+		}
+		verificationKeys = append(verificationKeys, key)
+	}
+
+	log.Printf("Signing tokens with key %s", signingKey.ID)
+	return auth.NewKeyedJWTManager(signingKey, verificationKeys, cfg.JWTExpiry)
+}
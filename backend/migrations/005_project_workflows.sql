@@ -0,0 +1,35 @@
+-- Configurable workflow columns per project
+-- Task statuses are no longer a fixed set: each project defines its own
+-- ordered columns and a task's status is the key of one of them.
+-- Project statuses are out of scope and keep their CHECK constraint: they are
+-- the columns of an organization's project board, which no single project's
+-- workflow can define.
+
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_status_check;
+ALTER TABLE tasks ALTER COLUMN status TYPE VARCHAR(50);
+
+CREATE TABLE IF NOT EXISTS workflow_columns (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    key VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    category VARCHAR(10) NOT NULL CHECK (category IN ('todo', 'doing', 'done')),
+    position INTEGER NOT NULL DEFAULT 0,
+    wip_limit INTEGER CHECK (wip_limit IS NULL OR wip_limit > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (project_id, key)
+);
+
+-- Give every existing project the default workflow
+INSERT INTO workflow_columns (project_id, key, name, category, position)
+SELECT p.id, d.key, d.name, d.category, d.position
+FROM projects p
+CROSS JOIN (VALUES
+    ('not-started', 'Not Started', 'todo', 0),
+    ('in-progress', 'In Progress', 'doing', 1),
+    ('done', 'Done', 'done', 2)
+) AS d(key, name, category, position)
+ON CONFLICT (project_id, key) DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS idx_workflow_columns_project_id ON workflow_columns(project_id);
@@ -0,0 +1,32 @@
+-- Workflow transitions
+-- Allowed status moves between the columns of a project's workflow. A project
+-- without transitions allows every move.
+
+CREATE TABLE IF NOT EXISTS workflow_transitions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    from_key VARCHAR(50) NOT NULL,
+    to_key VARCHAR(50) NOT NULL,
+    admin_only BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (project_id, from_key, to_key),
+    CHECK (from_key <> to_key)
+);
+
+-- Give every project still on the default columns the default transitions
+INSERT INTO workflow_transitions (project_id, from_key, to_key, admin_only)
+SELECT p.id, d.from_key, d.to_key, d.admin_only
+FROM projects p
+CROSS JOIN (VALUES
+    ('not-started', 'in-progress', FALSE),
+    ('not-started', 'done', FALSE),
+    ('in-progress', 'not-started', FALSE),
+    ('in-progress', 'done', FALSE),
+    ('done', 'in-progress', TRUE)
+) AS d(from_key, to_key, admin_only)
+WHERE (
+    SELECT array_agg(wc.key ORDER BY wc.key) FROM workflow_columns wc WHERE wc.project_id = p.id
+) = ARRAY['done', 'in-progress', 'not-started']::VARCHAR[]
+ON CONFLICT (project_id, from_key, to_key) DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS idx_workflow_transitions_project_id ON workflow_transitions(project_id);
@@ -0,0 +1,27 @@
+-- Card ranks
+-- Tasks and projects are ordered within their board column by a
+-- lexicographic rank so cards keep the position they were dragged to.
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS rank VARCHAR(64) COLLATE "C" NOT NULL DEFAULT '';
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS rank VARCHAR(64) COLLATE "C" NOT NULL DEFAULT '';
+
+-- Rank existing cards in their previous order (newest first). Hex digits are
+-- a subset of the rank alphabet and sort the same way.
+UPDATE tasks t
+SET rank = rtrim(lpad(to_hex(r.n * 4096), 8, '0'), '0')
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY project_id, status ORDER BY created_at DESC) AS n
+    FROM tasks
+) r
+WHERE t.id = r.id AND t.rank = '';
+
+UPDATE projects p
+SET rank = rtrim(lpad(to_hex(r.n * 4096), 8, '0'), '0')
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY org_id, status ORDER BY created_at DESC) AS n
+    FROM projects
+) r
+WHERE p.id = r.id AND p.rank = '';
+
+CREATE INDEX IF NOT EXISTS idx_tasks_project_status_rank ON tasks(project_id, status, rank);
+CREATE INDEX IF NOT EXISTS idx_projects_org_status_rank ON projects(org_id, status, rank);
@@ -0,0 +1,6 @@
+-- Assignee WIP limits
+-- Besides the limit on all tasks in a workflow column, a column may cap the
+-- tasks each assignee has in it.
+
+ALTER TABLE workflow_columns ADD COLUMN IF NOT EXISTS assignee_wip_limit INTEGER
+    CHECK (assignee_wip_limit IS NULL OR assignee_wip_limit > 0);
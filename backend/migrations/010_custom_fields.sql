@@ -0,0 +1,23 @@
+-- Custom fields
+-- Organization-defined fields on tasks and projects. Values live in a JSONB
+-- object on the task or project, keyed by the field key.
+
+CREATE TABLE IF NOT EXISTS custom_fields (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    entity VARCHAR(10) NOT NULL CHECK (entity IN ('task', 'project')),
+    key VARCHAR(50) NOT NULL CHECK (key ~ '^[a-z0-9_]+$'),
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(10) NOT NULL CHECK (type IN ('text', 'number', 'date', 'select', 'user')),
+    options JSONB NOT NULL DEFAULT '[]',
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (org_id, entity, key)
+);
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_tasks_custom_fields ON tasks USING GIN (custom_fields);
+CREATE INDEX IF NOT EXISTS idx_projects_custom_fields ON projects USING GIN (custom_fields);
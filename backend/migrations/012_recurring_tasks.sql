@@ -0,0 +1,38 @@
+-- Recurring tasks
+-- Task templates with a recurrence rule (a subset of RRULE). A background
+-- job creates a task for every occurrence; tasks remember the occurrence
+-- they were created for so no occurrence is created twice.
+
+CREATE TABLE IF NOT EXISTS recurring_tasks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    created_by UUID NOT NULL REFERENCES users(id),
+    name VARCHAR(200) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    priority VARCHAR(10) NOT NULL DEFAULT 'medium'
+        CHECK (priority IN ('low', 'medium', 'high', 'urgent')),
+    story_points INTEGER CHECK (story_points IS NULL OR story_points >= 0),
+    estimate_minutes INTEGER CHECK (estimate_minutes IS NULL OR estimate_minutes >= 0),
+    assignee_ids JSONB NOT NULL DEFAULT '[]',
+    label_ids JSONB NOT NULL DEFAULT '[]',
+    frequency VARCHAR(10) NOT NULL CHECK (frequency IN ('daily', 'weekly', 'monthly')),
+    interval INTEGER NOT NULL DEFAULT 1 CHECK (interval >= 1),
+    by_weekday JSONB NOT NULL DEFAULT '[]',
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    until TIMESTAMP WITH TIME ZONE,
+    count INTEGER CHECK (count IS NULL OR count >= 1),
+    due_after_minutes INTEGER CHECK (due_after_minutes IS NULL OR due_after_minutes >= 0),
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    occurrence_count INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS recurring_task_id UUID REFERENCES recurring_tasks(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS occurrence_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_recurring_tasks_project_id ON recurring_tasks(project_id);
+CREATE INDEX IF NOT EXISTS idx_recurring_tasks_next_run_at ON recurring_tasks(next_run_at) WHERE next_run_at IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tasks_recurrence_occurrence ON tasks(recurring_task_id, occurrence_at);
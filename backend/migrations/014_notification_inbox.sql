@@ -0,0 +1,6 @@
+-- Notification inbox
+-- Records who caused each notification and indexes the unread inbox.
+
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS actor_id UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_unread ON notifications(user_id, created_at) WHERE read_at IS NULL;
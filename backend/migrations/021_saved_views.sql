@@ -0,0 +1,21 @@
+-- Saved views
+-- Named task list setups (filters, sort, grouping and visible columns). A
+-- view of a project's task list may be shared with the organization; a view
+-- without a project belongs to its owner's /me/tasks list.
+
+CREATE TABLE IF NOT EXISTS saved_views (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    project_id UUID REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    shared BOOLEAN NOT NULL DEFAULT FALSE CHECK (NOT shared OR project_id IS NOT NULL),
+    filters JSONB NOT NULL DEFAULT '{}',
+    sort VARCHAR(50) NOT NULL DEFAULT '',
+    group_by VARCHAR(50) NOT NULL DEFAULT '',
+    columns JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_views_user_id ON saved_views(user_id);
+CREATE INDEX IF NOT EXISTS idx_saved_views_project_id ON saved_views(project_id);